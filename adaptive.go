@@ -0,0 +1,310 @@
+package paypal
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	ADAPTIVE_SANDBOX_URL    = "https://svcs.sandbox.paypal.com/AdaptivePayments/"
+	ADAPTIVE_PRODUCTION_URL = "https://svcs.paypal.com/AdaptivePayments/"
+	// Application id every sandbox account can use
+	ADAPTIVE_SANDBOX_APP_ID = "APP-80W284485P519543T"
+)
+
+// Who pays PayPal's fees on an Adaptive payment
+const (
+	FEES_PAYER_SENDER           = "SENDER"
+	FEES_PAYER_PRIMARY_RECEIVER = "PRIMARYRECEIVER"
+	FEES_PAYER_EACH_RECEIVER    = "EACHRECEIVER"
+	FEES_PAYER_SECONDARY_ONLY   = "SECONDARYONLY"
+)
+
+// Receivers an Adaptive payment can have; a chained payment has one
+// primary and up to five secondary receivers
+const MaxAdaptiveReceivers = 6
+
+// Returned by Pay before calling PayPal when a chained payment's receivers
+// don't add up
+type ChainedPaymentError struct {
+	Detail string
+}
+
+func (e *ChainedPaymentError) Error() string {
+	return "PayPal chained payment invalid: " + e.Detail
+}
+
+// Language of the error messages, sent as requestEnvelope.errorLanguage
+// unless the request sets its own
+const ADAPTIVE_ERROR_LANGUAGE = "en_US"
+
+// Calls the Adaptive Payments API with a PayPalClient's credentials, http
+// client, rate limiter and metrics
+type AdaptiveClient struct {
+	client        *PayPalClient
+	applicationId string
+}
+
+// applicationId is the app id PayPal issued for the integration, or
+// ADAPTIVE_SANDBOX_APP_ID in the sandbox
+func NewAdaptiveClient(client *PayPalClient, applicationId string) *AdaptiveClient {
+	return &AdaptiveClient{client: client, applicationId: applicationId}
+}
+
+type AdaptiveReceiver struct {
+	Email  string
+	Amount float64
+	// Marks the primary receiver of a chained payment
+	Primary bool
+}
+
+// A single receiver makes a simple payment, several a parallel payment, and
+// a Primary one among them a chained payment
+type AdaptivePay struct {
+	CurrencyCode string
+	Receivers    []AdaptiveReceiver
+	ReturnUrl    string
+	CancelUrl    string
+	// Optional
+	FeesPayer  string
+	Memo       string
+	TrackingId string
+}
+
+type AdaptiveResponse struct {
+	Ack           string
+	CorrelationId string
+	Timestamp     string
+	Values        url.Values
+	// Non-fatal problems noticed by the client, e.g. truncated fields
+	Warnings []string
+
+	usedSandbox bool
+}
+
+// Where to send the buyer to approve the payment made by Pay
+func (r *AdaptiveResponse) PayUrl() string {
+	query := url.Values{}
+	query.Set("cmd", "_ap-payment")
+	query.Add("paykey", r.Values.Get("payKey"))
+	checkoutUrl := CHECKOUT_PRODUCTION_URL
+	if r.usedSandbox {
+		checkoutUrl = CHECKOUT_SANDBOX_URL
+	}
+	return fmt.Sprintf("%s?%s", checkoutUrl, query.Encode())
+}
+
+// Sends an Adaptive Payments operation, e.g. "Pay", with the X-PAYPAL-*
+// authentication headers and the NV data format. API errors are returned
+// as *PayPalError. WithContext, WithSubject, WithCredentials and WithDryRun
+// apply as they do to NVP calls.
+func (ac *AdaptiveClient) PerformRequest(operation string, values url.Values, opts ...CallOption) (*AdaptiveResponse, error) {
+	pClient := ac.client
+	endpoint := ADAPTIVE_PRODUCTION_URL
+	if pClient.usesSandbox {
+		endpoint = ADAPTIVE_SANDBOX_URL
+	}
+
+	settings := pClient.callSettings(opts)
+	if err := pClient.checkGranted(operation); err != nil {
+		return nil, err
+	}
+	dryRun := pClient.dryRun || settings.dryRun
+	if !dryRun {
+		if err := pClient.checkLive(operation); err != nil {
+			return nil, err
+		}
+	}
+	values, warnings, err := enforceFieldLengths(values, pClient.truncateFields)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values.Get("requestEnvelope.errorLanguage")) == 0 {
+		values = copyValues(values)
+		values.Set("requestEnvelope.errorLanguage", ADAPTIVE_ERROR_LANGUAGE)
+	}
+	if dryRun {
+		return nil, &DryRunError{
+			Method:   operation,
+			Endpoint: endpoint + operation,
+			Values:   copyValues(values),
+			Body:     values.Encode(),
+			Warnings: warnings,
+		}
+	}
+
+	request, err := http.NewRequest("POST", endpoint+operation, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request = request.WithContext(settings.ctx)
+	request.Header.Set("Content-Type", REQUEST_CONTENT_TYPE)
+	request.Header.Set("X-PAYPAL-SECURITY-USERID", settings.credentials.Username)
+	request.Header.Set("X-PAYPAL-SECURITY-PASSWORD", settings.credentials.Password)
+	request.Header.Set("X-PAYPAL-SECURITY-SIGNATURE", settings.credentials.Signature)
+	request.Header.Set("X-PAYPAL-APPLICATION-ID", ac.applicationId)
+	request.Header.Set("X-PAYPAL-REQUEST-DATA-FORMAT", "NV")
+	request.Header.Set("X-PAYPAL-RESPONSE-DATA-FORMAT", "NV")
+	if len(settings.subject) != 0 {
+		request.Header.Set("X-PAYPAL-SECURITY-SUBJECT", settings.subject)
+	}
+
+	if err := pClient.waitForLimits(settings.ctx, endpoint, settings.credentials.Username, settings.subject); err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	httpResponse, err := pClient.client.Do(request)
+	if err != nil {
+		pClient.reportLatency(operation, started)
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+
+	snippet := &snippetWriter{limit: TRANSPORT_ERROR_SNIPPET_LENGTH}
+	responseValues, err := DecodeNVP(io.TeeReader(httpResponse.Body, snippet))
+	pClient.reportLatency(operation, started)
+
+	if len(responseValues.Get("responseEnvelope.ack")) == 0 {
+		if err == nil {
+			err = errNotNVP
+		}
+		return nil, &TransportError{StatusCode: httpResponse.StatusCode, Snippet: snippet.String(), Err: err}
+	}
+
+	response := &AdaptiveResponse{
+		Ack:           responseValues.Get("responseEnvelope.ack"),
+		CorrelationId: responseValues.Get("responseEnvelope.correlationId"),
+		Timestamp:     responseValues.Get("responseEnvelope.timestamp"),
+		Values:        responseValues,
+		Warnings:      warnings,
+		usedSandbox:   pClient.usesSandbox,
+	}
+
+	if errorId := responseValues.Get("error(0).errorId"); len(errorId) != 0 || strings.HasPrefix(strings.ToLower(response.Ack), "failure") {
+		pError := &PayPalError{
+			Ack:          response.Ack,
+			ErrorCode:    errorId,
+			ShortMessage: responseValues.Get("error(0).message"),
+			LongMessage:  responseValues.Get("error(0).message"),
+			SeverityCode: responseValues.Get("error(0).severity"),
+			// the envelope's correlation id is the one PayPal support asks for
+			CorrelationId: response.CorrelationId,
+			RequestId:     RequestIdFromContext(settings.ctx),
+		}
+		// error code hooks get the envelope in the shape of an NVP response
+		pClient.reportError(operation, &PayPalResponse{
+			Ack:           response.Ack,
+			CorrelationId: response.CorrelationId,
+			Timestamp:     response.Timestamp,
+			Values:        responseValues,
+			usedSandbox:   pClient.usesSandbox,
+		}, pError)
+		return response, pError
+	}
+	return response, nil
+}
+
+// A chained payment of total to the seller, of which fee is passed on to
+// the platform at payment time
+func PlatformFeePay(currencyCode, sellerEmail string, total float64, platformEmail string, fee float64) AdaptivePay {
+	return AdaptivePay{
+		CurrencyCode: currencyCode,
+		Receivers: []AdaptiveReceiver{
+			{Email: sellerEmail, Amount: total, Primary: true},
+			{Email: platformEmail, Amount: fee},
+		},
+		FeesPayer: FEES_PAYER_EACH_RECEIVER,
+	}
+}
+
+func (pay *AdaptivePay) validate() error {
+	if len(pay.Receivers) > MaxAdaptiveReceivers {
+		return &CountLimitError{"receivers", len(pay.Receivers), MaxAdaptiveReceivers}
+	}
+
+	var primary *AdaptiveReceiver
+	var secondaryCents int64
+	for i := range pay.Receivers {
+		receiver := &pay.Receivers[i]
+		if !receiver.Primary {
+			secondaryCents += toCents(receiver.Amount)
+			continue
+		}
+		if primary != nil {
+			return &ChainedPaymentError{"more than one primary receiver"}
+		}
+		primary = receiver
+	}
+
+	if primary == nil {
+		return nil
+	}
+	if len(pay.Receivers) == 1 {
+		return &ChainedPaymentError{"primary receiver without secondary receivers"}
+	}
+	if secondaryCents > toCents(primary.Amount) {
+		return &ChainedPaymentError{fmt.Sprintf("secondary receivers get %.2f out of %.2f", float64(secondaryCents)/100, primary.Amount)}
+	}
+	return nil
+}
+
+// The pay key is in the response's payKey value, see PayUrl. In a chained
+// payment the primary receiver's amount is what the buyer pays, and the
+// secondary receivers' amounts are passed on out of it.
+func (ac *AdaptiveClient) Pay(pay AdaptivePay, opts ...CallOption) (*AdaptiveResponse, error) {
+	if err := pay.validate(); err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	values.Set("actionType", "PAY")
+	values.Add("currencyCode", pay.CurrencyCode)
+	values.Add("returnUrl", pay.ReturnUrl)
+	values.Add("cancelUrl", pay.CancelUrl)
+	for i, receiver := range pay.Receivers {
+		prefix := fmt.Sprintf("receiverList.receiver(%d).", i)
+		values.Add(prefix+"email", receiver.Email)
+		values.Add(prefix+"amount", formatAmount(receiver.Amount))
+		if receiver.Primary {
+			values.Add(prefix+"primary", "true")
+		}
+	}
+	if len(pay.FeesPayer) != 0 {
+		values.Add("feesPayer", pay.FeesPayer)
+	}
+	if len(pay.Memo) != 0 {
+		values.Add("memo", pay.Memo)
+	}
+	if len(pay.TrackingId) != 0 {
+		values.Add("trackingId", pay.TrackingId)
+	}
+	return ac.PerformRequest("Pay", values, opts...)
+}
+
+// Looks up a payment by its pay key; status is in the response's status
+// value and each receiver's in paymentInfoList.paymentInfo(n)
+func (ac *AdaptiveClient) PaymentDetails(payKey string, opts ...CallOption) (*AdaptiveResponse, error) {
+	values := url.Values{}
+	values.Set("payKey", payKey)
+	return ac.PerformRequest("PaymentDetails", values, opts...)
+}
+
+// Refunds a payment in full, or only the given receivers' amounts when
+// receivers is not empty
+func (ac *AdaptiveClient) Refund(payKey, currencyCode string, receivers []AdaptiveReceiver, opts ...CallOption) (*AdaptiveResponse, error) {
+	values := url.Values{}
+	values.Set("payKey", payKey)
+	values.Add("currencyCode", currencyCode)
+	for i, receiver := range receivers {
+		prefix := fmt.Sprintf("receiverList.receiver(%d).", i)
+		values.Add(prefix+"email", receiver.Email)
+		values.Add(prefix+"amount", formatAmount(receiver.Amount))
+	}
+	return ac.PerformRequest("Refund", values, opts...)
+}
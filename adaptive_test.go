@@ -0,0 +1,158 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// Records the last Adaptive request and answers with body
+type adaptiveTransport struct {
+	request *http.Request
+	values  url.Values
+	body    string
+}
+
+func (transport *adaptiveTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, _ := ioutil.ReadAll(r.Body)
+	transport.request = r
+	transport.values, _ = url.ParseQuery(string(body))
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(transport.body)),
+		Header:     make(http.Header),
+		Request:    r,
+	}, nil
+}
+
+func TestAdaptivePay(t *testing.T) {
+	transport := &adaptiveTransport{body: "responseEnvelope.ack=Success&responseEnvelope.correlationId=abc&payKey=AP-1&paymentExecStatus=CREATED"}
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: transport})
+	adaptive := paypal.NewAdaptiveClient(client, paypal.ADAPTIVE_SANDBOX_APP_ID)
+
+	response, err := adaptive.Pay(paypal.AdaptivePay{
+		CurrencyCode: "USD",
+		Receivers: []paypal.AdaptiveReceiver{
+			{Email: "seller@example.com", Amount: 100, Primary: true},
+			{Email: "platform@example.com", Amount: 10},
+		},
+		ReturnUrl: TEST_RETURN_URL,
+		CancelUrl: TEST_CANCEL_URL,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+
+	if transport.request.URL.String() != paypal.ADAPTIVE_SANDBOX_URL+"Pay" {
+		t.Errorf("Unexpected endpoint: %s", transport.request.URL)
+	}
+	header := transport.request.Header
+	if header.Get("X-PAYPAL-SECURITY-USERID") != "user" || header.Get("X-PAYPAL-APPLICATION-ID") != paypal.ADAPTIVE_SANDBOX_APP_ID || header.Get("X-PAYPAL-REQUEST-DATA-FORMAT") != "NV" {
+		t.Errorf("Unexpected headers: %#v", header)
+	}
+	values := transport.values
+	if values.Get("actionType") != "PAY" || values.Get("receiverList.receiver(0).primary") != "true" || values.Get("receiverList.receiver(1).amount") != "10.00" || values.Get("requestEnvelope.errorLanguage") != "en_US" {
+		t.Errorf("Unexpected request: %#v", values)
+	}
+	if values.Get("USER") != "" {
+		t.Errorf("Credentials sent in the body")
+	}
+
+	if !strings.Contains(response.PayUrl(), "paykey=AP-1") || !strings.HasPrefix(response.PayUrl(), paypal.CHECKOUT_SANDBOX_URL) {
+		t.Errorf("Unexpected pay URL: %s", response.PayUrl())
+	}
+}
+
+func TestAdaptiveError(t *testing.T) {
+	transport := &adaptiveTransport{body: "responseEnvelope.ack=Failure&error(0).errorId=580022&error(0).message=Invalid+request+parameter&error(0).severity=Error"}
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: transport})
+	hooked := 0
+	client.OnErrorCode("580022", func(response *paypal.PayPalResponse, err *paypal.PayPalError) {
+		hooked++
+	})
+
+	_, err := paypal.NewAdaptiveClient(client, paypal.ADAPTIVE_SANDBOX_APP_ID).PaymentDetails("AP-1", paypal.WithSubject("seller@example.com"))
+	pError, ok := err.(*paypal.PayPalError)
+	if !ok || pError.ErrorCode != "580022" {
+		t.Errorf("Expected a *PayPalError with code 580022, got %#v", err)
+	}
+	if hooked != 1 {
+		t.Errorf("Expected the 580022 hook to run once, ran %d times", hooked)
+	}
+	if transport.request.Header.Get("X-PAYPAL-SECURITY-SUBJECT") != "seller@example.com" {
+		t.Errorf("WithSubject not applied: %#v", transport.request.Header)
+	}
+	if transport.values.Get("payKey") != "AP-1" {
+		t.Errorf("Unexpected request: %#v", transport.values)
+	}
+}
+
+func TestPlatformFeePay(t *testing.T) {
+	transport := &adaptiveTransport{body: "responseEnvelope.ack=Success&payKey=AP-2"}
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: transport})
+	adaptive := paypal.NewAdaptiveClient(client, paypal.ADAPTIVE_SANDBOX_APP_ID)
+
+	pay := paypal.PlatformFeePay("USD", "seller@example.com", 50, "platform@example.com", 2.5)
+	if _, err := adaptive.Pay(pay); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if transport.values.Get("receiverList.receiver(0).primary") != "true" || transport.values.Get("receiverList.receiver(1).amount") != "2.50" || transport.values.Get("feesPayer") != "EACHRECEIVER" {
+		t.Errorf("Unexpected request: %#v", transport.values)
+	}
+
+	transport.values = nil
+	tooMuch := paypal.PlatformFeePay("USD", "seller@example.com", 50, "platform@example.com", 60)
+	if _, err := adaptive.Pay(tooMuch); err == nil {
+		t.Errorf("Expected a *ChainedPaymentError passing on more than the primary receives")
+	}
+	twoPrimaries := paypal.AdaptivePay{CurrencyCode: "USD", Receivers: []paypal.AdaptiveReceiver{
+		{Email: "a@example.com", Amount: 10, Primary: true},
+		{Email: "b@example.com", Amount: 10, Primary: true},
+	}}
+	if _, err := adaptive.Pay(twoPrimaries); err == nil {
+		t.Errorf("Expected a *ChainedPaymentError with two primary receivers")
+	}
+	if transport.values != nil {
+		t.Errorf("Invalid chained payment sent to PayPal")
+	}
+}
+
+func TestAdaptiveRequestChecks(t *testing.T) {
+	transport := &adaptiveTransport{body: "responseEnvelope.ack=Success&payKey=AP-3"}
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: transport})
+	pay := paypal.AdaptivePay{
+		CurrencyCode: "USD",
+		Receivers:    []paypal.AdaptiveReceiver{{Email: "seller@example.com", Amount: 10}},
+		Memo:         strings.Repeat("m", 1001),
+	}
+
+	seller := paypal.NewMerchantScopedClient(client, "seller@example.com", []string{"PaymentDetails"})
+	if _, err := paypal.NewAdaptiveClient(seller.PayPalClient, paypal.ADAPTIVE_SANDBOX_APP_ID).Pay(pay); err == nil {
+		t.Errorf("Expected a *PermissionError for an operation the seller did not grant")
+	} else if _, ok := err.(*paypal.PermissionError); !ok {
+		t.Errorf("Expected a *PermissionError, got %#v", err)
+	}
+
+	adaptive := paypal.NewAdaptiveClient(client, paypal.ADAPTIVE_SANDBOX_APP_ID)
+	if _, err := adaptive.Pay(pay); err == nil {
+		t.Errorf("Expected a *FieldLengthError for a 1001 character memo")
+	} else if lengthErr, ok := err.(*paypal.FieldLengthError); !ok || lengthErr.Field != "memo" {
+		t.Errorf("Expected a *FieldLengthError for memo, got %#v", err)
+	}
+	if transport.request != nil {
+		t.Errorf("Rejected payment sent to PayPal")
+	}
+
+	client.SetTruncateLongFields(true)
+	response, err := adaptive.Pay(pay)
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if len(transport.values.Get("memo")) != 1000 || len(response.Warnings) != 1 {
+		t.Errorf("Expected the memo cut to 1000 characters with a warning, got %d and %v", len(transport.values.Get("memo")), response.Warnings)
+	}
+}
@@ -0,0 +1,186 @@
+package paypal
+
+import (
+	"net/url"
+	"strings"
+)
+
+// A postal address as the NVP API, IPN and REST describe it
+type Address struct {
+	Name        string
+	Street      string
+	Street2     string
+	City        string
+	State       string
+	Zip         string
+	CountryCode string
+	Phone       string
+	// Whether PayPal has confirmed the address, when it says
+	Status AddressStatus
+}
+
+// REST JSON shape of an address, as used by purchase units and payers
+type RESTAddress struct {
+	AddressLine1 string `json:"address_line_1,omitempty"`
+	AddressLine2 string `json:"address_line_2,omitempty"`
+	AdminArea2   string `json:"admin_area_2,omitempty"`
+	AdminArea1   string `json:"admin_area_1,omitempty"`
+	PostalCode   string `json:"postal_code,omitempty"`
+	CountryCode  string `json:"country_code"`
+}
+
+// Returned by Address.Validate
+type AddressError struct {
+	Field  string
+	Detail string
+}
+
+func (e *AddressError) Error() string {
+	return "PayPal address " + e.Field + " " + e.Detail
+}
+
+var usStates = map[string]string{
+	"ALABAMA": "AL", "ALASKA": "AK", "ARIZONA": "AZ", "ARKANSAS": "AR", "CALIFORNIA": "CA",
+	"COLORADO": "CO", "CONNECTICUT": "CT", "DELAWARE": "DE", "DISTRICT OF COLUMBIA": "DC", "FLORIDA": "FL",
+	"GEORGIA": "GA", "HAWAII": "HI", "IDAHO": "ID", "ILLINOIS": "IL", "INDIANA": "IN",
+	"IOWA": "IA", "KANSAS": "KS", "KENTUCKY": "KY", "LOUISIANA": "LA", "MAINE": "ME",
+	"MARYLAND": "MD", "MASSACHUSETTS": "MA", "MICHIGAN": "MI", "MINNESOTA": "MN", "MISSISSIPPI": "MS",
+	"MISSOURI": "MO", "MONTANA": "MT", "NEBRASKA": "NE", "NEVADA": "NV", "NEW HAMPSHIRE": "NH",
+	"NEW JERSEY": "NJ", "NEW MEXICO": "NM", "NEW YORK": "NY", "NORTH CAROLINA": "NC", "NORTH DAKOTA": "ND",
+	"OHIO": "OH", "OKLAHOMA": "OK", "OREGON": "OR", "PENNSYLVANIA": "PA", "RHODE ISLAND": "RI",
+	"SOUTH CAROLINA": "SC", "SOUTH DAKOTA": "SD", "TENNESSEE": "TN", "TEXAS": "TX", "UTAH": "UT",
+	"VERMONT": "VT", "VIRGINIA": "VA", "WASHINGTON": "WA", "WEST VIRGINIA": "WV", "WISCONSIN": "WI",
+	"WYOMING": "WY", "PUERTO RICO": "PR", "GUAM": "GU", "VIRGIN ISLANDS": "VI", "AMERICAN SAMOA": "AS",
+	"NORTHERN MARIANA ISLANDS": "MP", "ARMED FORCES AMERICAS": "AA", "ARMED FORCES EUROPE": "AE", "ARMED FORCES PACIFIC": "AP",
+}
+
+var caProvinces = map[string]string{
+	"ALBERTA": "AB", "BRITISH COLUMBIA": "BC", "MANITOBA": "MB", "NEW BRUNSWICK": "NB",
+	"NEWFOUNDLAND AND LABRADOR": "NL", "NEWFOUNDLAND": "NL", "NOVA SCOTIA": "NS", "NORTHWEST TERRITORIES": "NT",
+	"NUNAVUT": "NU", "ONTARIO": "ON", "PRINCE EDWARD ISLAND": "PE", "QUEBEC": "QC", "QUÉBEC": "QC",
+	"SASKATCHEWAN": "SK", "YUKON": "YT",
+}
+
+// Trims every field, upper-cases the country code and, for US and Canadian
+// addresses, turns state and province names into their two-letter codes
+func (a Address) Normalize() Address {
+	a.Name = strings.TrimSpace(a.Name)
+	a.Street = strings.TrimSpace(a.Street)
+	a.Street2 = strings.TrimSpace(a.Street2)
+	a.City = strings.TrimSpace(a.City)
+	a.State = strings.TrimSpace(a.State)
+	a.Zip = strings.TrimSpace(a.Zip)
+	a.CountryCode = strings.ToUpper(strings.TrimSpace(a.CountryCode))
+	a.Phone = strings.TrimSpace(a.Phone)
+
+	var states map[string]string
+	switch a.CountryCode {
+	case "US":
+		states = usStates
+	case "CA":
+		states = caProvinces
+	}
+	if states != nil {
+		state := strings.ToUpper(strings.Join(strings.Fields(strings.Replace(a.State, ".", "", -1)), " "))
+		if code, ok := states[state]; ok {
+			a.State = code
+		} else if len(state) == 2 {
+			a.State = state
+		}
+	}
+	return a
+}
+
+// Checks the fields PayPal requires of a shipping address
+func (a Address) Validate() error {
+	if len(a.Street) == 0 {
+		return &AddressError{"Street", "is required"}
+	}
+	if len(a.City) == 0 {
+		return &AddressError{"City", "is required"}
+	}
+	if !IsSupportedCountry(a.CountryCode) {
+		return &AddressError{"CountryCode", "must be one of the COUNTRY_* codes, got " + a.CountryCode}
+	}
+	if a.CountryCode == "US" || a.CountryCode == "CA" {
+		if len(a.State) != 2 {
+			return &AddressError{"State", "must be a two-letter code for " + a.CountryCode + ", got " + a.State}
+		}
+	}
+	return nil
+}
+
+// Reads an address from NVP fields starting with prefix, e.g.
+// "PAYMENTREQUEST_0_SHIPTO" for SHIPTONAME, SHIPTOSTREET...
+func AddressFromNVP(values url.Values, prefix string) Address {
+	return Address{
+		Name:        values.Get(prefix + "NAME"),
+		Street:      values.Get(prefix + "STREET"),
+		Street2:     values.Get(prefix + "STREET2"),
+		City:        values.Get(prefix + "CITY"),
+		State:       values.Get(prefix + "STATE"),
+		Zip:         values.Get(prefix + "ZIP"),
+		CountryCode: values.Get(prefix + "COUNTRYCODE"),
+		Phone:       values.Get(prefix + "PHONENUM"),
+	}
+}
+
+// Writes the address as NVP fields starting with prefix. With an empty
+// prefix these are the billing address fields of a card payment, which
+// have no name or phone number.
+func (a Address) AddNVP(values url.Values, prefix string) {
+	if len(prefix) != 0 && len(a.Name) != 0 {
+		values.Add(prefix+"NAME", a.Name)
+	}
+	values.Add(prefix+"STREET", a.Street)
+	if len(a.Street2) != 0 {
+		values.Add(prefix+"STREET2", a.Street2)
+	}
+	values.Add(prefix+"CITY", a.City)
+	if len(a.State) != 0 {
+		values.Add(prefix+"STATE", a.State)
+	}
+	if len(a.Zip) != 0 {
+		values.Add(prefix+"ZIP", a.Zip)
+	}
+	values.Add(prefix+"COUNTRYCODE", a.CountryCode)
+	if len(prefix) != 0 && len(a.Phone) != 0 {
+		values.Add(prefix+"PHONENUM", a.Phone)
+	}
+}
+
+// Reads the address_* fields of an IPN message
+func AddressFromIPN(values url.Values) Address {
+	return Address{
+		Name:        values.Get("address_name"),
+		Street:      values.Get("address_street"),
+		City:        values.Get("address_city"),
+		State:       values.Get("address_state"),
+		Zip:         values.Get("address_zip"),
+		CountryCode: values.Get("address_country_code"),
+		Phone:       values.Get("contact_phone"),
+		Status:      parseAddressStatus(values.Get("address_status")),
+	}
+}
+
+func (a Address) REST() RESTAddress {
+	return RESTAddress{
+		AddressLine1: a.Street,
+		AddressLine2: a.Street2,
+		AdminArea2:   a.City,
+		AdminArea1:   a.State,
+		PostalCode:   a.Zip,
+		CountryCode:  a.CountryCode,
+	}
+}
+
+func AddressFromREST(r RESTAddress) Address {
+	return Address{
+		Street:      r.AddressLine1,
+		Street2:     r.AddressLine2,
+		City:        r.AdminArea2,
+		State:       r.AdminArea1,
+		Zip:         r.PostalCode,
+		CountryCode: r.CountryCode,
+	}
+}
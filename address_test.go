@@ -0,0 +1,84 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestAddressNormalize(t *testing.T) {
+	address := paypal.Address{Street: " 1 Main St ", City: "San Jose", State: "california", CountryCode: "us"}.Normalize()
+	if address.State != "CA" || address.CountryCode != "US" || address.Street != "1 Main St" {
+		t.Errorf("US address not normalized: %#v", address)
+	}
+	if err := address.Validate(); err != nil {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+
+	province := paypal.Address{Street: "1 Rue", City: "Montréal", State: "Québec", CountryCode: "CA"}.Normalize()
+	if province.State != "QC" {
+		t.Errorf("Canadian province not normalized: %#v", province)
+	}
+
+	german := paypal.Address{Street: "Hauptstr. 1", City: "Berlin", State: "Berlin", CountryCode: "DE"}.Normalize()
+	if german.State != "Berlin" {
+		t.Errorf("Non-US state changed: %#v", german)
+	}
+
+	if err := (paypal.Address{Street: "1 Main St", City: "Springfield", State: "Nowhere", CountryCode: "US"}).Normalize().Validate(); err == nil {
+		t.Errorf("Expected an *AddressError for an unknown US state")
+	}
+	if err := (paypal.Address{Street: "1 Main St", City: "Springfield", CountryCode: "USA"}).Validate(); err == nil {
+		t.Errorf("Expected an *AddressError for a three-letter country code")
+	}
+}
+
+func TestAddressConversions(t *testing.T) {
+	values, _ := url.ParseQuery("PAYMENTREQUEST_0_SHIPTONAME=Jane+Doe&PAYMENTREQUEST_0_SHIPTOSTREET=1+Main+St&PAYMENTREQUEST_0_SHIPTOCITY=San+Jose" +
+		"&PAYMENTREQUEST_0_SHIPTOSTATE=CA&PAYMENTREQUEST_0_SHIPTOZIP=95131&PAYMENTREQUEST_0_SHIPTOCOUNTRYCODE=US&PAYMENTREQUEST_0_ADDRESSSTATUS=Confirmed")
+
+	details := new(paypal.PayPalCheckoutDetails)
+	details.Populate(values)
+	address := details.ShippingAddress
+	if address.Name != "Jane Doe" || address.Zip != "95131" || address.Status != "Confirmed" {
+		t.Errorf("Shipping address not decoded: %#v", address)
+	}
+
+	encoded, _ := json.Marshal(address.REST())
+	if string(encoded) != `{"address_line_1":"1 Main St","admin_area_2":"San Jose","admin_area_1":"CA","postal_code":"95131","country_code":"US"}` {
+		t.Errorf("Unexpected REST address: %s", encoded)
+	}
+	var rest paypal.RESTAddress
+	json.Unmarshal(encoded, &rest)
+	if back := paypal.AddressFromREST(rest); back.City != "San Jose" || back.State != "CA" {
+		t.Errorf("REST address not decoded: %#v", back)
+	}
+
+	ipn, _ := url.ParseQuery("address_name=Jane+Doe&address_street=1+Main+St&address_city=San+Jose&address_country_code=US&address_status=unconfirmed")
+	if message := paypal.ParseIPNMessage(ipn); message.Address.City != "San Jose" || message.Address.Status != paypal.ADDRESS_STATUS_UNCONFIRMED {
+		t.Errorf("IPN address not decoded: %#v", message.Address)
+	}
+}
+
+func TestAddressOverride(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success"
+	})})
+
+	address := &paypal.Address{Name: "Jane Doe", Street: "1 Main St", City: "San Jose", State: "California", Zip: "95131", CountryCode: "US"}
+	if _, err := client.SetExpressCheckout(paypal.PayPalOrder{CurrencyCode: "USD", ShippingAddress: address, AddressOverride: true}, nil); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if sent.Get("ADDROVERRIDE") != "1" || sent.Get("PAYMENTREQUEST_0_SHIPTONAME") != "Jane Doe" || sent.Get("PAYMENTREQUEST_0_SHIPTOSTATE") != "CA" {
+		t.Errorf("Address override not sent: %#v", sent)
+	}
+
+	if _, err := client.SetExpressCheckout(paypal.PayPalOrder{CurrencyCode: "USD", ShippingAddress: &paypal.Address{City: "San Jose", CountryCode: "US"}}, nil); err == nil {
+		t.Errorf("Expected an *AddressError for an address without a street")
+	}
+}
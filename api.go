@@ -0,0 +1,36 @@
+package paypal
+
+import (
+	"net/url"
+)
+
+// The NVP calls of PayPalClient, for code that wants to swap the client for
+// a fake in its tests; see the paypaltest package
+type PayPalAPI interface {
+	PerformRequest(values url.Values, opts ...CallOption) (*PayPalResponse, error)
+
+	SetExpressCheckout(order PayPalOrder, goods []PayPalGood, opts ...CallOption) (*PayPalResponse, error)
+	SetExpressCheckoutDigitalGoods(paymentAmount float64, currencyCode string, returnURL, cancelURL string, goods []PayPalDigitalGood, opts ...CallOption) (*PayPalResponse, error)
+	SetExpressCheckoutMark(order PayPalOrder, goods []PayPalGood, opts ...CallOption) (*PayPalResponse, error)
+	GetExpressCheckoutDetails(token string, opts ...CallOption) (*PayPalResponse, error)
+	DoExpressCheckoutSale(token, payerId, currencyCode string, finalPaymentAmount float64, opts ...CallOption) (*PayPalResponse, error)
+	DoExpressCheckoutPayment(token, payerId string, paymentAction PaymentAction, currencyCode string, finalPaymentAmount float64, opts ...CallOption) (*PayPalResponse, error)
+	DoExpressCheckoutOrderPayment(token, payerId string, paymentAction PaymentAction, order PayPalOrder, goods []PayPalGood, opts ...CallOption) (*PayPalResponse, error)
+
+	DoAuthorization(transactionId string, amount float64, currencyCode string, opts ...CallOption) (*PayPalResponse, error)
+	DoReauthorization(authorizationId string, amount float64, currencyCode string, opts ...CallOption) (*PayPalResponse, error)
+	DoCapture(capture PayPalCapture, opts ...CallOption) (*PayPalResponse, error)
+	DoVoid(authorizationId, note string, opts ...CallOption) (*PayPalResponse, error)
+	RefundTransaction(refund PayPalRefund, opts ...CallOption) (*PayPalResponse, error)
+
+	TransactionSearch(search PayPalTransactionSearch, opts ...CallOption) (*PayPalResponse, error)
+	GetTransactionDetails(transactionId string, opts ...CallOption) (*PayPalResponse, error)
+	GetBalance(allCurrencies bool, opts ...CallOption) (*PayPalResponse, error)
+
+	CreateRecurringPaymentsProfile(request PayPalRecurringProfileRequest, opts ...CallOption) (*PayPalResponse, error)
+	GetRecurringPaymentsProfileDetails(profileId string, opts ...CallOption) (*PayPalResponse, error)
+	ManageRecurringPaymentsProfileStatus(profileId string, action ProfileAction, note string, opts ...CallOption) (*PayPalResponse, error)
+	BillOutstandingAmount(profileId string, amount float64, note string, opts ...CallOption) (*PayPalResponse, error)
+}
+
+var _ PayPalAPI = (*PayPalClient)(nil)
@@ -0,0 +1,44 @@
+package paypal
+
+import (
+	"net/url"
+)
+
+// Handle on a call running in its own goroutine
+type PayPalFuture struct {
+	done     chan struct{}
+	response *PayPalResponse
+	err      error
+}
+
+// Runs call in the background, e.g.
+//
+//	future := paypal.Async(func() (*paypal.PayPalResponse, error) {
+//		return client.GetExpressCheckoutDetails(token)
+//	})
+func Async(call func() (*PayPalResponse, error)) *PayPalFuture {
+	future := &PayPalFuture{done: make(chan struct{})}
+	go func() {
+		defer close(future.done)
+		future.response, future.err = call()
+	}()
+	return future
+}
+
+// Asynchronous PerformRequest
+func (pClient *PayPalClient) Submit(values url.Values, opts ...CallOption) *PayPalFuture {
+	return Async(func() (*PayPalResponse, error) {
+		return pClient.PerformRequest(values, opts...)
+	})
+}
+
+// Closed once the call has finished, for use in select statements
+func (future *PayPalFuture) Done() <-chan struct{} {
+	return future.done
+}
+
+// Blocks until the call has finished and returns its result
+func (future *PayPalFuture) Wait() (*PayPalResponse, error) {
+	<-future.done
+	return future.response, future.err
+}
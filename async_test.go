@@ -0,0 +1,39 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"errors"
+	"testing"
+)
+
+func TestAsync(t *testing.T) {
+	release := make(chan struct{})
+	future := paypal.Async(func() (*paypal.PayPalResponse, error) {
+		<-release
+		return &paypal.PayPalResponse{Ack: "Success"}, nil
+	})
+
+	select {
+	case <-future.Done():
+		t.Fatalf("Future finished before the call returned")
+	default:
+	}
+
+	close(release)
+	response, err := future.Wait()
+	if err != nil || response.Ack != "Success" {
+		t.Errorf("Unexpected result from future: %#v, %#v", response, err)
+	}
+}
+
+func TestAsyncError(t *testing.T) {
+	callErr := errors.New("boom")
+	_, err := paypal.Async(func() (*paypal.PayPalResponse, error) {
+		return nil, callErr
+	}).Wait()
+
+	if err != callErr {
+		t.Errorf("Expected the call's error from Wait, got %#v", err)
+	}
+}
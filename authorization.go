@@ -0,0 +1,213 @@
+package paypal
+
+import (
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// PayPal honors the authorized funds for 3 days
+	AUTHORIZATION_HONOR_PERIOD = 3 * 24 * time.Hour
+	// After 29 days an authorization can no longer be captured or reauthorized
+	AUTHORIZATION_VALIDITY_PERIOD = 29 * 24 * time.Hour
+)
+
+func (pClient *PayPalClient) DoVoid(authorizationId, note string, opts ...CallOption) (*PayPalResponse, error) {
+	values := url.Values{}
+	values.Set("METHOD", "DoVoid")
+	values.Add("AUTHORIZATIONID", authorizationId)
+	if len(note) != 0 {
+		values.Add("NOTE", note)
+	}
+	return pClient.PerformRequest(values, opts...)
+}
+
+// The new authorization id is in the response's AUTHORIZATIONID value
+func (pClient *PayPalClient) DoReauthorization(authorizationId string, amount float64, currencyCode string, opts ...CallOption) (*PayPalResponse, error) {
+	values := url.Values{}
+	values.Set("METHOD", "DoReauthorization")
+	values.Add("AUTHORIZATIONID", authorizationId)
+	values.Add("AMT", formatAmount(amount))
+	values.Add("CURRENCYCODE", currencyCode)
+	return pClient.PerformRequest(values, opts...)
+}
+
+type PayPalAuthorization struct {
+	AuthorizationId string
+	Amount          float64
+	CurrencyCode    string
+	// When the funds were (re)authorized
+	AuthorizedAt time.Time
+	// An authorization can only be reauthorized once
+	Reauthorized bool
+
+	// Filled in by CaptureAuthorization
+	Captured   float64
+	CaptureIds []string
+	Completed  bool
+
+	// held by CaptureAuthorization and AuthorizationTracker.Sweep while
+	// they use the authorization
+	mu sync.Mutex
+}
+
+func (auth *PayPalAuthorization) HonorPeriodEndsAt() time.Time {
+	return auth.AuthorizedAt.Add(AUTHORIZATION_HONOR_PERIOD)
+}
+
+func (auth *PayPalAuthorization) ExpiresAt() time.Time {
+	return auth.AuthorizedAt.Add(AUTHORIZATION_VALIDITY_PERIOD)
+}
+
+// Whether the honor period is over, so capturing may fail for lack of
+// funds, while the authorization can still be reauthorized
+func NeedsReauthorization(auth *PayPalAuthorization) bool {
+	now := time.Now()
+	return !auth.Reauthorized && now.After(auth.HonorPeriodEndsAt()) && now.Before(auth.ExpiresAt())
+}
+
+// What an AuthorizationTracker sweep does with a stale authorization
+type AuthorizationAction int
+
+const (
+	AUTHORIZATION_KEEP AuthorizationAction = iota
+	AUTHORIZATION_VOID
+	AUTHORIZATION_REAUTHORIZE
+)
+
+// Decides the fate of an authorization past its honor period
+type StaleAuthorizationFunc func(auth *PayPalAuthorization) AuthorizationAction
+
+// Keeps track of uncaptured authorizations and voids or reauthorizes them
+// as they go stale
+type AuthorizationTracker struct {
+	client  *PayPalClient
+	onStale StaleAuthorizationFunc
+
+	mu      sync.Mutex
+	tracked map[string]*PayPalAuthorization
+}
+
+func NewAuthorizationTracker(client *PayPalClient, onStale StaleAuthorizationFunc) *AuthorizationTracker {
+	return &AuthorizationTracker{client: client, onStale: onStale, tracked: make(map[string]*PayPalAuthorization)}
+}
+
+func (tracker *AuthorizationTracker) Track(auth *PayPalAuthorization) {
+	tracker.mu.Lock()
+	tracker.tracked[auth.AuthorizationId] = auth
+	tracker.mu.Unlock()
+}
+
+// Stops tracking an authorization, e.g. once it has been captured
+func (tracker *AuthorizationTracker) Untrack(authorizationId string) {
+	tracker.mu.Lock()
+	delete(tracker.tracked, authorizationId)
+	tracker.mu.Unlock()
+}
+
+func (tracker *AuthorizationTracker) Authorizations() []*PayPalAuthorization {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	auths := make([]*PayPalAuthorization, 0, len(tracker.tracked))
+	for _, auth := range tracker.tracked {
+		auths = append(auths, auth)
+	}
+	return auths
+}
+
+// Passes every authorization past its honor period to the callback and
+// carries out its decision. Authorizations past their validity are dropped
+// after the callback has seen them, and completed ones without calling it.
+// Returns the first failed call's error.
+//
+// Captures of an authorization wait while the sweep handles it, so the
+// callback must not capture the authorization itself. The voids and
+// reauthorizations are made with opts.
+func (tracker *AuthorizationTracker) Sweep(opts ...CallOption) error {
+	var firstErr error
+	now := time.Now()
+
+	for _, auth := range tracker.Authorizations() {
+		if err := tracker.sweep(auth, now, opts); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (tracker *AuthorizationTracker) sweep(auth *PayPalAuthorization, now time.Time, opts []CallOption) error {
+	auth.mu.Lock()
+	defer auth.mu.Unlock()
+
+	// an authorization captured in full has nothing left to void or
+	// reauthorize
+	if auth.Completed || toCents(auth.Remaining()) <= 0 {
+		tracker.Untrack(auth.AuthorizationId)
+		return nil
+	}
+	if now.Before(auth.HonorPeriodEndsAt()) {
+		return nil
+	}
+
+	var err error
+	switch tracker.onStale(auth) {
+	case AUTHORIZATION_VOID:
+		if _, err = tracker.client.DoVoid(auth.AuthorizationId, "", opts...); err == nil {
+			tracker.Untrack(auth.AuthorizationId)
+		}
+	case AUTHORIZATION_REAUTHORIZE:
+		if NeedsReauthorization(auth) {
+			err = tracker.reauthorize(auth, opts)
+		}
+	}
+
+	if now.After(auth.ExpiresAt()) {
+		tracker.Untrack(auth.AuthorizationId)
+	}
+	return err
+}
+
+// Reauthorizes what is left to capture of auth, which must be locked. auth
+// is renewed in place, so the caller's pointer and an OrderHandle's
+// Authorizations capture against the new id; its Amount becomes what was
+// left, and CaptureIds keeps the captures made before.
+func (tracker *AuthorizationTracker) reauthorize(auth *PayPalAuthorization, opts []CallOption) error {
+	remaining := auth.Remaining()
+	response, err := tracker.client.DoReauthorization(auth.AuthorizationId, remaining, auth.CurrencyCode, opts...)
+	if err != nil {
+		return err
+	}
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	delete(tracker.tracked, auth.AuthorizationId)
+	auth.AuthorizationId = response.Values.Get("AUTHORIZATIONID")
+	auth.Amount = remaining
+	auth.Captured = 0
+	auth.AuthorizedAt = time.Now()
+	auth.Reauthorized = true
+	tracker.tracked[auth.AuthorizationId] = auth
+	return nil
+}
+
+// Sweeps with opts every interval until stop is closed, handing errors to
+// onError (which may be nil)
+func (tracker *AuthorizationTracker) Run(interval time.Duration, stop <-chan struct{}, onError func(error), opts ...CallOption) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := tracker.Sweep(opts...); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
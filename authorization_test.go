@@ -0,0 +1,109 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestNeedsReauthorization(t *testing.T) {
+	fresh := &paypal.PayPalAuthorization{AuthorizedAt: time.Now().Add(-24 * time.Hour)}
+	stale := &paypal.PayPalAuthorization{AuthorizedAt: time.Now().Add(-5 * 24 * time.Hour)}
+	expired := &paypal.PayPalAuthorization{AuthorizedAt: time.Now().Add(-30 * 24 * time.Hour)}
+	renewed := &paypal.PayPalAuthorization{AuthorizedAt: time.Now().Add(-5 * 24 * time.Hour), Reauthorized: true}
+
+	if paypal.NeedsReauthorization(fresh) || !paypal.NeedsReauthorization(stale) || paypal.NeedsReauthorization(expired) || paypal.NeedsReauthorization(renewed) {
+		t.Errorf("Unexpected NeedsReauthorization results")
+	}
+}
+
+func TestAuthorizationTrackerSweep(t *testing.T) {
+	var methods []string
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		methods = append(methods, values.Get("METHOD")+" "+values.Get("AUTHORIZATIONID"))
+		return "ACK=Success&AUTHORIZATIONID=NEW-" + values.Get("AUTHORIZATIONID")
+	})})
+
+	tracker := paypal.NewAuthorizationTracker(client, func(auth *paypal.PayPalAuthorization) paypal.AuthorizationAction {
+		if auth.Amount > 100 {
+			return paypal.AUTHORIZATION_REAUTHORIZE
+		}
+		return paypal.AUTHORIZATION_VOID
+	})
+	tracker.Track(&paypal.PayPalAuthorization{AuthorizationId: "FRESH", Amount: 500, AuthorizedAt: time.Now()})
+	tracker.Track(&paypal.PayPalAuthorization{AuthorizationId: "SMALL", Amount: 5, AuthorizedAt: time.Now().Add(-4 * 24 * time.Hour)})
+	tracker.Track(&paypal.PayPalAuthorization{AuthorizationId: "LARGE", Amount: 500, CurrencyCode: "USD", AuthorizedAt: time.Now().Add(-4 * 24 * time.Hour)})
+
+	if err := tracker.Sweep(); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+
+	if len(methods) != 2 {
+		t.Fatalf("Expected a void and a reauthorization, got %#v", methods)
+	}
+	remaining := map[string]bool{}
+	for _, auth := range tracker.Authorizations() {
+		remaining[auth.AuthorizationId] = auth.Reauthorized
+	}
+	if len(remaining) != 2 || !remaining["NEW-LARGE"] {
+		t.Errorf("Expected FRESH and the reauthorized NEW-LARGE to remain, got %#v", remaining)
+	}
+	if _, ok := remaining["FRESH"]; !ok {
+		t.Errorf("Fresh authorization should still be tracked")
+	}
+}
+
+func TestAuthorizationTrackerSkipsCaptured(t *testing.T) {
+	var calls []url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		calls = append(calls, values)
+		return "ACK=Success&TRANSACTIONID=CAPTURE&AUTHORIZATIONID=NEW-" + values.Get("AUTHORIZATIONID")
+	})})
+	tracker := paypal.NewAuthorizationTracker(client, func(auth *paypal.PayPalAuthorization) paypal.AuthorizationAction {
+		if auth.AuthorizationId == "PARTIAL" {
+			return paypal.AUTHORIZATION_REAUTHORIZE
+		}
+		return paypal.AUTHORIZATION_VOID
+	})
+
+	stale := time.Now().Add(-4 * 24 * time.Hour)
+	captured := &paypal.PayPalAuthorization{AuthorizationId: "CAPTURED", Amount: 50, CurrencyCode: "USD", AuthorizedAt: stale}
+	partial := &paypal.PayPalAuthorization{AuthorizationId: "PARTIAL", Amount: 100, CurrencyCode: "USD", AuthorizedAt: stale}
+	tracker.Track(captured)
+	tracker.Track(partial)
+	if _, err := client.CaptureAuthorization(captured, 50, true); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if _, err := client.CaptureAuthorization(partial, 30, false); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	calls = nil
+
+	if err := tracker.Sweep(paypal.WithSubject("seller@example.com")); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if len(calls) != 1 || calls[0].Get("METHOD") != "DoReauthorization" || calls[0].Get("AMT") != "70.00" {
+		t.Fatalf("Expected only PARTIAL reauthorized for its remaining 70.00, got %v", calls)
+	}
+	if calls[0].Get("SUBJECT") != "seller@example.com" {
+		t.Errorf("Sweep options not passed to DoReauthorization: %v", calls[0])
+	}
+	auths := tracker.Authorizations()
+	if len(auths) != 1 || auths[0] != partial {
+		t.Fatalf("Expected only the renewed PARTIAL tracked, got %#v", auths)
+	}
+	if partial.AuthorizationId != "NEW-PARTIAL" || !partial.Reauthorized || partial.Remaining() != 70 {
+		t.Errorf("Expected the caller's authorization renewed in place, got %#v", partial)
+	}
+
+	calls = nil
+	if _, err := client.CaptureAuthorization(partial, 70, true); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if calls[0].Get("AUTHORIZATIONID") != "NEW-PARTIAL" {
+		t.Errorf("Expected the capture made against the new id, got %v", calls[0])
+	}
+}
@@ -0,0 +1,49 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestBuildRequestAndDo(t *testing.T) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		return "ACK=Success&TOKEN=" + values.Get("TOKEN") + "&SIGNED=" + values.Get("X-SIGNED")
+	})})
+
+	values := url.Values{}
+	values.Set("METHOD", "GetExpressCheckoutDetails")
+	values.Set("TOKEN", "EC-1")
+	request, err := client.BuildRequest(values, paypal.WithSubject("seller@example.com"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if request.URL.String() != paypal.NVP_SANDBOX_URL || request.Method != "POST" {
+		t.Errorf("Unexpected request: %s %s", request.Method, request.URL)
+	}
+
+	body, _ := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	sent, _ := url.ParseQuery(string(body))
+	if sent.Get("TOKEN") != "EC-1" || sent.Get("USER") != "user" || sent.Get("SUBJECT") != "seller@example.com" || sent.Get("VERSION") != paypal.NVP_VERSION {
+		t.Errorf("Unexpected body: %#v", sent)
+	}
+	if len(values.Get("USER")) != 0 {
+		t.Errorf("BuildRequest changed the caller's values")
+	}
+
+	// e.g. a proxy adding its own field before sending
+	sent.Set("X-SIGNED", "1")
+	request, _ = client.BuildRequest(values)
+	request.Body.Close()
+	request.Body = ioutil.NopCloser(strings.NewReader(sent.Encode()))
+	request.ContentLength = int64(len(sent.Encode()))
+	response, err := client.Do(request)
+	if err != nil || response.Token != "EC-1" || response.Values.Get("SIGNED") != "1" {
+		t.Errorf("Unexpected response: %#v, %v", response, err)
+	}
+}
@@ -0,0 +1,103 @@
+package paypal
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// Outcome of one call of a bulk run, Index being its position in the input
+type BulkResult struct {
+	Index    int
+	Response *PayPalResponse
+	Err      error
+}
+
+// Returned by Bulk runs in which at least one call failed
+type BulkError struct {
+	Total  int
+	Failed []BulkResult
+}
+
+func (e *BulkError) Error() string {
+	return fmt.Sprintf("PayPal bulk run: %d of %d calls failed, first error: %s", len(e.Failed), e.Total, e.Failed[0].Err)
+}
+
+// Runs many calls on a bounded number of goroutines
+type Bulk struct {
+	client  *PayPalClient
+	workers int
+	limiter RateLimiter
+}
+
+// limiter may be nil; when set it is shared by all workers
+func NewBulk(client *PayPalClient, workers int, limiter RateLimiter) *Bulk {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Bulk{client, workers, limiter}
+}
+
+// Runs each request through PerformRequest with opts
+func (b *Bulk) Run(requests []url.Values, opts ...CallOption) ([]BulkResult, error) {
+	calls := make([]func() (*PayPalResponse, error), len(requests))
+	for i := range requests {
+		values := requests[i]
+		calls[i] = func() (*PayPalResponse, error) {
+			return b.client.PerformRequest(values, opts...)
+		}
+	}
+	return b.RunCalls(calls, opts...)
+}
+
+// Runs arbitrary calls, typically closures around the typed client methods.
+// Results are returned in input order; the error is a *BulkError if any
+// call failed. Only the context of opts is used here, see WithContext: once
+// it is done no further calls are started, and those left get its error.
+func (b *Bulk) RunCalls(calls []func() (*PayPalResponse, error), opts ...CallOption) ([]BulkResult, error) {
+	ctx := b.client.callSettings(opts).ctx
+	results := make([]BulkResult, len(calls))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < b.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := waitLimiter(ctx, b.limiter); err != nil {
+					results[i] = BulkResult{i, nil, err}
+					continue
+				}
+				response, err := calls[i]()
+				results[i] = BulkResult{i, response, err}
+			}
+		}()
+	}
+
+	dispatched := 0
+dispatch:
+	for ; dispatched < len(calls); dispatched++ {
+		select {
+		case jobs <- dispatched:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	for i := dispatched; i < len(calls); i++ {
+		results[i] = BulkResult{i, nil, ctx.Err()}
+	}
+
+	bulkErr := &BulkError{Total: len(calls)}
+	for _, result := range results {
+		if result.Err != nil {
+			bulkErr.Failed = append(bulkErr.Failed, result)
+		}
+	}
+	if len(bulkErr.Failed) != 0 {
+		return results, bulkErr
+	}
+	return results, nil
+}
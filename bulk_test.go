@@ -0,0 +1,117 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Answers every request with the NVP body produced by respond
+type nvpTransport func(values url.Values) string
+
+func (respond nvpTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, _ := ioutil.ReadAll(r.Body)
+	values, _ := url.ParseQuery(string(body))
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(respond(values))),
+		Header:     make(http.Header),
+		Request:    r,
+	}, nil
+}
+
+func TestBulkRun(t *testing.T) {
+	var inFlight, maxInFlight int32
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+
+		if values.Get("TRANSACTIONID") == "bad" {
+			return "ACK=Failure&L_ERRORCODE0=10004&L_SHORTMESSAGE0=Invalid+transaction+ID"
+		}
+		return "ACK=Success&TRANSACTIONID=" + values.Get("TRANSACTIONID")
+	})})
+
+	requests := make([]url.Values, 20)
+	for i := range requests {
+		requests[i] = url.Values{"METHOD": {"RefundTransaction"}, "TRANSACTIONID": {"ok"}}
+	}
+	requests[7].Set("TRANSACTIONID", "bad")
+
+	results, err := paypal.NewBulk(client, 3, nil).Run(requests)
+
+	bulkErr, ok := err.(*paypal.BulkError)
+	if !ok {
+		t.Fatalf("Expected a *BulkError, got %#v", err)
+	}
+	if len(bulkErr.Failed) != 1 || bulkErr.Failed[0].Index != 7 || bulkErr.Total != 20 {
+		t.Errorf("Unexpected failures reported: %#v", bulkErr)
+	}
+	if len(results) != 20 || results[3].Response.Values.Get("TRANSACTIONID") != "ok" {
+		t.Errorf("Per-item results missing: %#v", results)
+	}
+	if maxInFlight > 3 {
+		t.Errorf("Expected at most 3 concurrent calls, saw %d", maxInFlight)
+	}
+}
+
+func TestBulkRateLimit(t *testing.T) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		return "ACK=Success"
+	})})
+
+	requests := make([]url.Values, 5)
+	for i := range requests {
+		requests[i] = url.Values{"METHOD": {"GetBalance"}}
+	}
+
+	start := time.Now()
+	if _, err := paypal.NewBulk(client, 5, paypal.NewRateLimiter(100)).Run(requests); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("5 calls at 100/s finished in %s, limiter not applied", elapsed)
+	}
+}
+
+func TestBulkCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int32
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		// the first call cancels the run
+		if atomic.AddInt32(&calls, 1) == 1 {
+			cancel()
+		}
+		return "ACK=Success&SUBJECT=" + values.Get("SUBJECT")
+	})})
+
+	requests := make([]url.Values, 20)
+	for i := range requests {
+		requests[i] = url.Values{"METHOD": {"GetBalance"}}
+	}
+	results, err := paypal.NewBulk(client, 1, nil).Run(requests, paypal.WithContext(ctx), paypal.WithSubject("seller@example.com"))
+	bulkErr, ok := err.(*paypal.BulkError)
+	if !ok || len(bulkErr.Failed) != 19 {
+		t.Fatalf("Expected the 19 calls after the cancel to fail, got %#v", err)
+	}
+	if results[0].Err != nil || results[0].Response.Values.Get("SUBJECT") != "seller@example.com" {
+		t.Errorf("Expected the first call made with the run's options, got %#v", results[0])
+	}
+	if results[19].Err != context.Canceled || atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("Expected no calls after the cancel, PayPal called %d times", calls)
+	}
+}
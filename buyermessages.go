@@ -0,0 +1,105 @@
+package paypal
+
+import "strings"
+
+// What went wrong, in terms a buyer can act on
+type BuyerMessageKey string
+
+const (
+	BUYER_MESSAGE_GENERIC         BuyerMessageKey = "generic"
+	BUYER_MESSAGE_DECLINED        BuyerMessageKey = "declined"
+	BUYER_MESSAGE_RETRY_LATER     BuyerMessageKey = "retry_later"
+	BUYER_MESSAGE_SESSION_EXPIRED BuyerMessageKey = "session_expired"
+	BUYER_MESSAGE_INVALID_ADDRESS BuyerMessageKey = "invalid_address"
+	BUYER_MESSAGE_ALREADY_PAID    BuyerMessageKey = "already_paid"
+)
+
+var buyerMessageKeys = map[string]BuyerMessageKey{
+	"10001":             BUYER_MESSAGE_RETRY_LATER,
+	"10445":             BUYER_MESSAGE_RETRY_LATER,
+	"10417":             BUYER_MESSAGE_DECLINED,
+	"10422":             BUYER_MESSAGE_DECLINED,
+	"10486":             BUYER_MESSAGE_DECLINED,
+	"10736":             BUYER_MESSAGE_INVALID_ADDRESS,
+	"10415":             BUYER_MESSAGE_ALREADY_PAID,
+	"11607":             BUYER_MESSAGE_ALREADY_PAID,
+	ERROR_TOKEN_EXPIRED: BUYER_MESSAGE_SESSION_EXPIRED,
+}
+
+// Built-in messages by language; none of them reveal merchant-side details
+var buyerMessages = map[string]map[BuyerMessageKey]string{
+	"en": {
+		BUYER_MESSAGE_GENERIC:         "We couldn't complete your payment. Please try again or choose another payment method.",
+		BUYER_MESSAGE_DECLINED:        "Your payment method was declined. Please return to PayPal and choose another way to pay.",
+		BUYER_MESSAGE_RETRY_LATER:     "PayPal is temporarily unavailable. Please try again in a few minutes.",
+		BUYER_MESSAGE_SESSION_EXPIRED: "Your checkout session has expired. Please start checkout again.",
+		BUYER_MESSAGE_INVALID_ADDRESS: "Your shipping address couldn't be verified. Please check it and try again.",
+		BUYER_MESSAGE_ALREADY_PAID:    "This order has already been paid.",
+	},
+	"de": {
+		BUYER_MESSAGE_GENERIC:         "Ihre Zahlung konnte nicht abgeschlossen werden. Bitte versuchen Sie es erneut oder wählen Sie eine andere Zahlungsart.",
+		BUYER_MESSAGE_DECLINED:        "Ihre Zahlungsquelle wurde abgelehnt. Bitte kehren Sie zu PayPal zurück und wählen Sie eine andere.",
+		BUYER_MESSAGE_RETRY_LATER:     "PayPal ist vorübergehend nicht erreichbar. Bitte versuchen Sie es in einigen Minuten erneut.",
+		BUYER_MESSAGE_SESSION_EXPIRED: "Ihre Sitzung ist abgelaufen. Bitte starten Sie den Bezahlvorgang erneut.",
+		BUYER_MESSAGE_INVALID_ADDRESS: "Ihre Lieferadresse konnte nicht überprüft werden. Bitte prüfen Sie sie und versuchen Sie es erneut.",
+		BUYER_MESSAGE_ALREADY_PAID:    "Diese Bestellung wurde bereits bezahlt.",
+	},
+	"fr": {
+		BUYER_MESSAGE_GENERIC:         "Votre paiement n'a pas pu être effectué. Veuillez réessayer ou choisir un autre moyen de paiement.",
+		BUYER_MESSAGE_DECLINED:        "Votre moyen de paiement a été refusé. Veuillez retourner sur PayPal et en choisir un autre.",
+		BUYER_MESSAGE_RETRY_LATER:     "PayPal est temporairement indisponible. Veuillez réessayer dans quelques minutes.",
+		BUYER_MESSAGE_SESSION_EXPIRED: "Votre session a expiré. Veuillez recommencer le paiement.",
+		BUYER_MESSAGE_INVALID_ADDRESS: "Votre adresse de livraison n'a pas pu être vérifiée. Veuillez la vérifier et réessayer.",
+		BUYER_MESSAGE_ALREADY_PAID:    "Cette commande a déjà été payée.",
+	},
+	"es": {
+		BUYER_MESSAGE_GENERIC:         "No pudimos completar su pago. Inténtelo de nuevo o elija otro método de pago.",
+		BUYER_MESSAGE_DECLINED:        "Su método de pago fue rechazado. Vuelva a PayPal y elija otra forma de pago.",
+		BUYER_MESSAGE_RETRY_LATER:     "PayPal no está disponible en este momento. Inténtelo de nuevo en unos minutos.",
+		BUYER_MESSAGE_SESSION_EXPIRED: "Su sesión ha caducado. Vuelva a iniciar el proceso de pago.",
+		BUYER_MESSAGE_INVALID_ADDRESS: "No se pudo verificar su dirección de envío. Revísela e inténtelo de nuevo.",
+		BUYER_MESSAGE_ALREADY_PAID:    "Este pedido ya ha sido pagado.",
+	},
+}
+
+// Returns the message for key in language, or false to fall back to the
+// built-in messages
+type BuyerMessageTranslator func(key BuyerMessageKey, language string) (string, bool)
+
+// Which BuyerMessageKey describes err for the buyer
+func BuyerMessageKeyFor(err error) BuyerMessageKey {
+	switch e := err.(type) {
+	case *PayPalError:
+		if key, ok := buyerMessageKeys[e.ErrorCode]; ok {
+			return key
+		}
+	case *TransportError:
+		return BUYER_MESSAGE_RETRY_LATER
+	}
+	if err == ErrExpiredToken {
+		return BUYER_MESSAGE_SESSION_EXPIRED
+	}
+	return BUYER_MESSAGE_GENERIC
+}
+
+// A message about err that is safe to show the buyer, unlike PayPal's long
+// messages which are meant for the merchant. language is a tag like "de" or
+// "fr-CA"; unsupported languages get English. translate may be nil.
+func BuyerMessage(err error, language string, translate BuyerMessageTranslator) string {
+	key := BuyerMessageKeyFor(err)
+	if translate != nil {
+		if message, ok := translate(key, language); ok {
+			return message
+		}
+	}
+
+	base := strings.ToLower(language)
+	if i := strings.IndexAny(base, "-_"); i >= 0 {
+		base = base[:i]
+	}
+	messages, ok := buyerMessages[base]
+	if !ok {
+		messages = buyerMessages["en"]
+	}
+	return messages[key]
+}
@@ -0,0 +1,34 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"testing"
+)
+
+func TestBuyerMessage(t *testing.T) {
+	declined := &paypal.PayPalError{ErrorCode: "10486", LongMessage: "This transaction couldn't be completed. Please redirect your customer to PayPal."}
+
+	if message := paypal.BuyerMessage(declined, "en-US", nil); message != "Your payment method was declined. Please return to PayPal and choose another way to pay." {
+		t.Errorf("Unexpected English message: %q", message)
+	}
+	if message := paypal.BuyerMessage(paypal.ErrExpiredToken, "de_DE", nil); message != "Ihre Sitzung ist abgelaufen. Bitte starten Sie den Bezahlvorgang erneut." {
+		t.Errorf("Unexpected German message: %q", message)
+	}
+	if message := paypal.BuyerMessage(&paypal.PayPalError{ErrorCode: "10002"}, "xx", nil); message != "We couldn't complete your payment. Please try again or choose another payment method." {
+		t.Errorf("Expected the generic English message, got %q", message)
+	}
+
+	translate := func(key paypal.BuyerMessageKey, language string) (string, bool) {
+		if language == "nl" && key == paypal.BUYER_MESSAGE_DECLINED {
+			return "Uw betaalmethode is geweigerd.", true
+		}
+		return "", false
+	}
+	if message := paypal.BuyerMessage(declined, "nl", translate); message != "Uw betaalmethode is geweigerd." {
+		t.Errorf("Translator not used: %q", message)
+	}
+	if message := paypal.BuyerMessage(declined, "fr", translate); message != "Votre moyen de paiement a été refusé. Veuillez retourner sur PayPal et en choisir un autre." {
+		t.Errorf("Expected the built-in French message when the translator has none, got %q", message)
+	}
+}
@@ -0,0 +1,69 @@
+package paypal
+
+import "context"
+
+// API credentials of a PayPal account
+type Credentials struct {
+	Username  string
+	Password  string
+	Signature string
+}
+
+// Changes how a single API call is made, e.g. to act for another account
+type CallOption func(settings *callSettings)
+
+type callSettings struct {
+	credentials Credentials
+	subject     string
+	ctx         context.Context
+	// Defaults to NVP_VERSION
+	version string
+	// Build the request without sending it, see WithDryRun
+	dryRun bool
+	// A Capabilities probe, whose expected errors are not reported to
+	// hooks or metrics and which the live guard lets through
+	probe bool
+}
+
+// Calls on behalf of the account with the given email or payer id, which
+// must have granted the client's account third-party permission to the API
+func WithSubject(account string) CallOption {
+	return func(settings *callSettings) {
+		settings.subject = account
+	}
+}
+
+// Calls with another account's API credentials instead of the client's
+func WithCredentials(credentials Credentials) CallOption {
+	return func(settings *callSettings) {
+		settings.credentials = credentials
+	}
+}
+
+// Makes the call with ctx, which can cancel it and carry a request id set
+// with ContextWithRequestId
+func WithContext(ctx context.Context) CallOption {
+	return func(settings *callSettings) {
+		settings.ctx = ctx
+	}
+}
+
+func withVersion(version string) CallOption {
+	return func(settings *callSettings) {
+		settings.version = version
+	}
+}
+
+func withProbe() CallOption {
+	return func(settings *callSettings) {
+		settings.probe = true
+	}
+}
+
+func (pClient *PayPalClient) callSettings(opts []CallOption) callSettings {
+	settings := callSettings{credentials: Credentials{pClient.username, pClient.password, pClient.signature}, subject: pClient.subject, ctx: context.Background(), version: NVP_VERSION}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	return settings
+}
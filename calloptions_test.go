@@ -0,0 +1,34 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestCallOptions(t *testing.T) {
+	var sent []url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = append(sent, values)
+		return "ACK=Success"
+	})})
+
+	client.GetTransactionDetails("TXN")
+	client.GetTransactionDetails("TXN", paypal.WithSubject("seller@example.com"))
+	client.GetTransactionDetails("TXN", paypal.WithCredentials(paypal.Credentials{"other", "otherpass", "othersig"}))
+
+	if len(sent) != 3 {
+		t.Fatalf("Expected 3 calls, got %d", len(sent))
+	}
+	if sent[0].Get("USER") != "user" || len(sent[0].Get("SUBJECT")) != 0 {
+		t.Errorf("Unexpected default call: %#v", sent[0])
+	}
+	if sent[1].Get("USER") != "user" || sent[1].Get("SUBJECT") != "seller@example.com" {
+		t.Errorf("Subject not sent: %#v", sent[1])
+	}
+	if sent[2].Get("USER") != "other" || sent[2].Get("PWD") != "otherpass" || sent[2].Get("SIGNATURE") != "othersig" {
+		t.Errorf("Credentials not overridden: %#v", sent[2])
+	}
+}
@@ -0,0 +1,103 @@
+package paypal
+
+import "net/url"
+
+// Error code of a reference transaction on an account without reference
+// transactions enabled
+const ERROR_REFERENCE_TRANSACTIONS_DISABLED = "11452"
+
+// Error code of a reference transaction made with an unknown reference id
+const ERROR_INVALID_REFERENCE_ID = "11451"
+
+// Whether an account can use a feature, as far as Capabilities could tell
+type FeatureStatus int
+
+const (
+	FEATURE_UNKNOWN FeatureStatus = iota
+	FEATURE_ENABLED
+	FEATURE_DISABLED
+)
+
+type PayPalCapabilities struct {
+	ReferenceTransactions FeatureStatus
+	ParallelPayments      FeatureStatus
+	// MassPay cannot be probed without sending money, so this stays
+	// FEATURE_UNKNOWN
+	Payouts FeatureStatus
+	// Error code each failed probe returned, keyed by API method
+	ErrorCodes map[string]string
+}
+
+// The balances are in the response's L_AMTn and L_CURRENCYCODEn values
+func (pClient *PayPalClient) GetBalance(allCurrencies bool, opts ...CallOption) (*PayPalResponse, error) {
+	values := url.Values{}
+	values.Set("METHOD", "GetBalance")
+	if allCurrencies {
+		values.Add("RETURNALLCURRENCIES", "1")
+	}
+	return pClient.PerformRequest(values, opts...)
+}
+
+// Probes which optional features the account has enabled, so applications
+// can leave out what isn't available instead of failing at checkout.
+//
+// GetBalance checks the credentials first; its error is returned as is.
+// Reference transactions are probed with a DoReferenceTransaction for a
+// reference id that doesn't exist, and parallel payments with a
+// SetExpressCheckout of two payment requests whose token is never used.
+// Neither moves money. The probes' errors are expected, so they don't reach
+// OnErrorCode hooks or metrics, and the live guard doesn't block them.
+func (pClient *PayPalClient) Capabilities(opts ...CallOption) (*PayPalCapabilities, error) {
+	if _, err := pClient.GetBalance(false, opts...); err != nil {
+		return nil, err
+	}
+
+	probeOpts := append(append([]CallOption(nil), opts...), withProbe())
+	capabilities := &PayPalCapabilities{ErrorCodes: make(map[string]string)}
+
+	reference := url.Values{}
+	reference.Set("METHOD", "DoReferenceTransaction")
+	reference.Add("REFERENCEID", "B-00000000000000000")
+	reference.Add("PAYMENTACTION", string(PAYMENT_ACTION_AUTHORIZATION))
+	reference.Add("AMT", formatAmount(1))
+	reference.Add("CURRENCYCODE", "USD")
+	_, err := pClient.PerformRequest(reference, probeOpts...)
+	capabilities.ReferenceTransactions = capabilities.probe("DoReferenceTransaction", err)
+	switch capabilities.ErrorCodes["DoReferenceTransaction"] {
+	case ERROR_INVALID_REFERENCE_ID:
+		capabilities.ReferenceTransactions = FEATURE_ENABLED
+	case ERROR_REFERENCE_TRANSACTIONS_DISABLED:
+		capabilities.ReferenceTransactions = FEATURE_DISABLED
+	}
+
+	parallel := url.Values{}
+	parallel.Set("METHOD", "SetExpressCheckout")
+	parallel.Add("RETURNURL", "https://localhost/return")
+	parallel.Add("CANCELURL", "https://localhost/cancel")
+	for _, request := range []string{"PAYMENTREQUEST_0_", "PAYMENTREQUEST_1_"} {
+		parallel.Add(request+"AMT", formatAmount(1))
+		parallel.Add(request+"CURRENCYCODE", "USD")
+		parallel.Add(request+"PAYMENTACTION", string(PAYMENT_ACTION_ORDER))
+	}
+	parallel.Add("PAYMENTREQUEST_0_PAYMENTREQUESTID", "probe-0")
+	parallel.Add("PAYMENTREQUEST_1_PAYMENTREQUESTID", "probe-1")
+	_, err = pClient.PerformRequest(parallel, probeOpts...)
+	capabilities.ParallelPayments = capabilities.probe("SetExpressCheckout", err)
+	if _, failed := capabilities.ErrorCodes["SetExpressCheckout"]; failed {
+		capabilities.ParallelPayments = FEATURE_DISABLED
+	}
+
+	return capabilities, nil
+}
+
+// FEATURE_ENABLED on success, otherwise FEATURE_UNKNOWN with the error
+// code recorded for the caller to refine
+func (capabilities *PayPalCapabilities) probe(method string, err error) FeatureStatus {
+	if err == nil {
+		return FEATURE_ENABLED
+	}
+	if pError, ok := err.(*PayPalError); ok {
+		capabilities.ErrorCodes[method] = pError.ErrorCode
+	}
+	return FEATURE_UNKNOWN
+}
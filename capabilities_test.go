@@ -0,0 +1,81 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestCapabilities(t *testing.T) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		switch values.Get("METHOD") {
+		case "GetBalance":
+			return "ACK=Success&L_AMT0=10.00&L_CURRENCYCODE0=USD"
+		case "DoReferenceTransaction":
+			return "ACK=Failure&L_ERRORCODE0=11452&L_SHORTMESSAGE0=Merchant+not+enabled+for+reference+transactions"
+		case "SetExpressCheckout":
+			return "ACK=Success&TOKEN=EC-PROBE"
+		}
+		return "ACK=Failure"
+	})})
+
+	capabilities, err := client.Capabilities()
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if capabilities.ReferenceTransactions != paypal.FEATURE_DISABLED || capabilities.ParallelPayments != paypal.FEATURE_ENABLED || capabilities.Payouts != paypal.FEATURE_UNKNOWN {
+		t.Errorf("Unexpected capabilities: %#v", capabilities)
+	}
+	if capabilities.ErrorCodes["DoReferenceTransaction"] != "11452" {
+		t.Errorf("Probe error code not recorded: %#v", capabilities.ErrorCodes)
+	}
+}
+
+func TestCapabilitiesBadCredentials(t *testing.T) {
+	client := paypal.NewClient("user", "wrong", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		return "ACK=Failure&L_ERRORCODE0=10002&L_SHORTMESSAGE0=Security+error"
+	})})
+
+	if _, err := client.Capabilities(); err == nil {
+		t.Errorf("Expected the GetBalance error")
+	}
+}
+
+func TestCapabilitiesProbesNotReported(t *testing.T) {
+	client := paypal.NewClient("user", "pass", "sig", false, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		switch values.Get("METHOD") {
+		case "GetBalance":
+			return "ACK=Success&L_AMT0=10.00&L_CURRENCYCODE0=USD"
+		case "DoReferenceTransaction":
+			return "ACK=Failure&L_ERRORCODE0=11452&L_SHORTMESSAGE0=Merchant+not+enabled+for+reference+transactions"
+		}
+		return "ACK=Failure&L_ERRORCODE0=10004&L_SHORTMESSAGE0=Transaction+refused"
+	})})
+	client.SetRequireLiveConfirmation(true)
+	metrics := paypal.NewMemoryMetrics()
+	client.SetMetrics(metrics)
+	hooked := 0
+	client.OnErrorCode("11452", func(response *paypal.PayPalResponse, err *paypal.PayPalError) {
+		hooked++
+	})
+
+	capabilities, err := client.Capabilities()
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if capabilities.ReferenceTransactions != paypal.FEATURE_DISABLED || capabilities.ParallelPayments != paypal.FEATURE_DISABLED {
+		t.Errorf("Expected the live guard to let the probes through, got %#v", capabilities)
+	}
+	if hooked != 0 || len(metrics.ErrorCounts()) != 0 {
+		t.Errorf("Expected probe errors to skip hooks and metrics, got %d hook calls and %#v", hooked, metrics.ErrorCounts())
+	}
+
+	// the client's own calls are still reported
+	client.ConfirmLive()
+	client.PerformRequest(url.Values{"METHOD": {"DoReferenceTransaction"}, "REFERENCEID": {"B-1"}})
+	if hooked != 1 || metrics.ErrorCounts()["11452"] != 1 {
+		t.Errorf("Expected a regular call's error to be reported, got %d hook calls and %#v", hooked, metrics.ErrorCounts())
+	}
+}
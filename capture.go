@@ -0,0 +1,104 @@
+package paypal
+
+import (
+	"fmt"
+	"net/url"
+)
+
+const (
+	// Closes the authorization; the remaining amount is released
+	COMPLETE_TYPE_COMPLETE = "Complete"
+	// Leaves the authorization open for further captures
+	COMPLETE_TYPE_NOT_COMPLETE = "NotComplete"
+)
+
+type PayPalCapture struct {
+	AuthorizationId string
+	Amount          float64
+	CurrencyCode    string
+	// COMPLETE_TYPE_COMPLETE or COMPLETE_TYPE_NOT_COMPLETE, defaults to
+	// COMPLETE_TYPE_COMPLETE
+	CompleteType string
+	// Merchant references carried by the captured transaction, sent when set
+	InvoiceId      string
+	Note           string
+	SoftDescriptor string
+}
+
+// Returned by CaptureAuthorization before calling PayPal when the amount
+// exceeds what is left on the authorization
+type OverCaptureError struct {
+	AuthorizationId string
+	Amount          float64
+	Remaining       float64
+}
+
+func (e *OverCaptureError) Error() string {
+	return fmt.Sprintf("PayPal authorization %s has %.2f left to capture, %.2f requested", e.AuthorizationId, e.Remaining, e.Amount)
+}
+
+// The capture's transaction id is in the response's TRANSACTIONID value
+func (pClient *PayPalClient) DoCapture(capture PayPalCapture, opts ...CallOption) (*PayPalResponse, error) {
+	values := url.Values{}
+	values.Set("METHOD", "DoCapture")
+	values.Add("AUTHORIZATIONID", capture.AuthorizationId)
+	values.Add("AMT", formatAmount(capture.Amount))
+	values.Add("CURRENCYCODE", capture.CurrencyCode)
+	completeType := capture.CompleteType
+	if len(completeType) == 0 {
+		completeType = COMPLETE_TYPE_COMPLETE
+	}
+	values.Add("COMPLETETYPE", completeType)
+	if len(capture.InvoiceId) != 0 {
+		values.Add("INVNUM", capture.InvoiceId)
+	}
+	if len(capture.Note) != 0 {
+		values.Add("NOTE", capture.Note)
+	}
+	if len(capture.SoftDescriptor) != 0 {
+		values.Add("SOFTDESCRIPTOR", capture.SoftDescriptor)
+	}
+	return pClient.PerformRequest(values, opts...)
+}
+
+// Amount of the authorization not captured yet
+func (auth *PayPalAuthorization) Remaining() float64 {
+	return float64(toCents(auth.Amount)-toCents(auth.Captured)) / 100
+}
+
+// Captures part of auth, leaving it open for further captures unless final
+// is set or nothing remains afterwards. Captured, CaptureIds and Completed
+// on auth are updated; captures of the same auth, and AuthorizationTracker
+// sweeps of it, run one at a time.
+func (pClient *PayPalClient) CaptureAuthorization(auth *PayPalAuthorization, amount float64, final bool, opts ...CallOption) (string, error) {
+	auth.mu.Lock()
+	defer auth.mu.Unlock()
+
+	remaining := auth.Remaining()
+	if toCents(amount) > toCents(remaining) {
+		return "", &OverCaptureError{auth.AuthorizationId, amount, remaining}
+	}
+
+	completeType := COMPLETE_TYPE_NOT_COMPLETE
+	if final || toCents(amount) == toCents(remaining) {
+		completeType = COMPLETE_TYPE_COMPLETE
+	}
+
+	response, err := pClient.DoCapture(PayPalCapture{
+		AuthorizationId: auth.AuthorizationId,
+		Amount:          amount,
+		CurrencyCode:    auth.CurrencyCode,
+		CompleteType:    completeType,
+	}, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	transactionId := response.Values.Get("TRANSACTIONID")
+	auth.Captured += amount
+	auth.CaptureIds = append(auth.CaptureIds, transactionId)
+	if completeType == COMPLETE_TYPE_COMPLETE {
+		auth.Completed = true
+	}
+	return transactionId, nil
+}
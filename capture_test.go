@@ -0,0 +1,74 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestPartialCaptures(t *testing.T) {
+	var completeTypes []string
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		completeTypes = append(completeTypes, values.Get("COMPLETETYPE"))
+		return "ACK=Success&TRANSACTIONID=CAPTURE-" + strconv.Itoa(len(completeTypes))
+	})})
+
+	auth := &paypal.PayPalAuthorization{AuthorizationId: "AUTH", Amount: 100, CurrencyCode: "USD"}
+
+	first, err := client.CaptureAuthorization(auth, 30, false)
+	if err != nil || first != "CAPTURE-1" {
+		t.Fatalf("Unexpected first capture: %q, %#v", first, err)
+	}
+	if auth.Remaining() != 70 || auth.Completed {
+		t.Errorf("Expected 70 remaining on an open authorization, got %.2f", auth.Remaining())
+	}
+
+	if _, err := client.CaptureAuthorization(auth, 80, false); err == nil {
+		t.Errorf("Expected an *OverCaptureError capturing more than remains")
+	}
+
+	second, err := client.CaptureAuthorization(auth, 70, false)
+	if err != nil || second != "CAPTURE-2" {
+		t.Fatalf("Unexpected second capture: %q, %#v", second, err)
+	}
+
+	if len(completeTypes) != 2 || completeTypes[0] != "NotComplete" || completeTypes[1] != "Complete" {
+		t.Errorf("Unexpected COMPLETETYPE values: %#v", completeTypes)
+	}
+	if !auth.Completed || auth.Remaining() != 0 || len(auth.CaptureIds) != 2 {
+		t.Errorf("Authorization not completed after capturing everything: %#v", auth)
+	}
+}
+
+func TestCaptureMerchantReferences(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success&TRANSACTIONID=CAPTURE"
+	})})
+
+	_, err := client.DoCapture(paypal.PayPalCapture{
+		AuthorizationId: "AUTH",
+		Amount:          12.5,
+		CurrencyCode:    "USD",
+		CompleteType:    paypal.COMPLETE_TYPE_COMPLETE,
+		InvoiceId:       "INV-2014-0042",
+		Note:            "Shipped in two boxes",
+		SoftDescriptor:  "EXAMPLE SHOP",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if sent.Get("INVNUM") != "INV-2014-0042" || sent.Get("NOTE") != "Shipped in two boxes" || sent.Get("SOFTDESCRIPTOR") != "EXAMPLE SHOP" || sent.Get("AMT") != "12.50" {
+		t.Errorf("Merchant references not sent: %#v", sent)
+	}
+	if _, err := client.DoCapture(paypal.PayPalCapture{AuthorizationId: "AUTH", Amount: 5, CurrencyCode: "USD"}); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if sent.Get("COMPLETETYPE") != paypal.COMPLETE_TYPE_COMPLETE {
+		t.Errorf("Expected a capture without CompleteType to be Complete, got %q", sent.Get("COMPLETETYPE"))
+	}
+}
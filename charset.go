@@ -0,0 +1,79 @@
+package paypal
+
+import (
+	"mime"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+const REQUEST_CONTENT_TYPE = "application/x-www-form-urlencoded; charset=UTF-8"
+
+// windows-1252 characters in the 0x80-0x9F range, which ISO-8859-1 leaves
+// to control codes. Unassigned positions map to themselves.
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// Charset declared by a Content-Type header, lower cased. Defaults to utf-8
+func responseCharset(contentType string) string {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil || len(params["charset"]) == 0 {
+		return "utf-8"
+	}
+	return strings.ToLower(params["charset"])
+}
+
+// Re-encodes decoded NVP values to UTF-8 in place. Charsets other than
+// UTF-8, ISO-8859-1 and windows-1252 are left untouched.
+func convertCharset(values url.Values, charset string) {
+	var toRune func(b byte) rune
+	switch charset {
+	case "iso-8859-1", "latin1", "latin-1":
+		toRune = func(b byte) rune { return rune(b) }
+	case "windows-1252", "cp1252":
+		toRune = func(b byte) rune {
+			if b >= 0x80 && b < 0xA0 {
+				return windows1252[b-0x80]
+			}
+			return rune(b)
+		}
+	default:
+		return
+	}
+
+	converted := make(url.Values, len(values))
+	for key, list := range values {
+		for i, value := range list {
+			list[i] = singleByteToUTF8(value, toRune)
+		}
+		converted[singleByteToUTF8(key, toRune)] = list
+	}
+	for key := range values {
+		delete(values, key)
+	}
+	for key, list := range converted {
+		values[key] = list
+	}
+}
+
+func singleByteToUTF8(s string, toRune func(b byte) rune) string {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) * 2)
+	for i := 0; i < len(s); i++ {
+		b.WriteRune(toRune(s[i]))
+	}
+	return b.String()
+}
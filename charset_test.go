@@ -0,0 +1,61 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type charsetTransport struct {
+	contentType string
+	body        string
+	sent        *http.Request
+}
+
+func (c *charsetTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	c.sent = r
+	header := make(http.Header)
+	header.Set("Content-Type", c.contentType)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(c.body)),
+		Header:     header,
+		Request:    r,
+	}, nil
+}
+
+func TestRequestDeclaresUTF8(t *testing.T) {
+	transport := &charsetTransport{contentType: "text/plain; charset=utf-8", body: "ACK=Success"}
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: transport})
+
+	client.GetExpressCheckoutDetails("EC-TOKEN")
+
+	if transport.sent.Header.Get("Content-Type") != "application/x-www-form-urlencoded; charset=UTF-8" {
+		t.Errorf("Unexpected request Content-Type %q", transport.sent.Header.Get("Content-Type"))
+	}
+}
+
+func TestResponseCharsetDecoding(t *testing.T) {
+	responses := map[string]string{
+		"text/plain; charset=utf-8":        "ACK=Success&FIRSTNAME=J%C3%B6rg&SHIPTONAME=J%C3%B6rg+%E2%82%AC",
+		"text/plain; charset=ISO-8859-1":   "ACK=Success&FIRSTNAME=J%F6rg&SHIPTONAME=J%F6rg+%A4",
+		"text/plain; charset=windows-1252": "ACK=Success&FIRSTNAME=J%F6rg&SHIPTONAME=J%F6rg+%80",
+	}
+	expectedShipTo := map[string]string{
+		"text/plain; charset=utf-8":        "Jörg €",
+		"text/plain; charset=ISO-8859-1":   "Jörg ¤",
+		"text/plain; charset=windows-1252": "Jörg €",
+	}
+
+	for contentType, body := range responses {
+		client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: &charsetTransport{contentType: contentType, body: body}})
+		response, _ := client.GetExpressCheckoutDetails("EC-TOKEN")
+
+		if response.Values.Get("FIRSTNAME") != "Jörg" || response.Values.Get("SHIPTONAME") != expectedShipTo[contentType] {
+			t.Errorf("%s: decoded %q / %q", contentType, response.Values.Get("FIRSTNAME"), response.Values.Get("SHIPTONAME"))
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Package checkout is a small, API-neutral layer over PayPal payments: one
+// set of types for orders, captures and refunds, and a Payments interface
+// implemented by NVP (NewNVP). Code written against Payments does not
+// change when the backend behind it does, e.g. during a move to another
+// PayPal API.
+//
+//	payments := checkout.NewNVP(client, paypal.PAYMENT_ACTION_SALE)
+//	order, err := payments.CreateOrder(ctx, checkout.Order{...})
+//	// send the buyer to order.ApproveUrl; once they are back:
+//	capture, err := payments.CaptureOrder(ctx, order.Id)
+package checkout
+
+import "context"
+
+type Item struct {
+	Sku      string
+	Name     string
+	Amount   float64
+	Quantity int
+}
+
+type Order struct {
+	// Set by CreateOrder: the backend's id for the order
+	Id string
+	// Where to send the buyer to approve the payment, set by CreateOrder
+	ApproveUrl string
+
+	Currency    string
+	Items       []Item
+	Tax         float64
+	Shipping    float64
+	Description string
+	ReturnUrl   string
+	CancelUrl   string
+}
+
+// Sum of the items, tax and shipping
+func (order *Order) Total() float64 {
+	return float64(order.itemCents()+toCents(order.Tax)+toCents(order.Shipping)) / 100
+}
+
+func (order *Order) itemCents() int64 {
+	var cents int64
+	for _, item := range order.Items {
+		cents += toCents(item.Amount) * int64(item.Quantity)
+	}
+	return cents
+}
+
+type Capture struct {
+	// Id of the captured payment, what refunds are made against
+	Id       string
+	OrderId  string
+	Amount   float64
+	Currency string
+	// e.g. Completed or Pending
+	Status string
+}
+
+type Refund struct {
+	Id        string
+	CaptureId string
+	// 0 refunds the whole capture
+	Amount   float64
+	Currency string
+	Note     string
+	Status   string
+}
+
+type Payments interface {
+	// Registers order with PayPal, returning it with Id and ApproveUrl set
+	CreateOrder(ctx context.Context, order Order) (*Order, error)
+	// Takes the payment of an order the buyer approved
+	CaptureOrder(ctx context.Context, orderId string) (*Capture, error)
+	// Returns refund with Id and Status set
+	Refund(ctx context.Context, refund Refund) (*Refund, error)
+}
@@ -0,0 +1,53 @@
+package checkout
+
+import (
+	"context"
+	"testing"
+
+	"github.com/badoet/go-paypal"
+	"github.com/badoet/go-paypal/paypaltest"
+)
+
+func TestNVPPayments(t *testing.T) {
+	fake := paypaltest.NewFake()
+	fake.On("SetExpressCheckout", paypaltest.Response("ACK=Success&TOKEN=EC-4WL17777V4111184H"), nil)
+	fake.On("GetExpressCheckoutDetails", paypaltest.Response("ACK=Success&TOKEN=EC-4WL17777V4111184H&PAYERID=QYR5Z8XDVJNXQ"+
+		"&PAYMENTREQUEST_0_AMT=25.00&PAYMENTREQUEST_0_CURRENCYCODE=EUR"), nil)
+	fake.On("DoExpressCheckoutPayment", paypaltest.Response("ACK=Success&PAYMENTINFO_0_TRANSACTIONID=8RT51812D3056610N"+
+		"&PAYMENTINFO_0_AMT=25.00&PAYMENTINFO_0_CURRENCYCODE=EUR&PAYMENTINFO_0_PAYMENTSTATUS=Completed"), nil)
+	fake.On("RefundTransaction", paypaltest.Response("ACK=Success&REFUNDTRANSACTIONID=9PL23456E4167721P&GROSSREFUNDAMT=25.00"+
+		"&CURRENCYCODE=EUR&REFUNDSTATUS=instant"), nil)
+
+	payments := NewNVP(fake, paypal.PAYMENT_ACTION_SALE)
+	ctx := context.Background()
+
+	order, err := payments.CreateOrder(ctx, Order{
+		Currency: "EUR",
+		Items:    []Item{{Sku: "W-1", Name: "Widget", Amount: 10, Quantity: 2}},
+		Shipping: 5,
+	})
+	if err != nil || order.Id != "EC-4WL17777V4111184H" || len(order.ApproveUrl) == 0 {
+		t.Fatalf("Unexpected order %+v, %v", order, err)
+	}
+	sent := fake.CallsTo("SetExpressCheckout")[0].Args[0].(paypal.PayPalOrder)
+	if sent.SubTotal != 20 || sent.Total != 25 || sent.CurrencyCode != "EUR" {
+		t.Errorf("Unexpected PayPal order %+v", sent)
+	}
+
+	capture, err := payments.CaptureOrder(ctx, order.Id)
+	if err != nil || capture.Id != "8RT51812D3056610N" || capture.Amount != 25 || capture.Status != "Completed" {
+		t.Fatalf("Unexpected capture %+v, %v", capture, err)
+	}
+	args := fake.CallsTo("DoExpressCheckoutPayment")[0].Args
+	if args[1] != "QYR5Z8XDVJNXQ" || args[3] != "EUR" || args[4] != 25.0 {
+		t.Errorf("Unexpected payment call %v", args)
+	}
+
+	refund, err := payments.Refund(ctx, Refund{CaptureId: capture.Id})
+	if err != nil || refund.Id != "9PL23456E4167721P" || refund.Amount != 25 || refund.Status != "instant" {
+		t.Fatalf("Unexpected refund %+v, %v", refund, err)
+	}
+	if request := fake.CallsTo("RefundTransaction")[0].Args[0].(paypal.PayPalRefund); request.RefundType != paypal.REFUND_TYPE_FULL {
+		t.Errorf("Expected a full refund, got %+v", request)
+	}
+}
@@ -0,0 +1,104 @@
+package checkout
+
+import (
+	"context"
+	"math"
+
+	"github.com/badoet/go-paypal"
+)
+
+// Payments backed by Express Checkout: orders are checkout tokens and
+// captures are the transactions DoExpressCheckoutPayment creates
+type nvpPayments struct {
+	api    paypal.PayPalAPI
+	action paypal.PaymentAction
+}
+
+// api is typically a *paypal.PayPalClient. action is how CaptureOrder pays,
+// PAYMENT_ACTION_SALE to take the money right away.
+func NewNVP(api paypal.PayPalAPI, action paypal.PaymentAction) Payments {
+	return &nvpPayments{api, action}
+}
+
+func (p *nvpPayments) CreateOrder(ctx context.Context, order Order) (*Order, error) {
+	goods := make([]paypal.PayPalGood, len(order.Items))
+	for i, item := range order.Items {
+		goods[i] = paypal.PayPalGood{Id: item.Sku, Name: item.Name, Amount: item.Amount, Quantity: item.Quantity}
+	}
+	response, err := p.api.SetExpressCheckout(paypal.PayPalOrder{
+		SubTotal:     float64(order.itemCents()) / 100,
+		Tax:          order.Tax,
+		Shipping:     order.Shipping,
+		Total:        order.Total(),
+		CurrencyCode: order.Currency,
+		Description:  order.Description,
+		ReturnUrl:    order.ReturnUrl,
+		CancelUrl:    order.CancelUrl,
+	}, goods, paypal.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	order.Id = response.Token
+	order.ApproveUrl = response.CheckoutUrl()
+	return &order, nil
+}
+
+// Pays the checkout's full amount, as the buyer approved it
+func (p *nvpPayments) CaptureOrder(ctx context.Context, orderId string) (*Capture, error) {
+	response, err := p.api.GetExpressCheckoutDetails(orderId, paypal.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	var details paypal.PayPalCheckoutDetails
+	if err = response.Decode(&details); err != nil {
+		return nil, err
+	}
+
+	response, err = p.api.DoExpressCheckoutPayment(orderId, details.PayerId, p.action, details.Currency, details.Amount, paypal.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	var payment paypal.PayPalPaymentResponse
+	if err = response.Decode(&payment); err != nil {
+		return nil, err
+	}
+	return &Capture{
+		Id:       payment.TransactionId,
+		OrderId:  orderId,
+		Amount:   payment.Amount,
+		Currency: payment.Currency,
+		Status:   payment.Status,
+	}, nil
+}
+
+func (p *nvpPayments) Refund(ctx context.Context, refund Refund) (*Refund, error) {
+	request := paypal.PayPalRefund{
+		TransactionId: refund.CaptureId,
+		RefundType:    paypal.REFUND_TYPE_FULL,
+		Note:          refund.Note,
+	}
+	if refund.Amount > 0 {
+		request.RefundType = paypal.REFUND_TYPE_PARTIAL
+		request.Amount, request.CurrencyCode = refund.Amount, refund.Currency
+	}
+	response, err := p.api.RefundTransaction(request, paypal.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	var result paypal.RefundResult
+	if err = response.Decode(&result); err != nil {
+		return nil, err
+	}
+
+	refund.Id = result.RefundTransactionId
+	refund.Status = result.RefundStatus
+	if refund.Amount == 0 {
+		refund.Amount, refund.Currency = result.GrossRefundAmount, result.Currency
+	}
+	return &refund, nil
+}
+
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
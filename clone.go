@@ -0,0 +1,51 @@
+package paypal
+
+import "time"
+
+// Overrides a setting of the client returned by With
+type Option func(pClient *PayPalClient)
+
+// Switches between the sandbox and production endpoints. A ConfirmLive on
+// the original client does not carry over.
+func WithEnvironment(usesSandbox bool) Option {
+	return func(pClient *PayPalClient) {
+		pClient.usesSandbox = usesSandbox
+		pClient.liveConfirmed = false
+	}
+}
+
+// Sets the http.Client timeout; the transport stays shared
+func WithTimeout(timeout time.Duration) Option {
+	return func(pClient *PayPalClient) {
+		httpClient := *pClient.client
+		httpClient.Timeout = timeout
+		pClient.client = &httpClient
+	}
+}
+
+// Calls on behalf of account unless a call passes its own WithSubject
+func WithDefaultSubject(account string) Option {
+	return func(pClient *PayPalClient) {
+		pClient.subject = account
+	}
+}
+
+// Replaces the client's API credentials, e.g. when rotating a signature
+func WithDefaultCredentials(credentials Credentials) Option {
+	return func(pClient *PayPalClient) {
+		pClient.username = credentials.Username
+		pClient.password = credentials.Password
+		pClient.signature = credentials.Signature
+	}
+}
+
+// Returns a copy of the client with opts applied, leaving pClient as it
+// was. The copy shares the http transport, rate limiter, metrics, order
+// store and details cache; error code hooks registered on it are its own.
+func (pClient *PayPalClient) With(opts ...Option) *PayPalClient {
+	clone := *pClient
+	for _, opt := range opts {
+		opt(&clone)
+	}
+	return &clone
+}
@@ -0,0 +1,57 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientWith(t *testing.T) {
+	var sent []url.Values
+	httpClient := &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = append(sent, values)
+		return "ACK=Success"
+	})}
+	client := paypal.NewClient("user", "pass", "sig", true, httpClient)
+	client = client.With() // a plain copy behaves like the original
+
+	seller := client.With(paypal.WithDefaultSubject("seller@example.com"), paypal.WithTimeout(time.Second))
+	seller.GetTransactionDetails("TXN")
+	seller.GetTransactionDetails("TXN", paypal.WithSubject("other@example.com"))
+	client.GetTransactionDetails("TXN")
+
+	if sent[0].Get("SUBJECT") != "seller@example.com" || sent[1].Get("SUBJECT") != "other@example.com" || len(sent[2].Get("SUBJECT")) != 0 {
+		t.Errorf("Unexpected subjects: %q, %q, %q", sent[0].Get("SUBJECT"), sent[1].Get("SUBJECT"), sent[2].Get("SUBJECT"))
+	}
+	if httpClient.Timeout != 0 {
+		t.Errorf("WithTimeout changed the original http.Client")
+	}
+
+	sandbox, _ := client.SetExpressCheckout(paypal.PayPalOrder{CurrencyCode: "USD"}, nil)
+	live, _ := client.With(paypal.WithEnvironment(false)).SetExpressCheckout(paypal.PayPalOrder{CurrencyCode: "USD"}, nil)
+	if !strings.HasPrefix(sandbox.CheckoutUrl(), paypal.CHECKOUT_SANDBOX_URL) || !strings.HasPrefix(live.CheckoutUrl(), paypal.CHECKOUT_PRODUCTION_URL) {
+		t.Errorf("Unexpected checkout URLs: %s, %s", sandbox.CheckoutUrl(), live.CheckoutUrl())
+	}
+}
+
+func TestClientWithDefaultCredentials(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success"
+	})})
+
+	rotated := client.With(paypal.WithDefaultCredentials(paypal.Credentials{Username: "user", Password: "pass2", Signature: "sig2"}))
+	rotated.GetBalance(false)
+	if sent.Get("PWD") != "pass2" || sent.Get("SIGNATURE") != "sig2" {
+		t.Errorf("Rotated credentials not sent: %#v", sent)
+	}
+	client.GetBalance(false)
+	if sent.Get("SIGNATURE") != "sig" {
+		t.Errorf("Rotating credentials on a copy changed the original client")
+	}
+}
@@ -0,0 +1,273 @@
+// Command paypal makes one-off PayPal NVP calls from the shell, e.g. to look
+// up or refund a transaction while handling a support ticket.
+//
+//	paypal [-live] [-subject account] [-dry-run] <command> [flags]
+//
+// Commands are set-checkout, get-details, do-payment, refund, search and
+// balance; run "paypal <command> -h" for their flags. API credentials are
+// read from PAYPAL_USERNAME, PAYPAL_PASSWORD and PAYPAL_SIGNATURE. Calls go
+// to the sandbox unless -live is given. Every command prints the response
+// as a JSON object and exits with status 1 when the call failed; with
+// -dry-run the request is printed instead of being sent.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/badoet/go-paypal"
+)
+
+// What a command prints: the raw NVP response, plus the error when the
+// call failed
+type output struct {
+	Ack           string            `json:"ack,omitempty"`
+	CorrelationId string            `json:"correlation_id,omitempty"`
+	Values        map[string]string `json:"values,omitempty"`
+	// set-checkout only, where to send the buyer
+	CheckoutUrl string `json:"checkout_url,omitempty"`
+	Error       string `json:"error,omitempty"`
+	// -dry-run only, what would have been sent
+	Request map[string]string `json:"request,omitempty"`
+}
+
+type command struct {
+	usage string
+	run   func(client *paypal.PayPalClient, opts []paypal.CallOption, args []string) (*paypal.PayPalResponse, error)
+}
+
+var commands = map[string]command{
+	"set-checkout": {"start a checkout and print the URL to send the buyer to", setCheckout},
+	"get-details":  {"show the buyer and order of a checkout token", getDetails},
+	"do-payment":   {"complete a checkout the buyer has approved", doPayment},
+	"refund":       {"refund a transaction in full or in part", refund},
+	"search":       {"search transactions by date, email or id", search},
+	"balance":      {"show the account balance", balance},
+}
+
+var errUsage = errors.New("usage")
+
+func main() {
+	if err := run(os.Args[1:], os.Getenv, new(http.Client), os.Stdout, os.Stderr); err != nil {
+		os.Exit(1)
+	}
+}
+
+func run(args []string, getenv func(string) string, httpClient *http.Client, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("paypal", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	live := flags.Bool("live", false, "call the live PayPal API instead of the sandbox")
+	subject := flags.String("subject", "", "act on behalf of this account (third-party permissions)")
+	dryRun := flags.Bool("dry-run", false, "print the request instead of sending it")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: paypal [-live] [-subject account] [-dry-run] <command> [flags]")
+		flags.PrintDefaults()
+		fmt.Fprintln(stderr, "\ncommands:")
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(stderr, "  %-14s %s\n", name, commands[name].usage)
+		}
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errUsage
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "paypal: unknown command %q\n", flags.Arg(0))
+		flags.Usage()
+		return errUsage
+	}
+
+	client := paypal.NewClient(getenv("PAYPAL_USERNAME"), getenv("PAYPAL_PASSWORD"), getenv("PAYPAL_SIGNATURE"), !*live, httpClient)
+	client.SetDryRun(*dryRun)
+	var opts []paypal.CallOption
+	if len(*subject) != 0 {
+		opts = append(opts, paypal.WithSubject(*subject))
+	}
+
+	response, err := cmd.run(client, opts, flags.Args()[1:])
+	if err == errUsage || err == flag.ErrHelp {
+		return err
+	}
+
+	out := output{}
+	if response != nil {
+		out.Ack = response.Ack
+		out.CorrelationId = response.CorrelationId
+		out.Values = make(map[string]string, len(response.Values))
+		for key := range response.Values {
+			out.Values[key] = response.Values.Get(key)
+		}
+		if flags.Arg(0) == "set-checkout" && err == nil {
+			out.CheckoutUrl = response.CheckoutUrl()
+		}
+	}
+	if request, ok := err.(*paypal.DryRunError); ok {
+		out.Request = make(map[string]string, len(request.Values))
+		for key := range request.Values {
+			out.Request[key] = request.Values.Get(key)
+		}
+		err = nil
+	}
+	if err != nil {
+		out.Error = err.Error()
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if encodeErr := encoder.Encode(out); encodeErr != nil {
+		return encodeErr
+	}
+	return err
+}
+
+func newFlags(name string) *flag.FlagSet {
+	return flag.NewFlagSet("paypal "+name, flag.ContinueOnError)
+}
+
+// Parses args, reporting the flags named in required that were left empty
+func parseFlags(flags *flag.FlagSet, args []string, required ...string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	for _, name := range required {
+		if f := flags.Lookup(name); f != nil && (f.Value.String() == "" || f.Value.String() == "0") {
+			fmt.Fprintf(flags.Output(), "%s: -%s is required\n", flags.Name(), name)
+			flags.Usage()
+			return errUsage
+		}
+	}
+	return nil
+}
+
+func setCheckout(client *paypal.PayPalClient, opts []paypal.CallOption, args []string) (*paypal.PayPalResponse, error) {
+	flags := newFlags("set-checkout")
+	amount := flags.Float64("amount", 0, "order total")
+	currency := flags.String("currency", "USD", "currency code")
+	returnUrl := flags.String("return", "", "URL the buyer returns to after approving")
+	cancelUrl := flags.String("cancel", "", "URL the buyer returns to after cancelling")
+	description := flags.String("description", "", "order description shown to the buyer")
+	if err := parseFlags(flags, args, "amount", "return", "cancel"); err != nil {
+		return nil, err
+	}
+
+	order := paypal.PayPalOrder{
+		SubTotal:     *amount,
+		Total:        *amount,
+		CurrencyCode: *currency,
+		ReturnUrl:    *returnUrl,
+		CancelUrl:    *cancelUrl,
+		Description:  *description,
+	}
+	return client.SetExpressCheckout(order, nil, opts...)
+}
+
+func getDetails(client *paypal.PayPalClient, opts []paypal.CallOption, args []string) (*paypal.PayPalResponse, error) {
+	flags := newFlags("get-details")
+	token := flags.String("token", "", "checkout token")
+	if err := parseFlags(flags, args, "token"); err != nil {
+		return nil, err
+	}
+	return client.GetExpressCheckoutDetails(*token, opts...)
+}
+
+func doPayment(client *paypal.PayPalClient, opts []paypal.CallOption, args []string) (*paypal.PayPalResponse, error) {
+	flags := newFlags("do-payment")
+	token := flags.String("token", "", "checkout token")
+	payerId := flags.String("payer", "", "PAYERID returned with the buyer")
+	amount := flags.Float64("amount", 0, "final amount")
+	currency := flags.String("currency", "USD", "currency code")
+	action := flags.String("action", string(paypal.PAYMENT_ACTION_SALE), "Sale, Authorization or Order")
+	if err := parseFlags(flags, args, "token", "payer", "amount"); err != nil {
+		return nil, err
+	}
+	return client.DoExpressCheckoutPayment(*token, *payerId, paypal.PaymentAction(*action), *currency, *amount, opts...)
+}
+
+func refund(client *paypal.PayPalClient, opts []paypal.CallOption, args []string) (*paypal.PayPalResponse, error) {
+	flags := newFlags("refund")
+	txnId := flags.String("txn", "", "transaction id")
+	amount := flags.Float64("amount", 0, "amount for a partial refund, refunds in full when 0")
+	currency := flags.String("currency", "USD", "currency code of a partial refund")
+	note := flags.String("note", "", "note shown to the buyer")
+	if err := parseFlags(flags, args, "txn"); err != nil {
+		return nil, err
+	}
+
+	request := paypal.PayPalRefund{TransactionId: *txnId, RefundType: paypal.REFUND_TYPE_FULL, Note: *note}
+	if *amount > 0 {
+		request.RefundType = paypal.REFUND_TYPE_PARTIAL
+		request.Amount = *amount
+		request.CurrencyCode = *currency
+	}
+	return client.RefundTransaction(request, opts...)
+}
+
+func search(client *paypal.PayPalClient, opts []paypal.CallOption, args []string) (*paypal.PayPalResponse, error) {
+	flags := newFlags("search")
+	start := flags.String("start", "", "earliest date, YYYY-MM-DD or RFC 3339 (default 30 days ago)")
+	end := flags.String("end", "", "latest date, YYYY-MM-DD or RFC 3339")
+	email := flags.String("email", "", "buyer email")
+	txnId := flags.String("txn", "", "transaction id")
+	invoiceId := flags.String("invoice", "", "invoice id")
+	class := flags.String("class", "", "transaction class, e.g. Received or Refund")
+	receiptId := flags.String("receipt", "", "receipt id from the buyer's email")
+	auctionItem := flags.String("auction-item", "", "auction item number")
+	if err := parseFlags(flags, args); err != nil {
+		return nil, err
+	}
+
+	query := paypal.PayPalTransactionSearch{
+		StartDate:     time.Now().AddDate(0, 0, -30),
+		Email:         *email,
+		TransactionId: *txnId,
+		InvoiceId:     *invoiceId,
+
+		TransactionClass:  *class,
+		ReceiptId:         *receiptId,
+		AuctionItemNumber: *auctionItem,
+	}
+	var err error
+	if len(*start) != 0 {
+		if query.StartDate, err = parseDate(*start); err != nil {
+			return nil, err
+		}
+	}
+	if len(*end) != 0 {
+		if query.EndDate, err = parseDate(*end); err != nil {
+			return nil, err
+		}
+	}
+	return client.TransactionSearch(query, opts...)
+}
+
+func parseDate(value string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func balance(client *paypal.PayPalClient, opts []paypal.CallOption, args []string) (*paypal.PayPalResponse, error) {
+	flags := newFlags("balance")
+	all := flags.Bool("all", false, "show the balance of every currency held")
+	if err := parseFlags(flags, args); err != nil {
+		return nil, err
+	}
+	return client.GetBalance(*all, opts...)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type recordingTransport struct {
+	sent     url.Values
+	response string
+}
+
+func (t *recordingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, _ := ioutil.ReadAll(r.Body)
+	t.sent, _ = url.ParseQuery(string(body))
+	return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(t.response)), Header: http.Header{}}, nil
+}
+
+func runCommand(t *testing.T, transport *recordingTransport, args ...string) (output, error) {
+	var stdout bytes.Buffer
+	env := map[string]string{"PAYPAL_USERNAME": "user", "PAYPAL_PASSWORD": "pass", "PAYPAL_SIGNATURE": "sig"}
+	err := run(args, func(key string) string { return env[key] }, &http.Client{Transport: transport}, &stdout, ioutil.Discard)
+
+	var out output
+	if stdout.Len() != 0 {
+		if decodeErr := json.Unmarshal(stdout.Bytes(), &out); decodeErr != nil {
+			t.Fatalf("Output is not JSON: %s", stdout.String())
+		}
+	}
+	return out, err
+}
+
+func TestSetCheckoutCommand(t *testing.T) {
+	transport := &recordingTransport{response: "ACK=Success&TOKEN=EC-CLI&CORRELATIONID=abc"}
+	out, err := runCommand(t, transport, "set-checkout", "-amount", "12.5", "-return", "https://example.com/r", "-cancel", "https://example.com/c")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if transport.sent.Get("METHOD") != "SetExpressCheckout" || transport.sent.Get("PAYMENTREQUEST_0_AMT") != "12.50" || transport.sent.Get("USER") != "user" {
+		t.Errorf("Unexpected request: %#v", transport.sent)
+	}
+	if out.Values["TOKEN"] != "EC-CLI" || out.CorrelationId != "abc" || !strings.Contains(out.CheckoutUrl, "sandbox") {
+		t.Errorf("Unexpected output: %#v", out)
+	}
+}
+
+func TestRefundCommandFailure(t *testing.T) {
+	transport := &recordingTransport{response: "ACK=Failure&L_ERRORCODE0=10009&L_SHORTMESSAGE0=Transaction+refused"}
+	out, err := runCommand(t, transport, "-subject", "seller@example.com", "refund", "-txn", "TX1", "-amount", "5")
+	if err == nil || !strings.Contains(out.Error, "10009") {
+		t.Errorf("Expected the PayPal error in the output, got %#v", out)
+	}
+	if transport.sent.Get("REFUNDTYPE") != "Partial" || transport.sent.Get("AMT") != "5.00" || transport.sent.Get("SUBJECT") != "seller@example.com" {
+		t.Errorf("Unexpected request: %#v", transport.sent)
+	}
+}
+
+func TestDryRunCommand(t *testing.T) {
+	transport := &recordingTransport{response: "ACK=Success"}
+	out, err := runCommand(t, transport, "-live", "-dry-run", "refund", "-txn", "TX1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if transport.sent != nil {
+		t.Errorf("Expected nothing to be sent, got %#v", transport.sent)
+	}
+	if out.Request["METHOD"] != "RefundTransaction" || out.Request["TRANSACTIONID"] != "TX1" || out.Request["PWD"] != "REDACTED" {
+		t.Errorf("Unexpected output: %#v", out)
+	}
+}
+
+func TestCommandUsage(t *testing.T) {
+	transport := &recordingTransport{}
+	if _, err := runCommand(t, transport, "get-details"); err != errUsage {
+		t.Errorf("Expected a usage error without -token, got %v", err)
+	}
+	if _, err := runCommand(t, transport, "unknown"); err != errUsage {
+		t.Errorf("Expected a usage error for an unknown command, got %v", err)
+	}
+	if transport.sent != nil {
+		t.Errorf("PayPal called despite the usage errors")
+	}
+}
@@ -0,0 +1,97 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// Meant to be run with -race: many checkouts sharing one client, its
+// stores and hooks, while other goroutines derive clients from it
+func TestConcurrentCheckouts(t *testing.T) {
+	var tokens int64
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		switch values.Get("METHOD") {
+		case "SetExpressCheckout":
+			return fmt.Sprintf("ACK=Success&TOKEN=EC-%d", atomic.AddInt64(&tokens, 1))
+		case "GetExpressCheckoutDetails":
+			return "ACK=Success&TOKEN=" + values.Get("TOKEN") + "&PAYERID=BUYER&PAYMENTREQUEST_0_AMT=19.98&PAYMENTREQUEST_0_CURRENCYCODE=USD"
+		}
+		if values.Get("PAYMENTREQUEST_0_AMT") == "0.01" {
+			return "ACK=Failure&L_ERRORCODE0=10486"
+		}
+		return "ACK=Success&PAYMENTINFO_0_TRANSACTIONID=TX"
+	})})
+	metrics := paypal.NewMemoryMetrics()
+	client.SetMetrics(metrics)
+	client.SetOrderStore(paypal.NewMemoryOrderStore(0))
+	client.SetDetailsCache(paypal.NewMemoryDetailsCache(0))
+	var declined int64
+	client.OnErrorCode("10486", func(response *paypal.PayPalResponse, err *paypal.PayPalError) {
+		atomic.AddInt64(&declined, 1)
+	})
+
+	const checkouts = 50
+	var wg sync.WaitGroup
+	for i := 0; i < checkouts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			// half the checkouts run on a derived client
+			api := client
+			if i%2 == 1 {
+				api = client.With(paypal.WithDefaultSubject(fmt.Sprintf("seller-%d@example.com", i)))
+			}
+
+			order := paypal.PayPalOrder{SubTotal: 19.98, Total: 19.98, CurrencyCode: "USD"}
+			response, err := api.SetExpressCheckout(order, benchmarkGoods(1))
+			if err != nil {
+				t.Errorf("SetExpressCheckout: %v", err)
+				return
+			}
+			for reload := 0; reload < 3; reload++ {
+				if _, err := api.GetExpressCheckoutDetails(response.Token); err != nil {
+					t.Errorf("GetExpressCheckoutDetails: %v", err)
+				}
+			}
+			amount := 19.98
+			if i%10 == 0 {
+				amount = 0.01
+			}
+			api.DoExpressCheckoutSale(response.Token, "BUYER", "USD", amount)
+		}(i)
+	}
+	wg.Wait()
+
+	if declined != checkouts/10 {
+		t.Errorf("Expected %d declined payments reported to the hook, got %d", checkouts/10, declined)
+	}
+	if histogram := metrics.Latency("SetExpressCheckout", paypal.ENVIRONMENT_SANDBOX); histogram.Count != checkouts {
+		t.Errorf("Expected %d observed SetExpressCheckout calls, got %d", checkouts, histogram.Count)
+	}
+}
+
+func TestOnErrorCodeCopiesHooks(t *testing.T) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		return "ACK=Failure&L_ERRORCODE0=10486"
+	})})
+	var original, derived int
+	client.OnErrorCode("10486", func(*paypal.PayPalResponse, *paypal.PayPalError) { original++ })
+	clone := client.With()
+	clone.OnErrorCode("10486", func(*paypal.PayPalResponse, *paypal.PayPalError) { derived++ })
+
+	client.GetBalance(false)
+	if original != 1 || derived != 0 {
+		t.Errorf("A hook registered on a copy ran for the original client")
+	}
+	clone.GetBalance(false)
+	if original != 2 || derived != 1 {
+		t.Errorf("Expected the copy to run both hooks, got %d and %d", original, derived)
+	}
+}
@@ -0,0 +1,282 @@
+package paypal
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ISO 3166-1 alpha-2 codes of the countries PayPal supports. C2 is
+// PayPal's own code for China worldwide accounts.
+const (
+	COUNTRY_AD = "AD"
+	COUNTRY_AE = "AE"
+	COUNTRY_AG = "AG"
+	COUNTRY_AI = "AI"
+	COUNTRY_AL = "AL"
+	COUNTRY_AM = "AM"
+	COUNTRY_AO = "AO"
+	COUNTRY_AR = "AR"
+	COUNTRY_AT = "AT"
+	COUNTRY_AU = "AU"
+	COUNTRY_AW = "AW"
+	COUNTRY_AZ = "AZ"
+	COUNTRY_BA = "BA"
+	COUNTRY_BB = "BB"
+	COUNTRY_BE = "BE"
+	COUNTRY_BF = "BF"
+	COUNTRY_BG = "BG"
+	COUNTRY_BH = "BH"
+	COUNTRY_BI = "BI"
+	COUNTRY_BJ = "BJ"
+	COUNTRY_BM = "BM"
+	COUNTRY_BN = "BN"
+	COUNTRY_BO = "BO"
+	COUNTRY_BR = "BR"
+	COUNTRY_BS = "BS"
+	COUNTRY_BT = "BT"
+	COUNTRY_BW = "BW"
+	COUNTRY_BY = "BY"
+	COUNTRY_BZ = "BZ"
+	COUNTRY_C2 = "C2"
+	COUNTRY_CA = "CA"
+	COUNTRY_CD = "CD"
+	COUNTRY_CG = "CG"
+	COUNTRY_CH = "CH"
+	COUNTRY_CI = "CI"
+	COUNTRY_CK = "CK"
+	COUNTRY_CL = "CL"
+	COUNTRY_CM = "CM"
+	COUNTRY_CN = "CN"
+	COUNTRY_CO = "CO"
+	COUNTRY_CR = "CR"
+	COUNTRY_CV = "CV"
+	COUNTRY_CY = "CY"
+	COUNTRY_CZ = "CZ"
+	COUNTRY_DE = "DE"
+	COUNTRY_DJ = "DJ"
+	COUNTRY_DK = "DK"
+	COUNTRY_DM = "DM"
+	COUNTRY_DO = "DO"
+	COUNTRY_DZ = "DZ"
+	COUNTRY_EC = "EC"
+	COUNTRY_EE = "EE"
+	COUNTRY_EG = "EG"
+	COUNTRY_ER = "ER"
+	COUNTRY_ES = "ES"
+	COUNTRY_ET = "ET"
+	COUNTRY_FI = "FI"
+	COUNTRY_FJ = "FJ"
+	COUNTRY_FK = "FK"
+	COUNTRY_FM = "FM"
+	COUNTRY_FO = "FO"
+	COUNTRY_FR = "FR"
+	COUNTRY_GA = "GA"
+	COUNTRY_GB = "GB"
+	COUNTRY_GD = "GD"
+	COUNTRY_GE = "GE"
+	COUNTRY_GF = "GF"
+	COUNTRY_GI = "GI"
+	COUNTRY_GL = "GL"
+	COUNTRY_GM = "GM"
+	COUNTRY_GN = "GN"
+	COUNTRY_GP = "GP"
+	COUNTRY_GR = "GR"
+	COUNTRY_GT = "GT"
+	COUNTRY_GW = "GW"
+	COUNTRY_GY = "GY"
+	COUNTRY_HK = "HK"
+	COUNTRY_HN = "HN"
+	COUNTRY_HR = "HR"
+	COUNTRY_HU = "HU"
+	COUNTRY_ID = "ID"
+	COUNTRY_IE = "IE"
+	COUNTRY_IL = "IL"
+	COUNTRY_IN = "IN"
+	COUNTRY_IS = "IS"
+	COUNTRY_IT = "IT"
+	COUNTRY_JM = "JM"
+	COUNTRY_JO = "JO"
+	COUNTRY_JP = "JP"
+	COUNTRY_KE = "KE"
+	COUNTRY_KG = "KG"
+	COUNTRY_KH = "KH"
+	COUNTRY_KI = "KI"
+	COUNTRY_KM = "KM"
+	COUNTRY_KN = "KN"
+	COUNTRY_KR = "KR"
+	COUNTRY_KW = "KW"
+	COUNTRY_KY = "KY"
+	COUNTRY_KZ = "KZ"
+	COUNTRY_LA = "LA"
+	COUNTRY_LC = "LC"
+	COUNTRY_LI = "LI"
+	COUNTRY_LK = "LK"
+	COUNTRY_LS = "LS"
+	COUNTRY_LT = "LT"
+	COUNTRY_LU = "LU"
+	COUNTRY_LV = "LV"
+	COUNTRY_MA = "MA"
+	COUNTRY_MC = "MC"
+	COUNTRY_MD = "MD"
+	COUNTRY_ME = "ME"
+	COUNTRY_MG = "MG"
+	COUNTRY_MH = "MH"
+	COUNTRY_MK = "MK"
+	COUNTRY_ML = "ML"
+	COUNTRY_MN = "MN"
+	COUNTRY_MQ = "MQ"
+	COUNTRY_MR = "MR"
+	COUNTRY_MS = "MS"
+	COUNTRY_MT = "MT"
+	COUNTRY_MU = "MU"
+	COUNTRY_MV = "MV"
+	COUNTRY_MW = "MW"
+	COUNTRY_MX = "MX"
+	COUNTRY_MY = "MY"
+	COUNTRY_MZ = "MZ"
+	COUNTRY_NA = "NA"
+	COUNTRY_NC = "NC"
+	COUNTRY_NE = "NE"
+	COUNTRY_NF = "NF"
+	COUNTRY_NG = "NG"
+	COUNTRY_NI = "NI"
+	COUNTRY_NL = "NL"
+	COUNTRY_NO = "NO"
+	COUNTRY_NP = "NP"
+	COUNTRY_NR = "NR"
+	COUNTRY_NU = "NU"
+	COUNTRY_NZ = "NZ"
+	COUNTRY_OM = "OM"
+	COUNTRY_PA = "PA"
+	COUNTRY_PE = "PE"
+	COUNTRY_PF = "PF"
+	COUNTRY_PG = "PG"
+	COUNTRY_PH = "PH"
+	COUNTRY_PL = "PL"
+	COUNTRY_PM = "PM"
+	COUNTRY_PN = "PN"
+	COUNTRY_PR = "PR"
+	COUNTRY_PT = "PT"
+	COUNTRY_PW = "PW"
+	COUNTRY_PY = "PY"
+	COUNTRY_QA = "QA"
+	COUNTRY_RE = "RE"
+	COUNTRY_RO = "RO"
+	COUNTRY_RS = "RS"
+	COUNTRY_RU = "RU"
+	COUNTRY_RW = "RW"
+	COUNTRY_SA = "SA"
+	COUNTRY_SB = "SB"
+	COUNTRY_SC = "SC"
+	COUNTRY_SE = "SE"
+	COUNTRY_SG = "SG"
+	COUNTRY_SH = "SH"
+	COUNTRY_SI = "SI"
+	COUNTRY_SJ = "SJ"
+	COUNTRY_SK = "SK"
+	COUNTRY_SL = "SL"
+	COUNTRY_SM = "SM"
+	COUNTRY_SN = "SN"
+	COUNTRY_SO = "SO"
+	COUNTRY_SR = "SR"
+	COUNTRY_ST = "ST"
+	COUNTRY_SV = "SV"
+	COUNTRY_SZ = "SZ"
+	COUNTRY_TC = "TC"
+	COUNTRY_TD = "TD"
+	COUNTRY_TG = "TG"
+	COUNTRY_TH = "TH"
+	COUNTRY_TJ = "TJ"
+	COUNTRY_TM = "TM"
+	COUNTRY_TN = "TN"
+	COUNTRY_TO = "TO"
+	COUNTRY_TT = "TT"
+	COUNTRY_TV = "TV"
+	COUNTRY_TW = "TW"
+	COUNTRY_TZ = "TZ"
+	COUNTRY_UA = "UA"
+	COUNTRY_UG = "UG"
+	COUNTRY_US = "US"
+	COUNTRY_UY = "UY"
+	COUNTRY_VA = "VA"
+	COUNTRY_VC = "VC"
+	COUNTRY_VE = "VE"
+	COUNTRY_VG = "VG"
+	COUNTRY_VN = "VN"
+	COUNTRY_VU = "VU"
+	COUNTRY_WF = "WF"
+	COUNTRY_WS = "WS"
+	COUNTRY_YE = "YE"
+	COUNTRY_YT = "YT"
+	COUNTRY_ZA = "ZA"
+	COUNTRY_ZM = "ZM"
+	COUNTRY_ZW = "ZW"
+)
+
+var supportedCountries = map[string]bool{
+	COUNTRY_AD: true, COUNTRY_AE: true, COUNTRY_AG: true, COUNTRY_AI: true, COUNTRY_AL: true, COUNTRY_AM: true,
+	COUNTRY_AO: true, COUNTRY_AR: true, COUNTRY_AT: true, COUNTRY_AU: true, COUNTRY_AW: true, COUNTRY_AZ: true,
+	COUNTRY_BA: true, COUNTRY_BB: true, COUNTRY_BE: true, COUNTRY_BF: true, COUNTRY_BG: true, COUNTRY_BH: true,
+	COUNTRY_BI: true, COUNTRY_BJ: true, COUNTRY_BM: true, COUNTRY_BN: true, COUNTRY_BO: true, COUNTRY_BR: true,
+	COUNTRY_BS: true, COUNTRY_BT: true, COUNTRY_BW: true, COUNTRY_BY: true, COUNTRY_BZ: true, COUNTRY_C2: true,
+	COUNTRY_CA: true, COUNTRY_CD: true, COUNTRY_CG: true, COUNTRY_CH: true, COUNTRY_CI: true, COUNTRY_CK: true,
+	COUNTRY_CL: true, COUNTRY_CM: true, COUNTRY_CN: true, COUNTRY_CO: true, COUNTRY_CR: true, COUNTRY_CV: true,
+	COUNTRY_CY: true, COUNTRY_CZ: true, COUNTRY_DE: true, COUNTRY_DJ: true, COUNTRY_DK: true, COUNTRY_DM: true,
+	COUNTRY_DO: true, COUNTRY_DZ: true, COUNTRY_EC: true, COUNTRY_EE: true, COUNTRY_EG: true, COUNTRY_ER: true,
+	COUNTRY_ES: true, COUNTRY_ET: true, COUNTRY_FI: true, COUNTRY_FJ: true, COUNTRY_FK: true, COUNTRY_FM: true,
+	COUNTRY_FO: true, COUNTRY_FR: true, COUNTRY_GA: true, COUNTRY_GB: true, COUNTRY_GD: true, COUNTRY_GE: true,
+	COUNTRY_GF: true, COUNTRY_GI: true, COUNTRY_GL: true, COUNTRY_GM: true, COUNTRY_GN: true, COUNTRY_GP: true,
+	COUNTRY_GR: true, COUNTRY_GT: true, COUNTRY_GW: true, COUNTRY_GY: true, COUNTRY_HK: true, COUNTRY_HN: true,
+	COUNTRY_HR: true, COUNTRY_HU: true, COUNTRY_ID: true, COUNTRY_IE: true, COUNTRY_IL: true, COUNTRY_IN: true,
+	COUNTRY_IS: true, COUNTRY_IT: true, COUNTRY_JM: true, COUNTRY_JO: true, COUNTRY_JP: true, COUNTRY_KE: true,
+	COUNTRY_KG: true, COUNTRY_KH: true, COUNTRY_KI: true, COUNTRY_KM: true, COUNTRY_KN: true, COUNTRY_KR: true,
+	COUNTRY_KW: true, COUNTRY_KY: true, COUNTRY_KZ: true, COUNTRY_LA: true, COUNTRY_LC: true, COUNTRY_LI: true,
+	COUNTRY_LK: true, COUNTRY_LS: true, COUNTRY_LT: true, COUNTRY_LU: true, COUNTRY_LV: true, COUNTRY_MA: true,
+	COUNTRY_MC: true, COUNTRY_MD: true, COUNTRY_ME: true, COUNTRY_MG: true, COUNTRY_MH: true, COUNTRY_MK: true,
+	COUNTRY_ML: true, COUNTRY_MN: true, COUNTRY_MQ: true, COUNTRY_MR: true, COUNTRY_MS: true, COUNTRY_MT: true,
+	COUNTRY_MU: true, COUNTRY_MV: true, COUNTRY_MW: true, COUNTRY_MX: true, COUNTRY_MY: true, COUNTRY_MZ: true,
+	COUNTRY_NA: true, COUNTRY_NC: true, COUNTRY_NE: true, COUNTRY_NF: true, COUNTRY_NG: true, COUNTRY_NI: true,
+	COUNTRY_NL: true, COUNTRY_NO: true, COUNTRY_NP: true, COUNTRY_NR: true, COUNTRY_NU: true, COUNTRY_NZ: true,
+	COUNTRY_OM: true, COUNTRY_PA: true, COUNTRY_PE: true, COUNTRY_PF: true, COUNTRY_PG: true, COUNTRY_PH: true,
+	COUNTRY_PL: true, COUNTRY_PM: true, COUNTRY_PN: true, COUNTRY_PR: true, COUNTRY_PT: true, COUNTRY_PW: true,
+	COUNTRY_PY: true, COUNTRY_QA: true, COUNTRY_RE: true, COUNTRY_RO: true, COUNTRY_RS: true, COUNTRY_RU: true,
+	COUNTRY_RW: true, COUNTRY_SA: true, COUNTRY_SB: true, COUNTRY_SC: true, COUNTRY_SE: true, COUNTRY_SG: true,
+	COUNTRY_SH: true, COUNTRY_SI: true, COUNTRY_SJ: true, COUNTRY_SK: true, COUNTRY_SL: true, COUNTRY_SM: true,
+	COUNTRY_SN: true, COUNTRY_SO: true, COUNTRY_SR: true, COUNTRY_ST: true, COUNTRY_SV: true, COUNTRY_SZ: true,
+	COUNTRY_TC: true, COUNTRY_TD: true, COUNTRY_TG: true, COUNTRY_TH: true, COUNTRY_TJ: true, COUNTRY_TM: true,
+	COUNTRY_TN: true, COUNTRY_TO: true, COUNTRY_TT: true, COUNTRY_TV: true, COUNTRY_TW: true, COUNTRY_TZ: true,
+	COUNTRY_UA: true, COUNTRY_UG: true, COUNTRY_US: true, COUNTRY_UY: true, COUNTRY_VA: true, COUNTRY_VC: true,
+	COUNTRY_VE: true, COUNTRY_VG: true, COUNTRY_VN: true, COUNTRY_VU: true, COUNTRY_WF: true, COUNTRY_WS: true,
+	COUNTRY_YE: true, COUNTRY_YT: true, COUNTRY_ZA: true, COUNTRY_ZM: true, COUNTRY_ZW: true,
+}
+
+// Returned before calling PayPal when a COUNTRYCODE field holds a code
+// PayPal doesn't support, which it would answer with a 10736 error
+type CountryCodeError struct {
+	Field string
+	Code  string
+}
+
+func (e *CountryCodeError) Error() string {
+	return "PayPal does not support country code " + strconv.Quote(e.Code) + " in " + e.Field
+}
+
+// Whether code is one of the COUNTRY_* constants
+func IsSupportedCountry(code string) bool {
+	return supportedCountries[code]
+}
+
+func checkCountryCodes(values url.Values) error {
+	for key, fieldValues := range values {
+		if !strings.HasSuffix(key, "COUNTRYCODE") {
+			continue
+		}
+		for _, code := range fieldValues {
+			if !supportedCountries[code] {
+				return &CountryCodeError{key, code}
+			}
+		}
+	}
+	return nil
+}
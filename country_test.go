@@ -0,0 +1,37 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestCountryCodes(t *testing.T) {
+	if !paypal.IsSupportedCountry(paypal.COUNTRY_DE) || !paypal.IsSupportedCountry("C2") || paypal.IsSupportedCountry("XX") || paypal.IsSupportedCountry("de") {
+		t.Errorf("Unexpected supported countries")
+	}
+
+	calls := 0
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		calls++
+		return "ACK=Success"
+	})})
+
+	values := url.Values{}
+	values.Set("METHOD", "SetExpressCheckout")
+	values.Set("PAYMENTREQUEST_0_SHIPTOCOUNTRYCODE", "UK")
+	_, err := client.PerformRequest(values)
+	if countryErr, ok := err.(*paypal.CountryCodeError); !ok || countryErr.Code != "UK" || countryErr.Field != "PAYMENTREQUEST_0_SHIPTOCOUNTRYCODE" {
+		t.Errorf("Expected a *CountryCodeError for UK, got %#v", err)
+	}
+
+	values.Set("PAYMENTREQUEST_0_SHIPTOCOUNTRYCODE", paypal.COUNTRY_GB)
+	if _, err := client.PerformRequest(values); err != nil {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected only the valid request to reach PayPal, got %d calls", calls)
+	}
+}
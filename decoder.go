@@ -0,0 +1,155 @@
+package paypal
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/url"
+	"sync"
+)
+
+// Bytes of the body kept on a TransportError
+const TRANSPORT_ERROR_SNIPPET_LENGTH = 256
+
+var errNotNVP = errors.New("response is not in NVP format")
+
+var readerPool = sync.Pool{New: func() interface{} { return bufio.NewReaderSize(nil, 4096) }}
+
+// Decodes an NVP body as it is read, without first loading it into one
+// string. The result and error semantics match url.ParseQuery: malformed
+// pairs are skipped and the first error is returned once r is exhausted.
+func DecodeNVP(r io.Reader) (url.Values, error) {
+	reader := readerPool.Get().(*bufio.Reader)
+	reader.Reset(r)
+	defer func() {
+		reader.Reset(nil)
+		readerPool.Put(reader)
+	}()
+
+	values := make(url.Values)
+	var firstErr error
+	var pair []byte
+	// one-element slices for the values are cut from a shared slab
+	var slab []string
+
+	for {
+		chunk, err := reader.ReadSlice('&')
+		if err == bufio.ErrBufferFull {
+			pair = append(pair, chunk...)
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return values, err
+		}
+
+		pair = append(pair, chunk...)
+		if err == nil {
+			// drop the '&'
+			pair = pair[:len(pair)-1]
+		}
+		if len(pair) != 0 {
+			var pairErr error
+			if slab, pairErr = addPair(values, pair, slab); pairErr != nil && firstErr == nil {
+				firstErr = pairErr
+			}
+			pair = pair[:0]
+		}
+		if err == io.EOF {
+			return values, firstErr
+		}
+	}
+}
+
+func addPair(values url.Values, pair []byte, slab []string) ([]string, error) {
+	keyEnd := len(pair)
+	for i, c := range pair {
+		if c == '=' {
+			keyEnd = i
+			break
+		}
+	}
+
+	keyLen, err := unescapeNVP(pair[:keyEnd])
+	if err != nil {
+		return slab, err
+	}
+	valueLen := 0
+	if keyEnd < len(pair) {
+		if valueLen, err = unescapeNVP(pair[keyEnd+1:]); err != nil {
+			return slab, err
+		}
+		// key and value share a single string allocation
+		copy(pair[keyLen:], pair[keyEnd+1:keyEnd+1+valueLen])
+	}
+	decoded := string(pair[:keyLen+valueLen])
+	key, value := decoded[:keyLen], decoded[keyLen:]
+
+	if existing, ok := values[key]; ok {
+		values[key] = append(existing, value)
+		return slab, nil
+	}
+	if len(slab) == cap(slab) {
+		slab = make([]string, 0, 64)
+	}
+	slab = append(slab, value)
+	values[key] = slab[len(slab)-1 : len(slab) : len(slab)]
+	return slab, nil
+}
+
+var errInvalidEscape = errors.New("paypal: invalid URL escape in NVP response")
+
+// Decodes '+' and %XX in place, returning the decoded length
+func unescapeNVP(s []byte) (int, error) {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '+':
+			s[n] = ' '
+		case '%':
+			if i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+				return 0, errInvalidEscape
+			}
+			s[n] = unhex(s[i+1])<<4 | unhex(s[i+2])
+			i += 2
+		default:
+			s[n] = s[i]
+		}
+		n++
+	}
+	return n, nil
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+// Keeps the first limit bytes written to it
+type snippetWriter struct {
+	limit int
+	buf   []byte
+}
+
+func (w *snippetWriter) Write(p []byte) (int, error) {
+	if room := w.limit - len(w.buf); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		w.buf = append(w.buf, p[:room]...)
+	}
+	return len(p), nil
+}
+
+func (w *snippetWriter) String() string {
+	return string(w.buf)
+}
@@ -0,0 +1,215 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Body shaped like a TransactionSearch response with rows result rows
+func searchResponseBody(rows int) string {
+	values := url.Values{
+		"ACK":           {"Success"},
+		"CORRELATIONID": {"6b2c7a7e5d3f1"},
+		"TIMESTAMP":     {"2014-02-18T09:13:45Z"},
+		"VERSION":       {paypal.NVP_VERSION},
+		"BUILD":         {"9720069"},
+	}
+	for i := 0; i < rows; i++ {
+		values.Set(fmt.Sprintf("L_TIMESTAMP%d", i), "2014-02-17T22:41:07Z")
+		values.Set(fmt.Sprintf("L_TIMEZONE%d", i), "GMT")
+		values.Set(fmt.Sprintf("L_TYPE%d", i), "Payment")
+		values.Set(fmt.Sprintf("L_EMAIL%d", i), "buyer+test@example.com")
+		values.Set(fmt.Sprintf("L_NAME%d", i), "Jöhn Dœ")
+		values.Set(fmt.Sprintf("L_TRANSACTIONID%d", i), fmt.Sprintf("4HD5%012d", i))
+		values.Set(fmt.Sprintf("L_STATUS%d", i), "Completed")
+		values.Set(fmt.Sprintf("L_AMT%d", i), "21.32")
+		values.Set(fmt.Sprintf("L_CURRENCYCODE%d", i), "USD")
+		values.Set(fmt.Sprintf("L_FEEAMT%d", i), "-0.92")
+		values.Set(fmt.Sprintf("L_NETAMT%d", i), "20.40")
+	}
+	return values.Encode()
+}
+
+func TestDecodeNVPMatchesParseQuery(t *testing.T) {
+	bodies := []string{
+		searchResponseBody(3),
+		"ACK=Failure&L_LONGMESSAGE0=Token+value+is+invalid.%20Retry&L_ERRORCODE0=10410",
+		"A=1&&B=&C&A=2",
+		"LONG=" + strings.Repeat("x%20y", 5000) + "&ACK=Success",
+		"",
+	}
+
+	for _, body := range bodies {
+		expected, expectedErr := url.ParseQuery(body)
+		decoded, err := paypal.DecodeNVP(strings.NewReader(body))
+		if err != nil || expectedErr != nil {
+			t.Fatalf("Unexpected errors decoding %q: %v / %v", body, err, expectedErr)
+		}
+		if !reflect.DeepEqual(decoded, expected) {
+			t.Errorf("Decoded %q as %#v, expected %#v", body, decoded, expected)
+		}
+	}
+}
+
+func TestDecodeNVPInvalidEscape(t *testing.T) {
+	decoded, err := paypal.DecodeNVP(strings.NewReader("ACK=Success&BAD=%zz&TOKEN=EC-1"))
+	if err == nil {
+		t.Errorf("Expected an error for an invalid escape")
+	}
+	if decoded.Get("TOKEN") != "EC-1" || decoded.Get("ACK") != "Success" {
+		t.Errorf("Valid pairs around the invalid escape were lost: %#v", decoded)
+	}
+}
+
+func BenchmarkDecodeNVP(b *testing.B) {
+	body := []byte(searchResponseBody(100))
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for i := 0; i < b.N; i++ {
+		paypal.DecodeNVP(bytes.NewReader(body))
+	}
+}
+
+func BenchmarkParseQuery(b *testing.B) {
+	body := []byte(searchResponseBody(100))
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for i := 0; i < b.N; i++ {
+		url.ParseQuery(string(body))
+	}
+}
+
+func BenchmarkPerformRequestLargeResponse(b *testing.B) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: staticTransport(searchResponseBody(100))})
+	values := url.Values{"METHOD": {"TransactionSearch"}, "STARTDATE": {"2014-02-01T00:00:00Z"}}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		client.PerformRequest(values)
+	}
+}
+
+type rawTransport struct {
+	status int
+	body   io.Reader
+}
+
+func (raw rawTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: raw.status,
+		Body:       ioutil.NopCloser(raw.body),
+		Header:     make(http.Header),
+		Request:    r,
+	}, nil
+}
+
+// Body cut off by the connection dropping
+type truncatedReader struct {
+	data string
+}
+
+func (t *truncatedReader) Read(p []byte) (int, error) {
+	if len(t.data) == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(p, t.data)
+	t.data = t.data[n:]
+	return n, nil
+}
+
+func TestTransportErrorForHTMLPage(t *testing.T) {
+	page := "<html><head><title>Service Unavailable</title></head><body>PayPal is down for maintenance</body></html>"
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: rawTransport{503, strings.NewReader(page)}})
+
+	response, err := client.GetExpressCheckoutDetails("EC-TOKEN")
+
+	transportErr, ok := err.(*paypal.TransportError)
+	if !ok {
+		t.Fatalf("Expected a *TransportError, got %#v", err)
+	}
+	if response != nil {
+		t.Errorf("Expected no response alongside a transport error, got %#v", response)
+	}
+	if transportErr.StatusCode != 503 || !strings.HasPrefix(transportErr.Snippet, "<html><head><title>Service Unavailable") {
+		t.Errorf("Unexpected transport error details: %#v", transportErr)
+	}
+}
+
+func TestTransportErrorForTruncatedBody(t *testing.T) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: rawTransport{200, &truncatedReader{"TIMESTAMP=2014%2D02%2D18&CORREL"}}})
+
+	_, err := client.GetExpressCheckoutDetails("EC-TOKEN")
+
+	transportErr, ok := err.(*paypal.TransportError)
+	if !ok {
+		t.Fatalf("Expected a *TransportError, got %#v", err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) || transportErr.Snippet != "TIMESTAMP=2014%2D02%2D18&CORREL" {
+		t.Errorf("Unexpected transport error details: %#v", transportErr)
+	}
+}
+
+func TestInvalidEscapeDoesNotHideAck(t *testing.T) {
+	body := "ACK=Success&PAYMENTINFO_0_TRANSACTIONID=8AB&SHIPTONAME=100%+Cotton"
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: rawTransport{200, strings.NewReader(body)}})
+
+	response, err := client.DoExpressCheckoutSale("EC-TOKEN", "PAYERID", "USD", 10)
+	if err != nil {
+		t.Fatalf("Completed payment reported as failed: %#v", err)
+	}
+	if response.Values.Get("PAYMENTINFO_0_TRANSACTIONID") != "8AB" {
+		t.Errorf("Transaction id lost: %#v", response.Values)
+	}
+}
+
+func FuzzDecodeNVP(f *testing.F) {
+	f.Add("ACK=Success&TOKEN=EC-1")
+	f.Add("A=%zz&B=%4&C=%41")
+	f.Add("=&&=x&k")
+	f.Add("<html>\n<body>500</body></html>")
+	f.Add(searchResponseBody(2))
+
+	f.Fuzz(func(t *testing.T, body string) {
+		decoded, err := paypal.DecodeNVP(strings.NewReader(body))
+		if strings.Contains(body, ";") {
+			return
+		}
+		expected, expectedErr := url.ParseQuery(body)
+		if (err == nil) != (expectedErr == nil) {
+			t.Fatalf("Error mismatch for %q: %v vs %v", body, err, expectedErr)
+		}
+		if !reflect.DeepEqual(decoded, expected) {
+			t.Fatalf("Decoded %q as %#v, ParseQuery gave %#v", body, decoded, expected)
+		}
+	})
+}
+
+func FuzzPerformRequestResponse(f *testing.F) {
+	f.Add(200, "ACK=Success&TOKEN=EC-1")
+	f.Add(200, "ACK=Failure&L_ERRORCODE0=10410")
+	f.Add(503, "<html>maintenance</html>")
+	f.Add(200, "ACK=%")
+
+	f.Fuzz(func(t *testing.T, status int, body string) {
+		client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: rawTransport{status, strings.NewReader(body)}})
+		response, err := client.GetExpressCheckoutDetails("EC-TOKEN")
+		if response == nil && err == nil {
+			t.Fatalf("Neither a response nor an error for %d %q", status, body)
+		}
+		if err != nil && len(err.Error()) == 0 {
+			t.Fatalf("Empty error message for %d %q", status, body)
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package paypal
+
+import (
+	"sync"
+	"time"
+)
+
+// PayPal retries IPN deliveries until it gets a 200 back, so the same
+// message can arrive more than once. A Deduplicator remembers which ids
+// have been processed.
+type Deduplicator interface {
+	// Seen records eventId and reports whether it had been recorded before
+	Seen(eventId string) bool
+}
+
+// Adapter allowing an ordinary function to be used as a Deduplicator,
+// e.g. one backed by a shared database or cache
+type DeduplicatorFunc func(eventId string) bool
+
+func (f DeduplicatorFunc) Seen(eventId string) bool {
+	return f(eventId)
+}
+
+type MemoryDeduplicator struct {
+	ttl  time.Duration
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// ttl controls how long an id is remembered; 0 remembers ids forever
+func NewMemoryDeduplicator(ttl time.Duration) *MemoryDeduplicator {
+	return &MemoryDeduplicator{ttl: ttl, seen: make(map[string]time.Time)}
+}
+
+func (d *MemoryDeduplicator) Seen(eventId string) bool {
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.ttl > 0 {
+		for id, at := range d.seen {
+			if now.Sub(at) > d.ttl {
+				delete(d.seen, id)
+			}
+		}
+	}
+
+	if _, ok := d.seen[eventId]; ok {
+		return true
+	}
+	d.seen[eventId] = now
+	return false
+}
@@ -0,0 +1,32 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"testing"
+	"time"
+)
+
+func TestMemoryDeduplicator(t *testing.T) {
+	dedupe := paypal.NewMemoryDeduplicator(0)
+
+	if dedupe.Seen("txn-1") {
+		t.Errorf("First delivery of txn-1 reported as already seen")
+	}
+	if !dedupe.Seen("txn-1") {
+		t.Errorf("Second delivery of txn-1 was not reported as seen")
+	}
+	if dedupe.Seen("txn-2") {
+		t.Errorf("First delivery of txn-2 reported as already seen")
+	}
+}
+
+func TestMemoryDeduplicatorExpiry(t *testing.T) {
+	dedupe := paypal.NewMemoryDeduplicator(time.Millisecond)
+
+	dedupe.Seen("txn-1")
+	time.Sleep(5 * time.Millisecond)
+	if dedupe.Seen("txn-1") {
+		t.Errorf("txn-1 should have been forgotten after the ttl elapsed")
+	}
+}
@@ -0,0 +1,101 @@
+package paypal
+
+import (
+	"net/url"
+	"strings"
+)
+
+// The buyer as checkout and transaction details describe them
+type PayPalPayer struct {
+	PayerId     string
+	PayerStatus PayerStatus
+	Email       string
+	FirstName   string
+	LastName    string
+	// PHONENUM, only returned when the account's website preferences ask
+	// buyers for a contact telephone number
+	Phone       string
+	CountryCode string
+	// Set when the buyer pays with a business account, for invoicing the
+	// company rather than the person
+	BusinessName string
+}
+
+func (payer *PayPalPayer) populate(values url.Values, businessKey string) {
+	payer.PayerId = values.Get("PAYERID")
+	payer.PayerStatus = parsePayerStatus(values.Get("PAYERSTATUS"))
+	payer.Email = values.Get("EMAIL")
+	payer.FirstName = values.Get("FIRSTNAME")
+	payer.LastName = values.Get("LASTNAME")
+	payer.Phone = values.Get("PHONENUM")
+	payer.CountryCode = values.Get("COUNTRYCODE")
+	payer.BusinessName = values.Get(businessKey)
+}
+
+// Whether the buyer paid from a business account
+func (payer *PayPalPayer) IsBusiness() bool {
+	return len(payer.BusinessName) != 0
+}
+
+// Typed view of a GetExpressCheckoutDetails response
+type PayPalCheckoutDetails struct {
+	Token          string
+	CheckoutStatus string
+	PayPalPayer
+	Amount   float64
+	Currency string
+	// Left by the buyer when the checkout was set up with AllowNote
+	Note string
+	// Where the buyer asked for the order to be shipped
+	ShippingAddress Address
+
+	InsuranceOptionSelected bool
+}
+
+// NVP booleans come as true/false or Yes/No depending on the field
+func parseNVPBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "true", "yes", "1":
+		return true
+	}
+	return false
+}
+
+// The buyer's contact number, or the shipping address phone number when
+// PayPal was not asked for one
+func (details *PayPalCheckoutDetails) ContactPhone() string {
+	if len(details.Phone) != 0 {
+		return details.Phone
+	}
+	return details.ShippingAddress.Phone
+}
+
+func (details *PayPalCheckoutDetails) Populate(values url.Values) {
+	details.decode(&fieldParser{values: values})
+}
+
+func (details *PayPalCheckoutDetails) decode(p *fieldParser) {
+	values := p.values
+	details.Token = values.Get("TOKEN")
+	details.CheckoutStatus = values.Get("CHECKOUTSTATUS")
+	details.PayPalPayer.populate(values, "BUSINESS")
+	details.Amount = p.float("PAYMENTREQUEST_0_AMT")
+	details.Currency = values.Get("PAYMENTREQUEST_0_CURRENCYCODE")
+	details.Note = values.Get("PAYMENTREQUEST_0_NOTETEXT")
+	if len(details.Note) == 0 {
+		details.Note = values.Get("NOTE")
+	}
+
+	details.ShippingAddress = AddressFromNVP(values, "PAYMENTREQUEST_0_SHIPTO")
+	details.ShippingAddress.Status = parseAddressStatus(values.Get("PAYMENTREQUEST_0_ADDRESSSTATUS"))
+	if len(details.ShippingAddress.Street) == 0 {
+		details.ShippingAddress = AddressFromNVP(values, "SHIPTO")
+		details.ShippingAddress.Status = parseAddressStatus(values.Get("ADDRESSSTATUS"))
+	}
+
+	insurance := values.Get("PAYMENTREQUEST_0_INSURANCEOPTIONSELECTED")
+	if len(insurance) == 0 {
+		insurance = values.Get("INSURANCEOPTIONSELECTED")
+	}
+	details.InsuranceOptionSelected = parseNVPBool(insurance)
+}
@@ -0,0 +1,94 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/url"
+	"testing"
+)
+
+func TestCheckoutDetailsPopulate(t *testing.T) {
+	values, _ := url.ParseQuery("TOKEN=EC-1&CHECKOUTSTATUS=PaymentActionNotInitiated&PAYERID=QWERTY&EMAIL=buyer%40example.com" +
+		"&FIRSTNAME=Jane&LASTNAME=Doe&PAYMENTREQUEST_0_AMT=27.50&PAYMENTREQUEST_0_CURRENCYCODE=EUR" +
+		"&PAYMENTREQUEST_0_INSURANCEOPTIONSELECTED=true&PAYMENTREQUEST_0_NOTETEXT=Leave+at+the+door")
+
+	details := new(paypal.PayPalCheckoutDetails)
+	details.Populate(values)
+
+	if details.PayerId != "QWERTY" || details.Email != "buyer@example.com" || details.Amount != 27.5 || details.Currency != "EUR" {
+		t.Errorf("Details not decoded: %#v", details)
+	}
+	if details.Note != "Leave at the door" {
+		t.Errorf("Buyer note not decoded: %q", details.Note)
+	}
+	if !details.InsuranceOptionSelected {
+		t.Errorf("Insurance choice not decoded")
+	}
+}
+
+func TestPaymentResponseInsurance(t *testing.T) {
+	values, _ := url.ParseQuery("PAYMENTINFO_0_TRANSACTIONID=8AB&INSURANCEOPTIONSELECTED=No")
+
+	payment := new(paypal.PayPalPaymentResponse)
+	payment.Populate(values)
+
+	if payment.InsuranceOptionSelected {
+		t.Errorf("INSURANCEOPTIONSELECTED=No decoded as selected")
+	}
+}
+
+func TestExchangeRateFields(t *testing.T) {
+	values, _ := url.ParseQuery("PAYMENTINFO_0_TRANSACTIONID=8AB&PAYMENTINFO_0_AMT=100.00&PAYMENTINFO_0_CURRENCYCODE=EUR&PAYMENTINFO_0_EXCHANGERATE=1.0872&PAYMENTINFO_0_SETTLEAMT=105.12")
+
+	payment := new(paypal.PayPalPaymentResponse)
+	payment.Populate(values)
+	if payment.ExchangeRate != 1.0872 || payment.SettleAmount != 105.12 {
+		t.Errorf("FX fields not decoded from the payment: %#v", payment)
+	}
+
+	values, _ = url.ParseQuery("TRANSACTIONID=8AB&TRANSACTIONTYPE=expresscheckout&ORDERTIME=2014-02-10T12%3A00%3A00Z&AMT=100.00&CURRENCYCODE=EUR&EXCHANGERATE=1.0872&SETTLEAMT=105.12")
+
+	details := new(paypal.PayPalTransactionDetails)
+	details.Populate(values)
+	if details.ExchangeRate != 1.0872 || details.SettleAmount != 105.12 || details.OrderTime.Day() != 10 {
+		t.Errorf("FX fields not decoded from the transaction details: %#v", details)
+	}
+}
+
+func TestCheckoutDetailsPhone(t *testing.T) {
+	values, _ := url.ParseQuery("TOKEN=EC-1&PHONENUM=408-555-0100&PAYMENTREQUEST_0_SHIPTOSTREET=1+Main+St&PAYMENTREQUEST_0_SHIPTOPHONENUM=408-555-0199")
+
+	details := new(paypal.PayPalCheckoutDetails)
+	details.Populate(values)
+	if details.Phone != "408-555-0100" || details.ContactPhone() != "408-555-0100" {
+		t.Errorf("Buyer phone not decoded: %#v", details)
+	}
+
+	details.Phone = ""
+	if details.ContactPhone() != "408-555-0199" {
+		t.Errorf("Expected the shipping phone as fallback, got %q", details.ContactPhone())
+	}
+}
+
+func TestBusinessPayer(t *testing.T) {
+	values, _ := url.ParseQuery("TOKEN=EC-1&PAYERID=QWERTY&EMAIL=ap%40example.com&BUSINESS=Example+Widgets+GmbH&COUNTRYCODE=DE")
+	details := new(paypal.PayPalCheckoutDetails)
+	details.Populate(values)
+	if !details.IsBusiness() || details.BusinessName != "Example Widgets GmbH" || details.CountryCode != "DE" {
+		t.Errorf("Business payer not decoded from checkout details: %#v", details.PayPalPayer)
+	}
+
+	values, _ = url.ParseQuery("TRANSACTIONID=8AB&PAYERID=QWERTY&PAYERBUSINESS=Example+Widgets+GmbH")
+	transaction := new(paypal.PayPalTransactionDetails)
+	transaction.Populate(values)
+	if transaction.BusinessName != "Example Widgets GmbH" || transaction.PayerId != "QWERTY" {
+		t.Errorf("Business payer not decoded from transaction details: %#v", transaction.PayPalPayer)
+	}
+
+	values, _ = url.ParseQuery("TOKEN=EC-2&PAYERID=ASDF")
+	details = new(paypal.PayPalCheckoutDetails)
+	details.Populate(values)
+	if details.IsBusiness() {
+		t.Errorf("Personal payer reported as a business")
+	}
+}
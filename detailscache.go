@@ -0,0 +1,119 @@
+package paypal
+
+import (
+	"sync"
+	"time"
+)
+
+// How long PayPal keeps an Express Checkout token valid
+const CHECKOUT_TOKEN_LIFETIME = 3 * time.Hour
+
+// Remembers GetExpressCheckoutDetails responses by token, so reloading a
+// confirm page doesn't call PayPal again. The client qualifies each token
+// with the API username and subject the call was made as, so sellers
+// sharing a cache never see each other's checkouts. Implementations must be
+// safe for concurrent use.
+type DetailsCache interface {
+	// Returns nil for unknown or expired tokens
+	Get(token string) *PayPalResponse
+	Put(token string, response *PayPalResponse)
+	Delete(token string)
+}
+
+type cachedDetails struct {
+	response *PayPalResponse
+	cachedAt time.Time
+}
+
+type MemoryDetailsCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cachedDetails
+}
+
+// ttl controls how long details are served from the cache, at most
+// CHECKOUT_TOKEN_LIFETIME; 0 uses CHECKOUT_TOKEN_LIFETIME
+func NewMemoryDetailsCache(ttl time.Duration) *MemoryDetailsCache {
+	if ttl <= 0 || ttl > CHECKOUT_TOKEN_LIFETIME {
+		ttl = CHECKOUT_TOKEN_LIFETIME
+	}
+	return &MemoryDetailsCache{ttl: ttl, entries: make(map[string]cachedDetails)}
+}
+
+func (c *MemoryDetailsCache) Get(token string) *PayPalResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[token]
+	if !ok {
+		return nil
+	}
+	if time.Since(entry.cachedAt) > c.ttl {
+		delete(c.entries, token)
+		return nil
+	}
+	return entry.response
+}
+
+func (c *MemoryDetailsCache) Put(token string, response *PayPalResponse) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for cachedToken, entry := range c.entries {
+		if now.Sub(entry.cachedAt) > c.ttl {
+			delete(c.entries, cachedToken)
+		}
+	}
+	c.entries[token] = cachedDetails{response, now}
+}
+
+func (c *MemoryDetailsCache) Delete(token string) {
+	c.mu.Lock()
+	delete(c.entries, token)
+	c.mu.Unlock()
+}
+
+// With a cache set, GetExpressCheckoutDetails answers repeated calls for a
+// token from the cache until the checkout is paid. Every caller gets its
+// own copy of a cached response.
+func (pClient *PayPalClient) SetDetailsCache(cache DetailsCache) {
+	pClient.detailsCache = cache
+}
+
+func (pClient *PayPalClient) detailsKey(token string, opts []CallOption) string {
+	settings := pClient.callSettings(opts)
+	return token + "|" + settings.credentials.Username + "|" + settings.subject
+}
+
+func (pClient *PayPalClient) lookupDetails(token string, opts []CallOption) *PayPalResponse {
+	if pClient.detailsCache == nil {
+		return nil
+	}
+	if cached := pClient.detailsCache.Get(pClient.detailsKey(token, opts)); cached != nil {
+		return cached.copy()
+	}
+	return nil
+}
+
+func (pClient *PayPalClient) cacheDetails(token string, opts []CallOption, response *PayPalResponse) {
+	if pClient.detailsCache != nil {
+		pClient.detailsCache.Put(pClient.detailsKey(token, opts), response.copy())
+	}
+}
+
+func (pClient *PayPalClient) forgetDetails(token string, opts []CallOption) {
+	if pClient.detailsCache != nil {
+		pClient.detailsCache.Delete(pClient.detailsKey(token, opts))
+	}
+}
+
+// Copies the parts of a response Decode and callers may change, so a cached
+// response is never modified through the one handed out
+func (r *PayPalResponse) copy() *PayPalResponse {
+	copied := *r
+	copied.Values = copyValues(r.Values)
+	copied.Warnings = append([]string(nil), r.Warnings...)
+	return &copied
+}
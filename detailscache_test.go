@@ -0,0 +1,81 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestDetailsCache(t *testing.T) {
+	calls := 0
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		if values.Get("METHOD") == "GetExpressCheckoutDetails" {
+			calls++
+			return "ACK=Success&TOKEN=" + values.Get("TOKEN") + "&PAYERID=BUYER1"
+		}
+		return "ACK=Success&PAYMENTINFO_0_TRANSACTIONID=TX1"
+	})})
+	client.SetDetailsCache(paypal.NewMemoryDetailsCache(0))
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetExpressCheckoutDetails("EC-1"); err != nil {
+			t.Fatalf("Unexpected error: %#v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected repeated details calls to be served from the cache, PayPal called %d times", calls)
+	}
+
+	if _, err := client.DoExpressCheckoutPayment("EC-1", "BUYER1", paypal.PAYMENT_ACTION_SALE, "USD", 10); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	client.GetExpressCheckoutDetails("EC-1")
+	if calls != 2 {
+		t.Errorf("Expected the cached details to be dropped once the checkout was paid")
+	}
+}
+
+func TestDetailsCacheIsolation(t *testing.T) {
+	calls := 0
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		calls++
+		return "ACK=Success&TOKEN=" + values.Get("TOKEN") + "&PAYERID=" + values.Get("SUBJECT") + "&PAYMENTREQUEST_0_AMT=abc"
+	})})
+	client.SetDetailsCache(paypal.NewMemoryDetailsCache(0))
+
+	first, err := client.GetExpressCheckoutDetails("EC-1", paypal.WithSubject("a@example.com"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	first.Decode(&paypal.PayPalCheckoutDetails{})
+	first.Values.Set("PAYERID", "changed")
+
+	second, _ := client.GetExpressCheckoutDetails("EC-1", paypal.WithSubject("a@example.com"))
+	if calls != 1 {
+		t.Fatalf("Expected the second call to be served from the cache, PayPal called %d times", calls)
+	}
+	if len(second.Warnings) != 0 || second.Values.Get("PAYERID") != "a@example.com" {
+		t.Errorf("Changes to a returned response leaked into the cache: %v %v", second.Warnings, second.Values)
+	}
+
+	other, _ := client.GetExpressCheckoutDetails("EC-1", paypal.WithSubject("b@example.com"))
+	if calls != 2 || other.Values.Get("PAYERID") != "b@example.com" {
+		t.Errorf("Expected another subject's details not to come from the cache")
+	}
+}
+
+func TestMemoryDetailsCacheExpiry(t *testing.T) {
+	cache := paypal.NewMemoryDetailsCache(time.Millisecond)
+
+	cache.Put("EC-1", &paypal.PayPalResponse{Ack: "Success"})
+	if cache.Get("EC-1") == nil {
+		t.Fatalf("Expected EC-1 to be cached")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if cache.Get("EC-1") != nil {
+		t.Errorf("EC-1 should have expired after the ttl elapsed")
+	}
+}
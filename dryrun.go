@@ -0,0 +1,72 @@
+package paypal
+
+import (
+	"io/ioutil"
+	"net/url"
+)
+
+// Put in place of the password and signature in a DryRunError
+const DRY_RUN_REDACTED = "REDACTED"
+
+// Returned instead of a response by calls made in dry-run mode: the request
+// was built and passed every check the client makes, and was not sent
+type DryRunError struct {
+	Method   string
+	Endpoint string
+	// The request's fields, including USER, SUBJECT and VERSION, with PWD
+	// and SIGNATURE redacted. Adaptive calls send the credentials as
+	// headers, so their Values only hold the body.
+	Values url.Values
+	// Values encoded in key order, for snapshot tests
+	Body string
+	// What the client changed in the request, e.g. truncated fields
+	Warnings []string
+}
+
+func (e *DryRunError) Error() string {
+	return "PayPal " + e.Method + " not sent: dry run"
+}
+
+// Makes every call of the client a dry run, e.g. for a preview deployment
+// or an audit of what a batch job would send
+func (pClient *PayPalClient) SetDryRun(dryRun bool) {
+	pClient.dryRun = dryRun
+}
+
+// Makes the call fail with a *DryRunError holding its request instead of
+// sending it. Unlike sent calls, dry runs against the live API don't need
+// ConfirmLive.
+func WithDryRun() CallOption {
+	return func(settings *callSettings) {
+		settings.dryRun = true
+	}
+}
+
+func (pClient *PayPalClient) buildDryRun(values url.Values, settings callSettings) error {
+	settings.dryRun = true
+	request, err := pClient.buildRequest(values, settings)
+	if err != nil {
+		return err
+	}
+	body, err := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	sent, err := url.ParseQuery(string(body))
+	if err != nil {
+		return err
+	}
+	sent.Set("PWD", DRY_RUN_REDACTED)
+	sent.Set("SIGNATURE", DRY_RUN_REDACTED)
+
+	built, _ := request.Context().Value(builtRequestKey{}).(builtRequest)
+	return &DryRunError{
+		Method:   built.method,
+		Endpoint: request.URL.String(),
+		Values:   sent,
+		Body:     sent.Encode(),
+		Warnings: built.warnings,
+	}
+}
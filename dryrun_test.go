@@ -0,0 +1,78 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	calls := 0
+	client := paypal.NewClient("user", "pass", "sig", false, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		calls++
+		return "ACK=Success"
+	})})
+	client.SetRequireLiveConfirmation(true)
+	refund := paypal.PayPalRefund{TransactionId: "8RT51812D3056610N", RefundType: paypal.REFUND_TYPE_FULL, Note: "Late delivery"}
+
+	_, err := client.RefundTransaction(refund, paypal.WithDryRun(), paypal.WithSubject("seller@example.com"))
+	dryRun, ok := err.(*paypal.DryRunError)
+	if !ok {
+		t.Fatalf("Expected a *DryRunError, got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Expected nothing to be sent, got %d calls", calls)
+	}
+	if dryRun.Method != "RefundTransaction" || dryRun.Endpoint != paypal.NVP_PRODUCTION_URL {
+		t.Errorf("Unexpected dry run %+v", dryRun)
+	}
+	expected := "METHOD=RefundTransaction&NOTE=Late+delivery&PWD=REDACTED&REFUNDTYPE=Full&SIGNATURE=REDACTED" +
+		"&SUBJECT=seller%40example.com&TRANSACTIONID=8RT51812D3056610N&USER=user&VERSION=" + paypal.NVP_VERSION
+	if dryRun.Body != expected {
+		t.Errorf("Unexpected body:\n%s\nexpected:\n%s", dryRun.Body, expected)
+	}
+
+	// validation still applies
+	if _, err = client.RefundTransaction(paypal.PayPalRefund{RefundType: paypal.REFUND_TYPE_PARTIAL}, paypal.WithDryRun()); err != paypal.ErrRefundAmountRequired {
+		t.Errorf("Expected the refund to be validated, got %v", err)
+	}
+
+	// a request built for sending is still guarded
+	if _, err = client.BuildRequest(url.Values{"METHOD": {"RefundTransaction"}}, paypal.WithDryRun()); err == nil {
+		t.Errorf("Expected BuildRequest to apply the live guard")
+	}
+
+	client.SetDryRun(true)
+	if _, err = client.GetBalance(true); err == nil || calls != 0 {
+		t.Errorf("Expected the client to be in dry-run mode, got %v after %d calls", err, calls)
+	}
+}
+
+func TestDryRunAdaptive(t *testing.T) {
+	transport := &adaptiveTransport{body: "responseEnvelope.ack=Success&payKey=AP-5"}
+	live := paypal.NewClient("user", "pass", "sig", false, &http.Client{Transport: transport})
+	live.SetRequireLiveConfirmation(true)
+	adaptive := paypal.NewAdaptiveClient(live, "APP-LIVE")
+
+	pay := paypal.AdaptivePay{CurrencyCode: "USD", Receivers: []paypal.AdaptiveReceiver{{Email: "seller@example.com", Amount: 10}}}
+	_, err := adaptive.Pay(pay, paypal.WithDryRun())
+	dryRun, ok := err.(*paypal.DryRunError)
+	if !ok {
+		t.Fatalf("Expected a *DryRunError, got %#v", err)
+	}
+	if dryRun.Method != "Pay" || dryRun.Endpoint != paypal.ADAPTIVE_PRODUCTION_URL+"Pay" || dryRun.Values.Get("receiverList.receiver(0).amount") != "10.00" {
+		t.Errorf("Unexpected dry run: %#v", dryRun)
+	}
+
+	live.SetDryRun(true)
+	if _, err := adaptive.Refund("AP-5", "USD", nil); err == nil {
+		t.Errorf("Expected a *DryRunError from a dry-run client")
+	} else if _, ok := err.(*paypal.DryRunError); !ok {
+		t.Errorf("Expected a *DryRunError, got %#v", err)
+	}
+	if transport.request != nil {
+		t.Errorf("Dry-run Adaptive call sent to PayPal")
+	}
+}
@@ -0,0 +1,104 @@
+package paypal
+
+import (
+	"bytes"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const upperHex = "0123456789ABCDEF"
+
+// Form body under construction, recycled through encoderPool
+type nvpEncoder struct {
+	buf  bytes.Buffer
+	keys []string
+}
+
+var encoderPool = sync.Pool{New: func() interface{} { return new(nvpEncoder) }}
+
+func getEncoder() *nvpEncoder {
+	encoder := encoderPool.Get().(*nvpEncoder)
+	encoder.buf.Reset()
+	return encoder
+}
+
+func putEncoder(encoder *nvpEncoder) {
+	// don't keep unusually large bodies alive in the pool
+	if encoder.buf.Cap() > 64*1024 {
+		return
+	}
+	encoderPool.Put(encoder)
+}
+
+// Body implementing io.ReadCloser that hands the encoder back to the pool
+// once the transport is done with it
+type encodedBody struct {
+	*bytes.Reader
+	encoder *nvpEncoder
+}
+
+func (body *encodedBody) Close() error {
+	if body.encoder != nil {
+		putEncoder(body.encoder)
+		body.encoder = nil
+	}
+	return nil
+}
+
+func (encoder *nvpEncoder) body() *encodedBody {
+	return &encodedBody{bytes.NewReader(encoder.buf.Bytes()), encoder}
+}
+
+// Appends values in key order, the same output as values.Encode()
+func (encoder *nvpEncoder) writeValues(values url.Values) {
+	encoder.keys = encoder.keys[:0]
+	for key := range values {
+		encoder.keys = append(encoder.keys, key)
+	}
+	sort.Strings(encoder.keys)
+
+	for _, key := range encoder.keys {
+		for _, value := range values[key] {
+			encoder.writePair(key, value)
+		}
+	}
+}
+
+func (encoder *nvpEncoder) writePair(key, value string) {
+	if encoder.buf.Len() > 0 {
+		encoder.buf.WriteByte('&')
+	}
+	encoder.writeEscaped(key)
+	encoder.buf.WriteByte('=')
+	encoder.writeEscaped(value)
+}
+
+// Same escaping as url.QueryEscape, without the intermediate string
+func (encoder *nvpEncoder) writeEscaped(s string) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			encoder.buf.WriteByte(c)
+		case c == ' ':
+			encoder.buf.WriteByte('+')
+		default:
+			encoder.buf.WriteByte('%')
+			encoder.buf.WriteByte(upperHex[c>>4])
+			encoder.buf.WriteByte(upperHex[c&15])
+		}
+	}
+}
+
+// NVP amounts always carry two decimals
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// Key of the nth entry of a list field, e.g. L_PAYMENTREQUEST_0_NAME3
+func listKey(prefix string, n int) string {
+	return prefix + strconv.Itoa(n)
+}
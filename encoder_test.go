@@ -0,0 +1,244 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// Discards the request and answers with a fixed NVP body
+type staticTransport string
+
+func (body staticTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Body != nil {
+		ioutil.ReadAll(r.Body)
+		r.Body.Close()
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(string(body))),
+		Header:     make(http.Header),
+		Request:    r,
+	}, nil
+}
+
+func benchmarkGoods(count int) []paypal.PayPalGood {
+	goods := make([]paypal.PayPalGood, count)
+	for i := range goods {
+		goods[i] = paypal.PayPalGood{Id: "SKU-1234", Name: "Subscription & add-ons", Amount: 9.99, Quantity: 2}
+	}
+	return goods
+}
+
+func TestRequestEncoding(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "p&ss word", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success"
+	})})
+
+	order := paypal.PayPalOrder{SubTotal: 39.96, Total: 39.96, CurrencyCode: "USD", ReturnUrl: TEST_RETURN_URL + "?a=1&b=2", CancelUrl: TEST_CANCEL_URL}
+	if _, err := client.SetExpressCheckout(order, benchmarkGoods(2)); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+
+	expected := map[string]string{
+		"METHOD":                   "SetExpressCheckout",
+		"PWD":                      "p&ss word",
+		"RETURNURL":                TEST_RETURN_URL + "?a=1&b=2",
+		"PAYMENTREQUEST_0_AMT":     "39.96",
+		"L_PAYMENTREQUEST_0_NAME1": "Subscription & add-ons",
+		"L_PAYMENTREQUEST_0_AMT1":  "9.99",
+		"L_PAYMENTREQUEST_0_QTY1":  "2",
+	}
+	for key, value := range expected {
+		if sent.Get(key) != value {
+			t.Errorf("Expected %s=%q, got %q", key, value, sent.Get(key))
+		}
+	}
+}
+
+func TestDiscountStrategies(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success"
+	})})
+	goods := benchmarkGoods(1)
+
+	client.SetExpressCheckout(paypal.PayPalOrder{SubTotal: 14.98, Discount: 5, Total: 14.98, CurrencyCode: "USD"}, goods)
+	if sent.Get("L_PAYMENTREQUEST_0_NAME1") != "DISCOUNT" || sent.Get("L_PAYMENTREQUEST_0_AMT1") != "-5.00" || len(sent["PAYMENTREQUEST_0_SHIPDISCAMT"]) != 0 {
+		t.Errorf("Expected a DISCOUNT line item, got %#v", sent)
+	}
+
+	client.SetExpressCheckout(paypal.PayPalOrder{SubTotal: 19.98, Discount: 5, DiscountStrategy: paypal.DISCOUNT_SHIPPING_DISCOUNT, Total: 14.98, CurrencyCode: "USD"}, goods)
+	if sent.Get("PAYMENTREQUEST_0_SHIPDISCAMT") != "-5.00" || len(sent["L_PAYMENTREQUEST_0_NAME1"]) != 0 {
+		t.Errorf("Expected SHIPDISCAMT and no DISCOUNT line item, got %#v", sent)
+	}
+}
+
+func TestDescriptionAndNote(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success"
+	})})
+	order := paypal.PayPalOrder{SubTotal: 19.98, Total: 19.98, CurrencyCode: "USD", Description: "Order #1001", NoteText: "Thanks!", AllowNote: true}
+
+	client.SetExpressCheckout(order, benchmarkGoods(1))
+	if sent.Get("PAYMENTREQUEST_0_DESC") != "Order #1001" || sent.Get("PAYMENTREQUEST_0_NOTETEXT") != "Thanks!" || sent.Get("ALLOWNOTE") != "1" {
+		t.Errorf("Description and note not sent on setup: %#v", sent)
+	}
+
+	client.DoExpressCheckoutOrderPayment("EC-TOKEN", "PAYERID", paypal.PAYMENT_ACTION_SALE, order, benchmarkGoods(1))
+	if sent.Get("METHOD") != "DoExpressCheckoutPayment" || sent.Get("PAYMENTREQUEST_0_DESC") != "Order #1001" ||
+		sent.Get("L_PAYMENTREQUEST_0_NAME0") != "Subscription & add-ons" || sent.Get("PAYMENTREQUEST_0_AMT") != "19.98" {
+		t.Errorf("Order not sent with the payment: %#v", sent)
+	}
+	if len(sent["ALLOWNOTE"]) != 0 || len(sent["RETURNURL"]) != 0 {
+		t.Errorf("Setup-only fields sent with the payment: %#v", sent)
+	}
+}
+
+func TestInstantPaymentOnly(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success"
+	})})
+
+	client.SetExpressCheckout(paypal.PayPalOrder{CurrencyCode: "USD"}, nil)
+	if len(sent["PAYMENTREQUEST_0_ALLOWEDPAYMENTMETHOD"]) != 0 {
+		t.Errorf("ALLOWEDPAYMENTMETHOD sent by default")
+	}
+
+	order := paypal.PayPalOrder{CurrencyCode: "USD", InstantPaymentOnly: true}
+	client.SetExpressCheckout(order, nil)
+	if sent.Get("PAYMENTREQUEST_0_ALLOWEDPAYMENTMETHOD") != "InstantPaymentOnly" {
+		t.Errorf("Instant payment restriction not sent on setup: %#v", sent)
+	}
+	client.DoExpressCheckoutOrderPayment("EC-TOKEN", "PAYERID", paypal.PAYMENT_ACTION_SALE, order, nil)
+	if sent.Get("PAYMENTREQUEST_0_ALLOWEDPAYMENTMETHOD") != "InstantPaymentOnly" {
+		t.Errorf("Instant payment restriction not sent with the payment: %#v", sent)
+	}
+}
+
+func TestBillingAgreements(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success"
+	})})
+
+	client.SetExpressCheckout(paypal.PayPalOrder{CurrencyCode: "USD", BillingAgreements: []paypal.PayPalBillingAgreement{
+		{Type: paypal.BILLING_TYPE_RECURRING_PAYMENTS, Description: "Monthly plan"},
+		{Type: paypal.BILLING_TYPE_MERCHANT_INITIATED, Description: "Usage charges", Custom: "account-42", PaymentType: "InstantOnly"},
+	}}, nil)
+
+	if sent.Get("L_BILLINGTYPE0") != "RecurringPayments" || sent.Get("L_BILLINGAGREEMENTDESCRIPTION0") != "Monthly plan" || len(sent["L_PAYMENTTYPE0"]) != 0 {
+		t.Errorf("First agreement not sent: %#v", sent)
+	}
+	if sent.Get("L_BILLINGTYPE1") != "MerchantInitiatedBilling" || sent.Get("L_BILLINGAGREEMENTCUSTOM1") != "account-42" || sent.Get("L_PAYMENTTYPE1") != "InstantOnly" {
+		t.Errorf("Second agreement not sent: %#v", sent)
+	}
+}
+
+func TestShippingBehavior(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success"
+	})})
+
+	expected := map[paypal.ShippingBehavior][2]string{
+		paypal.SHIPPING_NONE:      {"1", "0"},
+		paypal.SHIPPING_OPTIONAL:  {"0", "0"},
+		paypal.SHIPPING_REQUIRED:  {"2", "0"},
+		paypal.SHIPPING_CONFIRMED: {"2", "1"},
+	}
+	for behavior, flags := range expected {
+		client.SetExpressCheckout(paypal.PayPalOrder{CurrencyCode: "USD", ShippingBehavior: behavior}, nil)
+		if sent.Get("NOSHIPPING") != flags[0] || sent.Get("REQCONFIRMSHIPPING") != flags[1] {
+			t.Errorf("Behavior %d: NOSHIPPING=%s REQCONFIRMSHIPPING=%s", behavior, sent.Get("NOSHIPPING"), sent.Get("REQCONFIRMSHIPPING"))
+		}
+	}
+}
+
+func TestSolutionAndChannelType(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success"
+	})})
+
+	client.SetExpressCheckout(paypal.PayPalOrder{CurrencyCode: "USD"}, nil)
+	if sent.Get("SOLUTIONTYPE") != "Sole" || len(sent["CHANNELTYPE"]) != 0 {
+		t.Errorf("Unexpected defaults: SOLUTIONTYPE=%q CHANNELTYPE=%q", sent.Get("SOLUTIONTYPE"), sent.Get("CHANNELTYPE"))
+	}
+
+	client.SetExpressCheckout(paypal.PayPalOrder{CurrencyCode: "USD", SolutionType: paypal.SOLUTION_TYPE_MARK, ChannelType: paypal.CHANNEL_TYPE_MERCHANT}, nil)
+	if sent.Get("SOLUTIONTYPE") != "Mark" || sent.Get("CHANNELTYPE") != "Merchant" {
+		t.Errorf("Unexpected values: SOLUTIONTYPE=%q CHANNELTYPE=%q", sent.Get("SOLUTIONTYPE"), sent.Get("CHANNELTYPE"))
+	}
+}
+
+func TestGiropayFlow(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success&TOKEN=EC-1&REDIRECTREQUIRED=true"
+	})})
+
+	order := paypal.PayPalOrder{CurrencyCode: "EUR", GiropaySuccessUrl: "https://shop.example/giropay/ok", GiropayCancelUrl: "https://shop.example/giropay/cancel", BankTxnPendingUrl: "https://shop.example/pending"}
+	client.SetExpressCheckout(order, nil)
+	if sent.Get("GIROPAYSUCCESSURL") != order.GiropaySuccessUrl || sent.Get("GIROPAYCANCELURL") != order.GiropayCancelUrl || sent.Get("BANKTXNPENDINGURL") != order.BankTxnPendingUrl {
+		t.Errorf("giropay URLs not sent: %#v", sent)
+	}
+
+	response, _ := client.DoExpressCheckoutSale("EC-1", "PAYERID", "EUR", 10)
+	payment := new(paypal.PayPalPaymentResponse)
+	payment.Populate(response.Values)
+	if !payment.RedirectRequired {
+		t.Errorf("REDIRECTREQUIRED not decoded")
+	}
+	if response.CompleteCheckoutUrl() != paypal.CHECKOUT_SANDBOX_URL+"?cmd=_complete-express-checkout&token=EC-1" {
+		t.Errorf("Unexpected complete checkout URL %s", response.CompleteCheckoutUrl())
+	}
+}
+
+func BenchmarkSetExpressCheckout(b *testing.B) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: staticTransport("ACK=Success&TOKEN=EC-0AB12345CD678901E")})
+	order := paypal.PayPalOrder{SubTotal: 199.80, Total: 199.80, CurrencyCode: "USD", ReturnUrl: TEST_RETURN_URL, CancelUrl: TEST_CANCEL_URL}
+	goods := benchmarkGoods(10)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		client.SetExpressCheckout(order, goods)
+	}
+}
+
+func BenchmarkSetExpressCheckoutDigitalGoods(b *testing.B) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: staticTransport("ACK=Success&TOKEN=EC-0AB12345CD678901E")})
+	goods := make([]paypal.PayPalDigitalGood, 10)
+	for i := range goods {
+		goods[i] = paypal.PayPalDigitalGood{Name: "E-book: Go & PayPal", Amount: 4.99, Quantity: 1}
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		client.SetExpressCheckoutDigitalGoods(49.90, "USD", TEST_RETURN_URL, TEST_CANCEL_URL, goods)
+	}
+}
+
+func BenchmarkDoExpressCheckoutSale(b *testing.B) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: staticTransport("ACK=Success&PAYMENTINFO_0_TRANSACTIONID=8AB12345CD678901E")})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		client.DoExpressCheckoutSale("EC-0AB12345CD678901E", "PAYERID123", "USD", 49.90)
+	}
+}
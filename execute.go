@@ -0,0 +1,24 @@
+package paypal
+
+import "context"
+
+// Calls request with ctx and decodes the response into a T, a struct with
+// nvp tags as described at PayPalResponse.DecodeTagged, so covering a new
+// method only takes a result struct:
+//
+//	balance, err := paypal.Execute[BalanceResult](ctx, client, paypal.NVPRequest{Method: "GetBalance"})
+//
+// When PayPal answers with an error the result is still decoded from the
+// response, which may carry details about the failure.
+func Execute[T any](ctx context.Context, client *PayPalClient, request NVPRequest, opts ...CallOption) (T, error) {
+	var result T
+	// copied so ctx never lands in the caller's backing array
+	response, err := client.Execute(request, append(append([]CallOption(nil), opts...), WithContext(ctx))...)
+	if response == nil {
+		return result, err
+	}
+	if decodeErr := response.DecodeTagged(&result); err == nil {
+		err = decodeErr
+	}
+	return result, err
+}
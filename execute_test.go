@@ -0,0 +1,65 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type balanceResult struct {
+	Amounts    []float64 `nvp:"L_AMT{n}"`
+	Currencies []string  `nvp:"L_CURRENCYCODE{n}"`
+	Timestamp  time.Time `nvp:"TIMESTAMP"`
+}
+
+type searchRow struct {
+	TransactionId string  `nvp:"L_TRANSACTIONID{n}"`
+	Amount        float64 `nvp:"L_AMT{n}"`
+}
+
+type searchResult struct {
+	Rows []searchRow `nvp:"*"`
+	Ack  string      `nvp:"ACK"`
+}
+
+func TestExecuteTyped(t *testing.T) {
+	answer := "ACK=Success&TIMESTAMP=2016-03-11T15%3A06%3A29Z&L_AMT0=10.00&L_CURRENCYCODE0=USD&L_AMT1=5.5&L_CURRENCYCODE1=EUR"
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		return answer
+	})})
+
+	balance, err := paypal.Execute[balanceResult](context.Background(), client, paypal.NVPRequest{Method: "GetBalance", Fields: map[string]string{"RETURNALLCURRENCIES": "1"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(balance.Amounts) != 2 || balance.Amounts[1] != 5.5 || balance.Currencies[1] != "EUR" || balance.Timestamp.Hour() != 15 {
+		t.Errorf("Unexpected result: %#v", balance)
+	}
+
+	answer = "ACK=Success&L_TRANSACTIONID0=TX1&L_AMT0=1.00&L_TRANSACTIONID1=TX2&L_AMT1=2.00"
+	// spare capacity in the caller's options must not be written to
+	opts := make([]paypal.CallOption, 1, 2)
+	opts[0] = paypal.WithSubject("seller@example.com")
+	search, err := paypal.Execute[searchResult](context.Background(), client, paypal.NVPRequest{Method: "TransactionSearch"}, opts...)
+	if opts[:2][1] != nil {
+		t.Errorf("Execute appended to the caller's options")
+	}
+	if err != nil || len(search.Rows) != 2 || search.Rows[1].TransactionId != "TX2" || search.Rows[1].Amount != 2 || search.Ack != "Success" {
+		t.Errorf("Unexpected result: %#v, %v", search, err)
+	}
+}
+
+func TestExecuteTypedStrict(t *testing.T) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		return "ACK=Success&L_AMT0=ten&L_CURRENCYCODE0=USD"
+	})})
+	client.SetParseMode(paypal.PARSE_STRICT)
+
+	if _, err := paypal.Execute[balanceResult](context.Background(), client, paypal.NVPRequest{Method: "GetBalance"}); err == nil {
+		t.Errorf("Expected a *ParseError for an invalid amount in strict mode")
+	}
+}
@@ -0,0 +1,162 @@
+package paypal
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Maximum lengths of free text NVP fields, keyed with every number in the
+// field name replaced by n. PayPal answers longer values with a 10004
+// error that doesn't say which field was at fault.
+var FieldLengthLimits = map[string]int{
+	"DESC":                      127,
+	"CUSTOM":                    256,
+	"NOTE":                      255,
+	"NOTETEXT":                  255,
+	"INVNUM":                    127,
+	"SOFTDESCRIPTOR":            22,
+	"PAYMENTREQUEST_n_DESC":     127,
+	"PAYMENTREQUEST_n_CUSTOM":   256,
+	"PAYMENTREQUEST_n_NOTETEXT": 255,
+	"L_PAYMENTREQUEST_n_NAMEn":  127,
+	"L_PAYMENTREQUEST_n_DESCn":  127,
+
+	"PAYMENTREQUEST_n_SHIPTONAME":     128,
+	"PAYMENTREQUEST_n_SHIPTOSTREET":   100,
+	"PAYMENTREQUEST_n_SHIPTOSTREETn":  100,
+	"PAYMENTREQUEST_n_SHIPTOCITY":     40,
+	"PAYMENTREQUEST_n_SHIPTOSTATE":    40,
+	"PAYMENTREQUEST_n_SHIPTOZIP":      20,
+	"PAYMENTREQUEST_n_SHIPTOPHONENUM": 20,
+
+	"L_BILLINGAGREEMENTDESCRIPTIONn": 127,
+	"L_BILLINGAGREEMENTCUSTOMn":      256,
+
+	"SUBSCRIBERNAME": 32,
+
+	// Adaptive Payments fields
+	"memo":                           1000,
+	"trackingId":                     127,
+	"receiverList.receiver(n).email": 127,
+}
+
+// Line items accepted in a single payment request, including the item
+// SetExpressCheckout adds for a discount
+var MaxLineItems = 100
+
+// Payment requests accepted in a single parallel checkout
+var MaxPaymentRequests = 10
+
+type FieldLengthError struct {
+	Field  string
+	Length int
+	Limit  int
+}
+
+func (e *FieldLengthError) Error() string {
+	return fmt.Sprintf("PayPal field %s is %d characters long, the limit is %d", e.Field, e.Length, e.Limit)
+}
+
+// Returned before calling PayPal when a request carries more entries of a
+// list than PayPal accepts
+type CountLimitError struct {
+	What  string
+	Count int
+	Limit int
+}
+
+func (e *CountLimitError) Error() string {
+	return fmt.Sprintf("PayPal accepts at most %d %s per request, got %d", e.Limit, e.What, e.Count)
+}
+
+func checkPaymentRequestCount(count int) error {
+	if count > MaxPaymentRequests {
+		return &CountLimitError{"payment requests", count, MaxPaymentRequests}
+	}
+	return nil
+}
+
+func checkLineItemCount(count int) error {
+	if count > MaxLineItems {
+		return &CountLimitError{"line items", count, MaxLineItems}
+	}
+	return nil
+}
+
+// When enabled, values over their limit are cut to length and a warning is
+// added to the response instead of failing the call with a
+// *FieldLengthError
+func (pClient *PayPalClient) SetTruncateLongFields(truncate bool) {
+	pClient.truncateFields = truncate
+}
+
+func fieldLimitKey(field string) string {
+	var b strings.Builder
+	inNumber := false
+	for i := 0; i < len(field); i++ {
+		c := field[i]
+		if '0' <= c && c <= '9' {
+			if !inNumber {
+				b.WriteByte('n')
+			}
+			inNumber = true
+			continue
+		}
+		inNumber = false
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// Returns values with over-long fields truncated, copying them first so
+// the caller's values are left alone
+func enforceFieldLengths(values url.Values, truncate bool) (url.Values, []string, error) {
+	var warnings []string
+	copied := false
+
+	for field, list := range values {
+		limit, ok := FieldLengthLimits[fieldLimitKey(field)]
+		if !ok {
+			continue
+		}
+		for i, value := range list {
+			length := utf8.RuneCountInString(value)
+			if length <= limit {
+				continue
+			}
+			if !truncate {
+				return values, nil, &FieldLengthError{field, length, limit}
+			}
+
+			if !copied {
+				values = copyValues(values)
+				copied = true
+			}
+			values[field][i] = truncateRunes(value, limit)
+			warnings = append(warnings, fmt.Sprintf("%s truncated from %d to %d characters", field, length, limit))
+		}
+	}
+
+	return values, warnings, nil
+}
+
+func copyValues(values url.Values) url.Values {
+	copied := make(url.Values, len(values))
+	for key, list := range values {
+		copied[key] = append([]string(nil), list...)
+	}
+	return copied
+}
+
+func truncateRunes(s string, limit int) string {
+	count := 0
+	for i := range s {
+		if count == limit {
+			return s[:i]
+		}
+		count++
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestFieldLengthError(t *testing.T) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		t.Errorf("Request with an over-long field was sent")
+		return "ACK=Success"
+	})})
+
+	goods := []paypal.PayPalGood{{Name: strings.Repeat("n", 128), Amount: 1, Quantity: 1}}
+	_, err := client.SetExpressCheckout(paypal.PayPalOrder{SubTotal: 1, Total: 1, CurrencyCode: "USD"}, goods)
+
+	lengthErr, ok := err.(*paypal.FieldLengthError)
+	if !ok {
+		t.Fatalf("Expected a *FieldLengthError, got %#v", err)
+	}
+	if lengthErr.Field != "L_PAYMENTREQUEST_0_NAME0" || lengthErr.Length != 128 || lengthErr.Limit != 127 {
+		t.Errorf("Unexpected error details: %#v", lengthErr)
+	}
+}
+
+func TestFieldLengthTruncation(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success"
+	})})
+	client.SetTruncateLongFields(true)
+
+	values := url.Values{
+		"METHOD":                  {"SetExpressCheckout"},
+		"PAYMENTREQUEST_0_CUSTOM": {strings.Repeat("é", 300)},
+		"NOTETEXT":                {"short enough"},
+	}
+	response, err := client.PerformRequest(values)
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+
+	if sent.Get("PAYMENTREQUEST_0_CUSTOM") != strings.Repeat("é", 256) {
+		t.Errorf("CUSTOM not truncated to 256 characters: %d", len([]rune(sent.Get("PAYMENTREQUEST_0_CUSTOM"))))
+	}
+	if len(values.Get("PAYMENTREQUEST_0_CUSTOM")) != 600 {
+		t.Errorf("Caller's values were modified")
+	}
+	if len(response.Warnings) != 1 || !strings.Contains(response.Warnings[0], "PAYMENTREQUEST_0_CUSTOM") {
+		t.Errorf("Expected one truncation warning, got %#v", response.Warnings)
+	}
+}
+
+func TestLineItemLimit(t *testing.T) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		t.Errorf("Request with too many line items was sent")
+		return "ACK=Success"
+	})})
+
+	goods := make([]paypal.PayPalGood, paypal.MaxLineItems)
+	_, err := client.SetExpressCheckout(paypal.PayPalOrder{Discount: 5, CurrencyCode: "USD"}, goods)
+
+	countErr, ok := err.(*paypal.CountLimitError)
+	if !ok {
+		t.Fatalf("Expected a *CountLimitError, got %#v", err)
+	}
+	if countErr.Count != paypal.MaxLineItems+1 || countErr.Limit != paypal.MaxLineItems {
+		t.Errorf("Discount line not counted: %#v", countErr)
+	}
+
+	digitalGoods := make([]paypal.PayPalDigitalGood, paypal.MaxLineItems+1)
+	if _, err := client.SetExpressCheckoutDigitalGoods(0, "USD", TEST_RETURN_URL, TEST_CANCEL_URL, digitalGoods); err == nil {
+		t.Errorf("Expected an error for too many digital goods")
+	}
+}
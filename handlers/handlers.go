@@ -0,0 +1,151 @@
+// Package handlers provides net/http handlers for the URLs PayPal sends
+// buyers and notifications to, so an integration only has to register them
+// on a mux:
+//
+//	mux.Handle("/paypal/return", handlers.Return(client, paypal.PAYMENT_ACTION_SALE, onPaid, nil))
+//	mux.Handle("/paypal/cancel", handlers.Cancel(onCancel))
+//	mux.Handle("/paypal/ipn", handlers.IPN(listener, onNotification))
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/badoet/go-paypal"
+)
+
+// CHECKOUTSTATUS of a checkout that has already been paid
+const CHECKOUT_STATUS_COMPLETED = "PaymentActionCompleted"
+
+// Passed to the ErrorFunc when the buyer comes back to the return URL of a
+// checkout that was already paid, e.g. after reloading the page
+var ErrAlreadyCompleted = errors.New("paypal: checkout already completed")
+
+// Passed to the ErrorFunc when the return URL is requested without a token
+// and PayerID
+var ErrMissingToken = errors.New("paypal: return URL called without token or PayerID")
+
+// Called once the payment of a returning buyer went through
+type PaidFunc func(w http.ResponseWriter, r *http.Request, details *paypal.PayPalCheckoutDetails, payment *paypal.PayPalResponse)
+
+// Called when completing the payment failed; the response has not been
+// written yet
+type ErrorFunc func(w http.ResponseWriter, r *http.Request, err error)
+
+// Answers 400 for requests without a token and 502 for everything else
+func defaultError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusBadGateway
+	if err == ErrMissingToken || err == ErrAlreadyCompleted {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// Handler for the checkout's ReturnUrl: reads the token and PayerID PayPal
+// adds to it, looks up the checkout and pays its full amount with action.
+// Set an OrderStore on client to have the order's line items sent with the
+// payment. onError may be nil.
+func Return(client *paypal.PayPalClient, action paypal.PaymentAction, onPaid PaidFunc, onError ErrorFunc) http.HandlerFunc {
+	if onError == nil {
+		onError = defaultError
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, payerId := r.FormValue("token"), r.FormValue("PayerID")
+		if len(token) == 0 || len(payerId) == 0 {
+			onError(w, r, ErrMissingToken)
+			return
+		}
+
+		// the request's context cancels the calls when the buyer goes away
+		// and carries its request id, see paypal.ContextWithRequestId
+		opts := []paypal.CallOption{paypal.WithContext(r.Context())}
+		response, err := client.GetExpressCheckoutDetails(token, opts...)
+		if err != nil {
+			onError(w, r, err)
+			return
+		}
+		details := new(paypal.PayPalCheckoutDetails)
+		if err = response.Decode(details); err != nil {
+			onError(w, r, err)
+			return
+		}
+		if details.CheckoutStatus == CHECKOUT_STATUS_COMPLETED {
+			onError(w, r, ErrAlreadyCompleted)
+			return
+		}
+
+		payment, err := client.DoExpressCheckoutPayment(token, payerId, action, details.Currency, details.Amount, opts...)
+		if err != nil {
+			onError(w, r, err)
+			return
+		}
+		onPaid(w, r, details, payment)
+	}
+}
+
+// Handler for the checkout's CancelUrl, passing onCancel the token of the
+// abandoned checkout
+func Cancel(onCancel func(w http.ResponseWriter, r *http.Request, token string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		onCancel(w, r, r.FormValue("token"))
+	}
+}
+
+// Handler for the IPN notification URL. Notifications that pass the
+// listener's checks are handed to onMessage; PayPal redelivers them until
+// onMessage returns nil. Duplicates and notifications rejected by the
+// listener are acknowledged without calling onMessage, since redelivering
+// them would not change the outcome. The listener's Deduplicator records a
+// message before onMessage runs, so leave it unset if onMessage failing
+// should get the message redelivered.
+func IPN(listener *paypal.IPNListener, onMessage func(r *http.Request, message *paypal.IPNMessage) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, "IPN notifications must be POSTed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		message, err := listener.Process(r)
+		if err != nil {
+			writeIPNError(w, err)
+			return
+		}
+
+		if err = onMessage(r, message); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// Answers PayPal for a notification the listener did not accept
+func writeIPNError(w http.ResponseWriter, err error) {
+	if rejection, ok := err.(*paypal.IPNRejection); ok {
+		if rejection.Reason == paypal.IPN_REJECT_UNVERIFIED {
+			http.Error(w, rejection.Error(), http.StatusBadRequest)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+		return
+	}
+	var bodyErr *paypal.IPNBodyError
+	if errors.As(err, &bodyErr) {
+		writeBodyError(w, bodyErr.Err)
+		return
+	}
+	// PayPal couldn't be reached to verify the message, let it retry
+	http.Error(w, err.Error(), http.StatusServiceUnavailable)
+}
+
+// Answers 413 for bodies over paypal.MaxIPNBodySize and 400 for other
+// unreadable bodies, neither of which a redelivery would fix
+func writeBodyError(w http.ResponseWriter, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
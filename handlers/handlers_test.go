@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/badoet/go-paypal"
+)
+
+// Answers IPN verification with verifyAnswer and NVP calls with
+// nvp(values)
+type fakePayPal struct {
+	verifyAnswer string
+	nvp          func(values url.Values) string
+}
+
+func (f *fakePayPal) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, _ := ioutil.ReadAll(r.Body)
+	answer := f.verifyAnswer
+	if !strings.Contains(r.URL.Path, "webscr") {
+		values, _ := url.ParseQuery(string(body))
+		answer = f.nvp(values)
+	}
+	return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(answer)), Header: http.Header{}}, nil
+}
+
+func TestReturnPaysCheckout(t *testing.T) {
+	var paid url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: &fakePayPal{nvp: func(values url.Values) string {
+		if values.Get("METHOD") == "GetExpressCheckoutDetails" {
+			return "ACK=Success&TOKEN=EC-1&CHECKOUTSTATUS=PaymentActionNotInitiated&PAYMENTREQUEST_0_AMT=24.98&PAYMENTREQUEST_0_CURRENCYCODE=EUR"
+		}
+		paid = values
+		return "ACK=Success&TOKEN=EC-1&PAYMENTINFO_0_TRANSACTIONID=TX1"
+	}}})
+
+	var gotDetails *paypal.PayPalCheckoutDetails
+	handler := Return(client, paypal.PAYMENT_ACTION_SALE, func(w http.ResponseWriter, r *http.Request, details *paypal.PayPalCheckoutDetails, payment *paypal.PayPalResponse) {
+		gotDetails = details
+		w.Write([]byte(payment.Values.Get("PAYMENTINFO_0_TRANSACTIONID")))
+	}, nil)
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest("GET", "/return?token=EC-1&PayerID=BUYER1", nil))
+
+	if recorder.Body.String() != "TX1" || gotDetails == nil || gotDetails.Currency != "EUR" {
+		t.Fatalf("Payment callback not called: %d %q", recorder.Code, recorder.Body.String())
+	}
+	if paid.Get("PAYERID") != "BUYER1" || paid.Get("PAYMENTREQUEST_0_AMT") != "24.98" || paid.Get("PAYMENTREQUEST_0_CURRENCYCODE") != "EUR" {
+		t.Errorf("Unexpected payment request: %#v", paid)
+	}
+	// a buyer who went away cancels the calls
+	paid = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest("GET", "/return?token=EC-2&PayerID=BUYER1", nil).WithContext(ctx))
+	if recorder.Code != http.StatusBadGateway || paid != nil {
+		t.Errorf("Expected the cancelled request not to pay, got %d", recorder.Code)
+	}
+}
+
+func TestReturnRefusesCompletedCheckout(t *testing.T) {
+	paid := false
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: &fakePayPal{nvp: func(values url.Values) string {
+		if values.Get("METHOD") == "DoExpressCheckoutPayment" {
+			paid = true
+		}
+		return "ACK=Success&TOKEN=EC-1&CHECKOUTSTATUS=PaymentActionCompleted&PAYMENTREQUEST_0_AMT=24.98"
+	}}})
+
+	var gotErr error
+	handler := Return(client, paypal.PAYMENT_ACTION_SALE, nil, func(w http.ResponseWriter, r *http.Request, err error) {
+		gotErr = err
+	})
+	handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/return?token=EC-1&PayerID=BUYER1", nil))
+
+	if gotErr != ErrAlreadyCompleted || paid {
+		t.Errorf("Expected ErrAlreadyCompleted without a second payment, got %v", gotErr)
+	}
+
+	recorder := httptest.NewRecorder()
+	Return(client, paypal.PAYMENT_ACTION_SALE, nil, nil)(recorder, httptest.NewRequest("GET", "/return", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a token, got %d", recorder.Code)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	var cancelled string
+	handler := Cancel(func(w http.ResponseWriter, r *http.Request, token string) {
+		cancelled = token
+	})
+	handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/cancel?token=EC-1", nil))
+	if cancelled != "EC-1" {
+		t.Errorf("Expected the cancelled token, got %q", cancelled)
+	}
+}
+
+func TestIPN(t *testing.T) {
+	fake := &fakePayPal{verifyAnswer: "VERIFIED"}
+	listener := paypal.NewIPNListener("seller@example.com", true, &http.Client{Transport: fake})
+	listener.Deduplicator = paypal.NewMemoryDeduplicator(0)
+
+	calls := 0
+	var callbackErr error
+	handler := IPN(listener, func(r *http.Request, message *paypal.IPNMessage) error {
+		calls++
+		return callbackErr
+	})
+	post := func() int {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/ipn", strings.NewReader("txn_id=TX1&payment_status=Completed&receiver_email=seller@example.com"))
+		handler(recorder, request)
+		return recorder.Code
+	}
+
+	callbackErr = errors.New("database down")
+	if code := post(); code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 so PayPal redelivers, got %d", code)
+	}
+
+	fake.verifyAnswer = "INVALID"
+	if code := post(); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unverified message, got %d", code)
+	}
+
+	fake.verifyAnswer = "VERIFIED"
+	callbackErr = nil
+	listener.Deduplicator = paypal.NewMemoryDeduplicator(0)
+	if code := post(); code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", code)
+	}
+	if code := post(); code != http.StatusOK || calls != 2 {
+		t.Errorf("Expected the duplicate to be acknowledged without a callback, got %d after %d calls", code, calls)
+	}
+	// bodies PayPal can't fix by redelivering
+	for body, status := range map[string]int{
+		"txn_id=%zz": http.StatusBadRequest,
+		strings.Repeat("x", int(paypal.MaxIPNBodySize)+1): http.StatusRequestEntityTooLarge,
+	} {
+		recorder := httptest.NewRecorder()
+		handler(recorder, httptest.NewRequest("POST", "/ipn", strings.NewReader(body)))
+		if recorder.Code != status {
+			t.Errorf("Expected %d for an unreadable body, got %d", status, recorder.Code)
+		}
+	}
+}
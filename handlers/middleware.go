@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/badoet/go-paypal"
+)
+
+type contextKey int
+
+const ipnMessageKey contextKey = 0
+
+// Middleware running listener on IPN requests before next, for routers
+// built around http.Handler: use it directly with chi, through gin.WrapH or
+// echo.WrapMiddleware with Gin and Echo. next only sees notifications the
+// listener accepted, available from IPNMessageFromContext, and can still
+// read the original body. Rejected notifications are answered as IPN does,
+// bodies over paypal.MaxIPNBodySize with 413 Request Entity Too Large and
+// other unreadable bodies with 400.
+func VerifyIPN(listener *paypal.IPNListener) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, paypal.MaxIPNBodySize))
+			if err != nil {
+				writeBodyError(w, err)
+				return
+			}
+
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			message, err := listener.Process(r)
+			if err != nil {
+				writeIPNError(w, err)
+				return
+			}
+
+			r = r.WithContext(WithIPNMessage(r.Context(), message))
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Attaches message as VerifyIPN does, e.g. to test handlers mounted behind it
+func WithIPNMessage(ctx context.Context, message *paypal.IPNMessage) context.Context {
+	return context.WithValue(ctx, ipnMessageKey, message)
+}
+
+// The notification VerifyIPN accepted for this request
+func IPNMessageFromContext(ctx context.Context) (*paypal.IPNMessage, bool) {
+	message, ok := ctx.Value(ipnMessageKey).(*paypal.IPNMessage)
+	return message, ok
+}
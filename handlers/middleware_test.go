@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/badoet/go-paypal"
+)
+
+func TestVerifyIPN(t *testing.T) {
+	fake := &fakePayPal{verifyAnswer: "VERIFIED"}
+	listener := paypal.NewIPNListener("seller@example.com", true, &http.Client{Transport: fake})
+
+	var message *paypal.IPNMessage
+	var body string
+	handler := VerifyIPN(listener)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		message, _ = IPNMessageFromContext(r.Context())
+		raw, _ := ioutil.ReadAll(r.Body)
+		body = string(raw)
+	}))
+
+	notification := "txn_id=TX1&payment_status=Completed&receiver_email=seller@example.com"
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/ipn", strings.NewReader(notification)))
+	if recorder.Code != http.StatusOK || message == nil || message.TxnId != "TX1" || body != notification {
+		t.Fatalf("Verified message not passed on: %d %#v %q", recorder.Code, message, body)
+	}
+
+	message = nil
+	fake.verifyAnswer = "INVALID"
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/ipn", strings.NewReader(notification)))
+	if recorder.Code != http.StatusBadRequest || message != nil {
+		t.Errorf("Unverified message reached the handler: %d", recorder.Code)
+	}
+
+	message = nil
+	fake.verifyAnswer = "VERIFIED"
+	large := notification + "&memo=" + strings.Repeat("x", int(paypal.MaxIPNBodySize))
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/ipn", strings.NewReader(large)))
+	if recorder.Code != http.StatusRequestEntityTooLarge || message != nil {
+		t.Errorf("Oversized message reached the handler: %d", recorder.Code)
+	}
+
+	if _, ok := IPNMessageFromContext(httptest.NewRequest("GET", "/", nil).Context()); ok {
+		t.Errorf("Expected no message in a plain request context")
+	}
+}
+
+type failingBody struct{}
+
+func (failingBody) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestVerifyIPNUnreadableBody(t *testing.T) {
+	listener := paypal.NewIPNListener("seller@example.com", true, &http.Client{Transport: &fakePayPal{verifyAnswer: "VERIFIED"}})
+	handler := VerifyIPN(listener)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unreadable message reached the handler")
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/ipn", failingBody{}))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a body that failed to read, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,27 @@
+package paypal
+
+// What to do about well-known PayPal error codes, for on-call engineers
+// reading logs rather than for buyers
+var errorHints = map[string]string{
+	"10001":                               "PayPal internal error; retry later, safely only for read-only calls or calls with a MSGSUBID",
+	"10002":                               "authentication failed; check the API username, password and signature, and that sandbox credentials aren't used against production or vice versa",
+	"10004":                               "a field has an invalid value; check the long message for which one",
+	"10009":                               "the refund was refused; check the amount is not more than what is left to refund and the currency matches the transaction",
+	"10412":                               "the invoice id was already used for a payment; send a unique INVOICEID per order or disable the check in the PayPal account",
+	"10413":                               "the item amounts don't add up to ITEMAMT or the totals don't add up to AMT; check rounding of the order's amounts",
+	"10415":                               "the token was already used for a payment; look up the earlier payment instead of paying again",
+	"10417":                               "the buyer's funding source was declined; redirect the buyer back to PayPal with the same token to choose another one",
+	"10422":                               "the buyer has to choose a new funding source; redirect them back to PayPal with the same token",
+	"10445":                               "PayPal could not process the call at this time; retry later",
+	"10486":                               "the payment could not be completed; redirect the buyer back to PayPal with the same token so they can choose another funding source",
+	"10736":                               "PayPal could not validate the shipping address; ask the buyer to correct it",
+	ERROR_TOKEN_EXPIRED:                   "the checkout token expired (tokens last 3 hours); start a new checkout with SetExpressCheckout",
+	ERROR_INVALID_REFERENCE_ID:            "the billing agreement or reference transaction id is unknown or belongs to another account",
+	ERROR_REFERENCE_TRANSACTIONS_DISABLED: "reference transactions are not enabled on the account; ask PayPal to enable them",
+	ERROR_DUPLICATE_MSGSUBID:              "a call with this MSGSUBID already succeeded; treat it as completed and look up its result",
+}
+
+// Guidance on resolving the error, empty for codes without a known remedy
+func (e *PayPalError) Hint() string {
+	return errorHints[e.ErrorCode]
+}
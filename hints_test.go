@@ -0,0 +1,20 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"strings"
+	"testing"
+)
+
+func TestErrorHint(t *testing.T) {
+	if hint := (&paypal.PayPalError{ErrorCode: "10486"}).Hint(); !strings.Contains(hint, "same token") {
+		t.Errorf("Unexpected hint for 10486: %q", hint)
+	}
+	if hint := (&paypal.PayPalError{ErrorCode: paypal.ERROR_TOKEN_EXPIRED}).Hint(); !strings.Contains(hint, "SetExpressCheckout") {
+		t.Errorf("Unexpected hint for 10411: %q", hint)
+	}
+	if hint := (&paypal.PayPalError{ErrorCode: "99999"}).Hint(); len(hint) != 0 {
+		t.Errorf("Expected no hint for an unknown code, got %q", hint)
+	}
+}
@@ -0,0 +1,286 @@
+package paypal
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	IPN_SANDBOX_URL    = "https://ipnpb.sandbox.paypal.com/cgi-bin/webscr"
+	IPN_PRODUCTION_URL = "https://ipnpb.paypal.com/cgi-bin/webscr"
+)
+
+// Reasons an IPN message can be rejected with
+const (
+	IPN_REJECT_UNVERIFIED        = "unverified"
+	IPN_REJECT_RECEIVER          = "receiver_mismatch"
+	IPN_REJECT_STATUS_TRANSITION = "invalid_status_transition"
+	IPN_REJECT_DUPLICATE         = "duplicate"
+)
+
+// payment_status values a transaction may move to from a given status.
+// Statuses missing from the map are final.
+var ipnStatusTransitions = map[string][]string{
+	"Created":   {"Pending", "Processed", "Completed", "Denied", "Failed", "Expired", "Voided"},
+	"Pending":   {"Processed", "Completed", "Denied", "Failed", "Expired", "Voided", "Refunded", "Reversed"},
+	"Processed": {"Completed", "Denied", "Failed"},
+	"Completed": {"Refunded", "Reversed"},
+	"Reversed":  {"Canceled_Reversal"},
+}
+
+// Largest IPN body Process reads; PayPal's messages are a few kilobytes even
+// for carts with many items
+var MaxIPNBodySize int64 = 256 << 10
+
+// How long NewIPNListener's status store remembers a transaction. Refunds
+// and reversals can come months after the payment, so listeners seeing
+// them need a longer ttl or a persistent IPNStatusStore.
+const IPN_STATUS_TTL = 90 * 24 * time.Hour
+
+// Remembers the last payment_status of each txn_id, so an IPNListener can
+// reject messages moving a transaction back. Implementations must be safe
+// for concurrent use.
+type IPNStatusStore interface {
+	// Returns "" for transactions never seen
+	Status(txnId string) string
+	SetStatus(txnId, status string)
+}
+
+type MemoryIPNStatusStore struct {
+	ttl    time.Duration
+	mu     sync.Mutex
+	seen   map[string]ipnStatus
+	pruned time.Time
+}
+
+type ipnStatus struct {
+	status string
+	at     time.Time
+}
+
+// ttl controls how long a transaction's status is remembered after its
+// last message; 0 remembers statuses forever
+func NewMemoryIPNStatusStore(ttl time.Duration) *MemoryIPNStatusStore {
+	return &MemoryIPNStatusStore{ttl: ttl, seen: make(map[string]ipnStatus)}
+}
+
+func (s *MemoryIPNStatusStore) Status(txnId string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.seen[txnId]
+	if s.ttl > 0 && time.Since(entry.at) > s.ttl {
+		return ""
+	}
+	return entry.status
+}
+
+func (s *MemoryIPNStatusStore) SetStatus(txnId, status string) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// expired entries are swept at most every tenth of the ttl rather than
+	// on every message
+	if s.ttl > 0 && now.Sub(s.pruned) > s.ttl/10 {
+		for id, entry := range s.seen {
+			if now.Sub(entry.at) > s.ttl {
+				delete(s.seen, id)
+			}
+		}
+		s.pruned = now
+	}
+	s.seen[txnId] = ipnStatus{status, now}
+}
+
+type IPNRejection struct {
+	Reason string
+	Detail string
+}
+
+func (e *IPNRejection) Error() string {
+	return "PayPal IPN rejected (" + e.Reason + "): " + e.Detail
+}
+
+// Returned by Process when the notification's body could not be read or
+// parsed, e.g. with an *http.MaxBytesError for bodies over MaxIPNBodySize.
+// Unlike a failure to reach PayPal, retrying the same request won't help.
+type IPNBodyError struct {
+	Err error
+}
+
+func (e *IPNBodyError) Error() string {
+	return "PayPal IPN body unreadable: " + e.Err.Error()
+}
+
+func (e *IPNBodyError) Unwrap() error {
+	return e.Err
+}
+
+type IPNListener struct {
+	// Email of the PayPal account the payments must be made to
+	ReceiverEmail string
+	// Optional, used to drop redelivered messages
+	Deduplicator Deduplicator
+	// Where payment_status transitions are checked against; NewIPNListener
+	// sets a MemoryIPNStatusStore forgetting transactions after
+	// IPN_STATUS_TTL
+	Statuses IPNStatusStore
+	// Optional, overrides the sandbox/production verification URL, e.g.
+	// with an internal relay in front of PayPal
+	Endpoint string
+
+	usesSandbox bool
+	client      *http.Client
+
+	// makes the status check and update one step for the listener's
+	// messages
+	mu sync.Mutex
+}
+
+func NewIPNListener(receiverEmail string, usesSandbox bool, client *http.Client) *IPNListener {
+	return &IPNListener{
+		ReceiverEmail: receiverEmail,
+		usesSandbox:   usesSandbox,
+		client:        client,
+		Statuses:      NewMemoryIPNStatusStore(IPN_STATUS_TTL),
+	}
+}
+
+// Builds an http.Client sending its requests through an egress proxy, for
+// use with NewIPNListener or NewClient. tlsConfig may be nil
+func NewProxyHTTPClient(proxyUrl string, tlsConfig *tls.Config) (*http.Client, error) {
+	proxy, err := url.Parse(proxyUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &http.Transport{
+		Proxy:           http.ProxyURL(proxy),
+		TLSClientConfig: tlsConfig,
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// Verify posts the raw IPN body back to PayPal and returns an
+// *IPNRejection unless PayPal answers VERIFIED
+func (l *IPNListener) Verify(body []byte) error {
+	endpoint := IPN_PRODUCTION_URL
+	if l.usesSandbox {
+		endpoint = IPN_SANDBOX_URL
+	}
+	if len(l.Endpoint) != 0 {
+		endpoint = l.Endpoint
+	}
+
+	payload := append([]byte("cmd=_notify-validate&"), body...)
+	verifyResponse, err := l.client.Post(endpoint, "application/x-www-form-urlencoded", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer verifyResponse.Body.Close()
+
+	result, err := ioutil.ReadAll(verifyResponse.Body)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(string(result)) != "VERIFIED" {
+		return &IPNRejection{Reason: IPN_REJECT_UNVERIFIED, Detail: "PayPal answered " + strings.TrimSpace(string(result))}
+	}
+	return nil
+}
+
+// Process reads an IPN notification from r, verifies it with PayPal and
+// checks it is addressed to this account, follows a valid payment_status
+// transition and has not been processed before. Bodies over MaxIPNBodySize
+// are refused.
+func (l *IPNListener) Process(r *http.Request) (*IPNMessage, error) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, MaxIPNBodySize))
+	if err != nil {
+		return nil, &IPNBodyError{err}
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, &IPNBodyError{err}
+	}
+
+	message := ParseIPNMessage(values)
+
+	if err = l.Verify(body); err != nil {
+		return message, err
+	}
+
+	if err = l.checkReceiver(message); err != nil {
+		return message, err
+	}
+
+	txnId := message.TxnId
+	if len(txnId) == 0 {
+		return message, nil
+	}
+	status := message.PaymentStatus
+
+	// the transition is checked first so a message arriving before the
+	// status it follows isn't recorded, and is processed once redelivered
+	if err = l.transition(txnId, status); err != nil {
+		return message, err
+	}
+
+	if l.Deduplicator != nil && l.Deduplicator.Seen(txnId+"/"+status) {
+		return message, &IPNRejection{Reason: IPN_REJECT_DUPLICATE, Detail: "txn_id " + txnId + " already processed with payment_status " + status}
+	}
+
+	return message, nil
+}
+
+func (l *IPNListener) checkReceiver(message *IPNMessage) error {
+	if len(l.ReceiverEmail) == 0 {
+		return nil
+	}
+
+	receiver := message.ReceiverEmail
+	if len(receiver) == 0 {
+		receiver = message.Business
+	}
+	if !strings.EqualFold(receiver, l.ReceiverEmail) {
+		return &IPNRejection{Reason: IPN_REJECT_RECEIVER, Detail: "payment was made to " + receiver}
+	}
+	return nil
+}
+
+func (l *IPNListener) transition(txnId, status string) error {
+	// messages such as new_case carry the txn_id of a payment without a
+	// payment_status, and leave its status as it was
+	if len(status) == 0 || l.Statuses == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	previous := l.Statuses.Status(txnId)
+	if len(previous) != 0 && previous != status {
+		allowed := false
+		for _, next := range ipnStatusTransitions[previous] {
+			if next == status {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return &IPNRejection{Reason: IPN_REJECT_STATUS_TRANSITION, Detail: "txn_id " + txnId + " cannot move from " + previous + " to " + status}
+		}
+	}
+
+	l.Statuses.SetStatus(txnId, status)
+	return nil
+}
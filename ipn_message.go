@@ -0,0 +1,254 @@
+package paypal
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IPN timestamps look like "08:06:29 Mar 11, 2016 PDT"
+const IPN_TIME_LAYOUT = "15:04:05 Jan 2, 2006"
+
+var ipnTimeZones = map[string]*time.Location{
+	"PST": time.FixedZone("PST", -8*60*60),
+	"PDT": time.FixedZone("PDT", -7*60*60),
+}
+
+// txn_type values of the notifications about recurring payments profiles
+const (
+	IPN_RECURRING_PAYMENT            = "recurring_payment"
+	IPN_RECURRING_PAYMENT_FAILED     = "recurring_payment_failed"
+	IPN_RECURRING_PAYMENT_SKIPPED    = "recurring_payment_skipped"
+	IPN_RECURRING_PAYMENT_EXPIRED    = "recurring_payment_expired"
+	IPN_RECURRING_PAYMENT_SUSPENDED  = "recurring_payment_suspended"
+	IPN_RECURRING_PROFILE_CREATED    = "recurring_payment_profile_created"
+	IPN_RECURRING_PROFILE_CANCELLED  = "recurring_payment_profile_cancel"
+	IPN_RECURRING_SUSPENDED_MAX_FAIL = "recurring_payment_suspended_due_to_max_failed_payment"
+	IPN_RECURRING_OUTSTANDING_PAID   = "recurring_payment_outstanding_payment"
+	IPN_RECURRING_OUTSTANDING_FAILED = "recurring_payment_outstanding_payment_failed"
+)
+
+type IPNItem struct {
+	Name     string
+	Number   string
+	Quantity int
+	Gross    float64
+}
+
+type IPNCase struct {
+	CaseId       string
+	CaseType     string
+	CreationDate time.Time
+}
+
+type IPNSubscription struct {
+	SubscrId             string
+	SubscrDate           time.Time
+	RecurringPaymentId   string
+	ProfileStatus        string
+	ProductName          string
+	Period1              string
+	Period2              string
+	Period3              string
+	Amount1              float64
+	Amount2              float64
+	Amount3              float64
+	AmountPerCycle       float64
+	InitialPaymentAmount float64
+	OutstandingBalance   float64
+	NextPaymentDate      time.Time
+	TimeCreated          time.Time
+	RpInvoiceId          string
+	// "Regular" or "Trial" for the cycle a recurring payment was made for
+	PeriodType string
+	// e.g. "Monthly"
+	PaymentCycle string
+}
+
+type IPNMassPayItem struct {
+	TxnId         string
+	ReceiverEmail string
+	UniqueId      string
+	Status        string
+	Gross         float64
+	Fee           float64
+	Currency      string
+}
+
+type IPNMessage struct {
+	TxnId         string
+	TxnType       string
+	ParentTxnId   string
+	PaymentStatus string
+	PendingReason string
+	ReasonCode    string
+	PaymentType   string
+	PaymentDate   time.Time
+
+	Gross    float64
+	Fee      float64
+	Shipping float64
+	Handling float64
+	Tax      float64
+	Currency string
+
+	ReceiverEmail string
+	ReceiverId    string
+	Business      string
+
+	PayerEmail        string
+	PayerId           string
+	PayerStatus       PayerStatus
+	PayerBusinessName string
+	FirstName         string
+	LastName          string
+	ResidenceCountry  string
+
+	// The buyer's shipping address, with its confirmation status
+	Address Address
+
+	Invoice string
+	Custom  string
+	Items   []IPNItem
+
+	Case         IPNCase
+	Subscription IPNSubscription
+	MassPay      []IPNMassPayItem
+
+	Test       bool
+	IpnTrackId string
+
+	// The notification exactly as PayPal posted it
+	Values url.Values
+}
+
+// Reports whether the notification is about a recurring payments profile,
+// in which case Subscription carries the profile's state
+func (message *IPNMessage) IsRecurring() bool {
+	return strings.HasPrefix(message.TxnType, "recurring_payment")
+}
+
+func parseIPNTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	location := time.UTC
+	if i := strings.LastIndex(value, " "); i > 0 {
+		if zone, ok := ipnTimeZones[value[i+1:]]; ok {
+			location = zone
+			value = value[:i]
+		}
+	}
+	parsed, _ := time.ParseInLocation(IPN_TIME_LAYOUT, value, location)
+	return parsed
+}
+
+func parseIPNAmount(value string) float64 {
+	amount, _ := strconv.ParseFloat(value, 64)
+	return amount
+}
+
+func ParseIPNMessage(values url.Values) *IPNMessage {
+	message := &IPNMessage{Values: values}
+
+	message.TxnId = values.Get("txn_id")
+	message.TxnType = values.Get("txn_type")
+	message.ParentTxnId = values.Get("parent_txn_id")
+	message.PaymentStatus = values.Get("payment_status")
+	message.PendingReason = values.Get("pending_reason")
+	message.ReasonCode = values.Get("reason_code")
+	message.PaymentType = values.Get("payment_type")
+	message.PaymentDate = parseIPNTime(values.Get("payment_date"))
+
+	message.Gross = parseIPNAmount(values.Get("mc_gross"))
+	message.Fee = parseIPNAmount(values.Get("mc_fee"))
+	message.Shipping = parseIPNAmount(values.Get("mc_shipping"))
+	message.Handling = parseIPNAmount(values.Get("mc_handling"))
+	message.Tax = parseIPNAmount(values.Get("tax"))
+	message.Currency = values.Get("mc_currency")
+
+	message.ReceiverEmail = values.Get("receiver_email")
+	message.ReceiverId = values.Get("receiver_id")
+	message.Business = values.Get("business")
+
+	message.PayerEmail = values.Get("payer_email")
+	message.PayerId = values.Get("payer_id")
+	message.PayerStatus = parsePayerStatus(values.Get("payer_status"))
+	message.PayerBusinessName = values.Get("payer_business_name")
+	message.FirstName = values.Get("first_name")
+	message.LastName = values.Get("last_name")
+	message.ResidenceCountry = values.Get("residence_country")
+
+	message.Address = AddressFromIPN(values)
+
+	message.Invoice = values.Get("invoice")
+	message.Custom = values.Get("custom")
+
+	message.Case.CaseId = values.Get("case_id")
+	message.Case.CaseType = values.Get("case_type")
+	message.Case.CreationDate = parseIPNTime(values.Get("case_creation_date"))
+
+	subscription := &message.Subscription
+	subscription.SubscrId = values.Get("subscr_id")
+	subscription.SubscrDate = parseIPNTime(values.Get("subscr_date"))
+	subscription.RecurringPaymentId = values.Get("recurring_payment_id")
+	subscription.ProfileStatus = values.Get("profile_status")
+	subscription.ProductName = values.Get("product_name")
+	subscription.Period1 = values.Get("period1")
+	subscription.Period2 = values.Get("period2")
+	subscription.Period3 = values.Get("period3")
+	subscription.Amount1 = parseIPNAmount(values.Get("mc_amount1"))
+	subscription.Amount2 = parseIPNAmount(values.Get("mc_amount2"))
+	subscription.Amount3 = parseIPNAmount(values.Get("mc_amount3"))
+	subscription.AmountPerCycle = parseIPNAmount(values.Get("amount_per_cycle"))
+	subscription.InitialPaymentAmount = parseIPNAmount(values.Get("initial_payment_amount"))
+	subscription.OutstandingBalance = parseIPNAmount(values.Get("outstanding_balance"))
+	subscription.NextPaymentDate = parseIPNTime(values.Get("next_payment_date"))
+	subscription.TimeCreated = parseIPNTime(values.Get("time_created"))
+	subscription.RpInvoiceId = values.Get("rp_invoice_id")
+	subscription.PeriodType = strings.TrimSpace(values.Get("period_type"))
+	subscription.PaymentCycle = values.Get("payment_cycle")
+
+	message.Test = values.Get("test_ipn") == "1"
+	message.IpnTrackId = values.Get("ipn_track_id")
+
+	// mass pay and cart notifications both use numbered mc_gross_n fields
+	if message.TxnType == "masspay" {
+		for i := 1; len(values.Get(fmt.Sprintf("masspay_txn_id_%d", i))) != 0; i++ {
+			message.MassPay = append(message.MassPay, IPNMassPayItem{
+				TxnId:         values.Get(fmt.Sprintf("masspay_txn_id_%d", i)),
+				ReceiverEmail: values.Get(fmt.Sprintf("receiver_email_%d", i)),
+				UniqueId:      values.Get(fmt.Sprintf("unique_id_%d", i)),
+				Status:        values.Get(fmt.Sprintf("status_%d", i)),
+				Gross:         parseIPNAmount(values.Get(fmt.Sprintf("mc_gross_%d", i))),
+				Fee:           parseIPNAmount(values.Get(fmt.Sprintf("mc_fee_%d", i))),
+				Currency:      values.Get(fmt.Sprintf("mc_currency_%d", i)),
+			})
+		}
+	} else if itemCount, _ := strconv.Atoi(values.Get("num_cart_items")); itemCount > 0 {
+		// every item has at least one field, so a forged num_cart_items
+		// can't make us build millions of them
+		if itemCount > len(values) {
+			itemCount = len(values)
+		}
+		for i := 1; i <= itemCount; i++ {
+			quantity, _ := strconv.Atoi(values.Get(fmt.Sprintf("quantity%d", i)))
+			message.Items = append(message.Items, IPNItem{
+				Name:     values.Get(fmt.Sprintf("item_name%d", i)),
+				Number:   values.Get(fmt.Sprintf("item_number%d", i)),
+				Quantity: quantity,
+				Gross:    parseIPNAmount(values.Get(fmt.Sprintf("mc_gross_%d", i))),
+			})
+		}
+	} else if len(values.Get("item_name")) != 0 {
+		quantity, _ := strconv.Atoi(values.Get("quantity"))
+		message.Items = append(message.Items, IPNItem{
+			Name:     values.Get("item_name"),
+			Number:   values.Get("item_number"),
+			Quantity: quantity,
+			Gross:    message.Gross,
+		})
+	}
+
+	return message
+}
@@ -0,0 +1,256 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type ipnAnswerTransport string
+
+func (answer ipnAnswerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(string(answer))),
+		Header:     make(http.Header),
+		Request:    r,
+	}, nil
+}
+
+func newIPNRequest(body string) *http.Request {
+	r := httptest.NewRequest("POST", "/ipn", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func expectIPNRejection(t *testing.T, err error, reason string) {
+	rejection, ok := err.(*paypal.IPNRejection)
+	if !ok {
+		t.Fatalf("Expected an *IPNRejection with reason %s, got %#v", reason, err)
+	}
+	if rejection.Reason != reason {
+		t.Errorf("Expected rejection reason %s, got %s (%s)", reason, rejection.Reason, rejection.Detail)
+	}
+}
+
+func TestIPNListenerAcceptsVerifiedMessage(t *testing.T) {
+	listener := paypal.NewIPNListener("seller@example.com", true, &http.Client{Transport: ipnAnswerTransport("VERIFIED")})
+
+	message, err := listener.Process(newIPNRequest("txn_id=1&payment_status=Completed&receiver_email=SELLER%40example.com"))
+	if err != nil {
+		t.Fatalf("Unexpected error processing IPN: %#v", err)
+	}
+	if message.TxnId != "1" {
+		t.Errorf("Expected txn_id 1, got %#v", message)
+	}
+}
+
+func TestIPNListenerRejectsInvalidMessage(t *testing.T) {
+	listener := paypal.NewIPNListener("seller@example.com", true, &http.Client{Transport: ipnAnswerTransport("INVALID")})
+
+	_, err := listener.Process(newIPNRequest("txn_id=1&payment_status=Completed&receiver_email=seller%40example.com"))
+	expectIPNRejection(t, err, paypal.IPN_REJECT_UNVERIFIED)
+}
+
+func TestIPNListenerRejectsForeignReceiver(t *testing.T) {
+	listener := paypal.NewIPNListener("seller@example.com", true, &http.Client{Transport: ipnAnswerTransport("VERIFIED")})
+
+	_, err := listener.Process(newIPNRequest("txn_id=1&payment_status=Completed&receiver_email=attacker%40example.com"))
+	expectIPNRejection(t, err, paypal.IPN_REJECT_RECEIVER)
+}
+
+func TestIPNListenerRejectsReplaysAndBadTransitions(t *testing.T) {
+	listener := paypal.NewIPNListener("seller@example.com", true, &http.Client{Transport: ipnAnswerTransport("VERIFIED")})
+	listener.Deduplicator = paypal.NewMemoryDeduplicator(0)
+
+	if _, err := listener.Process(newIPNRequest("txn_id=1&payment_status=Refunded&business=seller%40example.com")); err != nil {
+		t.Fatalf("Unexpected error processing IPN: %#v", err)
+	}
+
+	_, err := listener.Process(newIPNRequest("txn_id=1&payment_status=Refunded&business=seller%40example.com"))
+	expectIPNRejection(t, err, paypal.IPN_REJECT_DUPLICATE)
+
+	_, err = listener.Process(newIPNRequest("txn_id=1&payment_status=Completed&business=seller%40example.com"))
+	expectIPNRejection(t, err, paypal.IPN_REJECT_STATUS_TRANSITION)
+}
+
+func TestIPNListenerOutOfOrderDelivery(t *testing.T) {
+	listener := paypal.NewIPNListener("seller@example.com", true, &http.Client{Transport: ipnAnswerTransport("VERIFIED")})
+	listener.Deduplicator = paypal.NewMemoryDeduplicator(0)
+
+	if _, err := listener.Process(newIPNRequest("txn_id=1&payment_status=Pending&business=seller%40example.com")); err != nil {
+		t.Fatalf("Unexpected error processing IPN: %#v", err)
+	}
+	// Canceled_Reversal overtakes the Reversed it follows
+	_, err := listener.Process(newIPNRequest("txn_id=1&payment_status=Canceled_Reversal&business=seller%40example.com"))
+	expectIPNRejection(t, err, paypal.IPN_REJECT_STATUS_TRANSITION)
+
+	if _, err := listener.Process(newIPNRequest("txn_id=1&payment_status=Reversed&business=seller%40example.com")); err != nil {
+		t.Fatalf("Unexpected error processing IPN: %#v", err)
+	}
+	if _, err := listener.Process(newIPNRequest("txn_id=1&payment_status=Canceled_Reversal&business=seller%40example.com")); err != nil {
+		t.Errorf("Expected the redelivered Canceled_Reversal to be processed, got %#v", err)
+	}
+}
+
+func TestParseIPNMessage(t *testing.T) {
+	values, _ := url.ParseQuery("txn_id=61E67681CH3238416&txn_type=cart&payment_status=Completed&mc_gross=19.95&mc_fee=0.88&mc_currency=USD" +
+		"&payment_date=20%3A12%3A59+Jan+13%2C+2009+PST&num_cart_items=2" +
+		"&item_name1=Widget&quantity1=1&mc_gross_1=9.95&item_name2=Gadget&quantity2=2&mc_gross_2=10.00&test_ipn=1")
+
+	message := paypal.ParseIPNMessage(values)
+
+	if message.Gross != 19.95 || message.Fee != 0.88 || message.Currency != "USD" {
+		t.Errorf("Amounts not decoded: %#v", message)
+	}
+	expectedDate := time.Date(2009, time.January, 14, 4, 12, 59, 0, time.UTC)
+	if !message.PaymentDate.Equal(expectedDate) {
+		t.Errorf("Expected payment_date %s, got %s", expectedDate, message.PaymentDate)
+	}
+	if len(message.Items) != 2 || message.Items[1].Name != "Gadget" || message.Items[1].Quantity != 2 || message.Items[1].Gross != 10 {
+		t.Errorf("Cart items not decoded: %#v", message.Items)
+	}
+	if !message.Test {
+		t.Errorf("test_ipn=1 not decoded")
+	}
+	if message.Values.Get("item_name1") != "Widget" {
+		t.Errorf("Original values not kept on the message")
+	}
+}
+
+func TestParseIPNMessageMassPay(t *testing.T) {
+	values, _ := url.ParseQuery("txn_type=masspay&payment_status=Processed" +
+		"&masspay_txn_id_1=A1&receiver_email_1=a%40example.com&mc_gross_1=5.00&status_1=Completed" +
+		"&masspay_txn_id_2=B2&receiver_email_2=b%40example.com&mc_gross_2=7.50&status_2=Unclaimed")
+
+	message := paypal.ParseIPNMessage(values)
+
+	if len(message.MassPay) != 2 || message.MassPay[1].TxnId != "B2" || message.MassPay[1].Gross != 7.5 || message.MassPay[1].Status != "Unclaimed" {
+		t.Errorf("Mass pay items not decoded: %#v", message.MassPay)
+	}
+	if len(message.Items) != 0 {
+		t.Errorf("Mass pay amounts decoded as cart items: %#v", message.Items)
+	}
+}
+
+func TestParseIPNMessageRecurringPayment(t *testing.T) {
+	values, _ := url.ParseQuery("txn_type=recurring_payment&txn_id=8RT51812D3056610N&payment_status=Completed&mc_gross=9.99" +
+		"&recurring_payment_id=I-BZ9H2TKW4B3S&rp_invoice_id=sub_8a3f&product_name=Monthly+plan&period_type=+Regular" +
+		"&payment_cycle=Monthly&amount_per_cycle=9.99&outstanding_balance=0.00" +
+		"&next_payment_date=03%3A00%3A00+Apr+01%2C+2016+PDT")
+
+	message := paypal.ParseIPNMessage(values)
+
+	if !message.IsRecurring() || message.TxnType != paypal.IPN_RECURRING_PAYMENT {
+		t.Errorf("Expected a recurring payment, got %q", message.TxnType)
+	}
+	subscription := message.Subscription
+	if subscription.RecurringPaymentId != "I-BZ9H2TKW4B3S" || subscription.PeriodType != "Regular" || subscription.PaymentCycle != "Monthly" ||
+		subscription.AmountPerCycle != 9.99 || subscription.NextPaymentDate.Month() != time.April || message.ProfileReference() != "sub_8a3f" {
+		t.Errorf("Subscription not decoded: %#v", subscription)
+	}
+}
+
+func TestIPNListenerEndpointOverride(t *testing.T) {
+	var postback string
+	relay := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		postback = string(body)
+		w.Write([]byte("VERIFIED"))
+	}))
+	defer relay.Close()
+
+	listener := paypal.NewIPNListener("", false, relay.Client())
+	listener.Endpoint = relay.URL
+
+	if _, err := listener.Process(newIPNRequest("txn_id=1&payment_status=Completed")); err != nil {
+		t.Fatalf("Unexpected error processing IPN: %#v", err)
+	}
+	if postback != "cmd=_notify-validate&txn_id=1&payment_status=Completed" {
+		t.Errorf("Unexpected postback body sent to the endpoint override: %s", postback)
+	}
+}
+
+func TestNewProxyHTTPClient(t *testing.T) {
+	client, err := paypal.NewProxyHTTPClient("http://proxy.internal:3128", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error building proxy client: %#v", err)
+	}
+
+	proxy, _ := client.Transport.(*http.Transport).Proxy(httptest.NewRequest("POST", paypal.IPN_PRODUCTION_URL, nil))
+	if proxy == nil || proxy.Host != "proxy.internal:3128" {
+		t.Errorf("Expected requests to go through proxy.internal:3128, got %#v", proxy)
+	}
+}
+
+var ipnSeeds = []string{
+	"txn_id=TX1&txn_type=cart&payment_status=Completed&mc_gross=24.98&mc_currency=USD&num_cart_items=2&item_name1=Mug&quantity1=2&mc_gross_1=19.98&item_name2=Shipping&mc_gross_2=5.00&payment_date=08%3A06%3A29+Mar+11%2C+2016+PDT",
+	"txn_type=masspay&masspay_txn_id_1=MP1&receiver_email_1=a%40example.com&mc_gross_1=5.00&status_1=Completed",
+	"txn_type=subscr_signup&subscr_id=S-1&mc_amount3=9.99&period3=1+M&subscr_date=10%3A00%3A00+Jan+1%2C+2017+PST",
+	"address_name=Jane+Doe&address_street=1+Main+St&address_country_code=US&address_status=confirmed&payer_status=verified",
+	"num_cart_items=-1&payment_date=+PDT&mc_gross=NaN",
+	"num_cart_items=1000000000",
+}
+
+// IPN bodies come from anyone who can reach the notification URL, so
+// parsing them must never panic or hang
+func FuzzParseIPNMessage(f *testing.F) {
+	for _, seed := range ipnSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, body string) {
+		values, err := url.ParseQuery(body)
+		if err != nil {
+			return
+		}
+		message := paypal.ParseIPNMessage(values)
+		if len(message.Items) > len(values) {
+			t.Errorf("%d items parsed from %d fields", len(message.Items), len(values))
+		}
+	})
+}
+
+func FuzzIPNListener(f *testing.F) {
+	for _, seed := range ipnSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, body string) {
+		listener := paypal.NewIPNListener("seller@example.com", true, &http.Client{Transport: ipnAnswerTransport("VERIFIED")})
+		listener.Process(newIPNRequest(body))
+	})
+}
+
+func TestIPNListenerStatusChecks(t *testing.T) {
+	listener := paypal.NewIPNListener("seller@example.com", true, &http.Client{Transport: ipnAnswerTransport("VERIFIED")})
+
+	if _, err := listener.Process(newIPNRequest("txn_id=1&payment_status=Completed&business=seller%40example.com")); err != nil {
+		t.Fatalf("Unexpected error processing IPN: %#v", err)
+	}
+	if _, err := listener.Process(newIPNRequest("txn_type=new_case&txn_id=1&case_type=dispute&business=seller%40example.com")); err != nil {
+		t.Errorf("Expected a message without payment_status to be accepted, got %#v", err)
+	}
+	if _, err := listener.Process(newIPNRequest("txn_id=1&payment_status=Refunded&business=seller%40example.com")); err != nil {
+		t.Errorf("Expected Completed to still move to Refunded, got %#v", err)
+	}
+
+	large := "txn_id=2&payment_status=Completed&business=seller%40example.com&memo=" + strings.Repeat("x", int(paypal.MaxIPNBodySize))
+	if _, err := listener.Process(newIPNRequest(large)); err == nil {
+		t.Errorf("Expected a body over MaxIPNBodySize to be refused")
+	}
+	if status := listener.Statuses.Status("2"); status != "" {
+		t.Errorf("Refused message recorded status %s", status)
+	}
+
+	statuses := paypal.NewMemoryIPNStatusStore(time.Millisecond)
+	statuses.SetStatus("3", "Completed")
+	time.Sleep(5 * time.Millisecond)
+	if status := statuses.Status("3"); status != "" {
+		t.Errorf("Expected the status to expire after the ttl, got %s", status)
+	}
+}
@@ -0,0 +1,42 @@
+package paypal
+
+import "os"
+
+// Set to 1 to confirm live calls for every client requiring confirmation,
+// e.g. in the production deploy's environment
+const CONFIRM_LIVE_ENV = "PAYPAL_CONFIRM_LIVE"
+
+// Returned before calling the production API with a call that could move
+// money, on a client requiring confirmation that never got it
+type LiveNotConfirmedError struct {
+	Method string
+}
+
+func (e *LiveNotConfirmedError) Error() string {
+	return "PayPal " + e.Method + " refused: the client points at the live API and live calls were not confirmed with ConfirmLive or " + CONFIRM_LIVE_ENV + "=1"
+}
+
+// Guards against test code or a staging config charging real money with
+// live credentials: once required, calls other than read-only lookups fail
+// with a *LiveNotConfirmedError against the production endpoint until
+// ConfirmLive is called or CONFIRM_LIVE_ENV is set. Sandbox clients are
+// never affected.
+func (pClient *PayPalClient) SetRequireLiveConfirmation(require bool) {
+	pClient.requireLiveConfirmation = require
+}
+
+// Allows calls changing money on the live API. Clients made from this one
+// with With keep the confirmation unless WithEnvironment is applied.
+func (pClient *PayPalClient) ConfirmLive() {
+	pClient.liveConfirmed = true
+}
+
+func (pClient *PayPalClient) checkLive(method string) error {
+	if !pClient.requireLiveConfirmation || pClient.usesSandbox || pClient.liveConfirmed || idempotentMethods[method] {
+		return nil
+	}
+	if os.Getenv(CONFIRM_LIVE_ENV) == "1" {
+		return nil
+	}
+	return &LiveNotConfirmedError{method}
+}
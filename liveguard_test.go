@@ -0,0 +1,77 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestLiveConfirmation(t *testing.T) {
+	calls := 0
+	transport := nvpTransport(func(values url.Values) string {
+		calls++
+		return "ACK=Success"
+	})
+	refund := paypal.PayPalRefund{TransactionId: "8RT51812D3056610N", RefundType: paypal.REFUND_TYPE_FULL}
+
+	live := paypal.NewClient("user", "pass", "sig", false, &http.Client{Transport: transport})
+	live.SetRequireLiveConfirmation(true)
+	if _, err := live.RefundTransaction(refund); err == nil {
+		t.Errorf("Expected an unconfirmed live refund to be refused")
+	} else if _, ok := err.(*paypal.LiveNotConfirmedError); !ok {
+		t.Errorf("Expected a *LiveNotConfirmedError, got %v", err)
+	}
+	if _, err := live.GetTransactionDetails("8RT51812D3056610N"); err != nil {
+		t.Errorf("Expected read-only calls to go through, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected only the lookup to reach PayPal, got %d calls", calls)
+	}
+
+	os.Setenv(paypal.CONFIRM_LIVE_ENV, "1")
+	_, err := live.RefundTransaction(refund)
+	os.Unsetenv(paypal.CONFIRM_LIVE_ENV)
+	if err != nil {
+		t.Errorf("Expected %s=1 to confirm live calls, got %v", paypal.CONFIRM_LIVE_ENV, err)
+	}
+
+	live.ConfirmLive()
+	if _, err := live.RefundTransaction(refund); err != nil {
+		t.Errorf("Expected a confirmed client to refund, got %v", err)
+	}
+	if _, err := live.With(paypal.WithEnvironment(true)).With(paypal.WithEnvironment(false)).RefundTransaction(refund); err == nil {
+		t.Errorf("Expected switching environments to drop the confirmation")
+	}
+
+	sandbox := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: transport})
+	sandbox.SetRequireLiveConfirmation(true)
+	if _, err := sandbox.RefundTransaction(refund); err != nil {
+		t.Errorf("Expected sandbox calls to be unaffected, got %v", err)
+	}
+}
+
+func TestLiveConfirmationAdaptive(t *testing.T) {
+	transport := &adaptiveTransport{body: "responseEnvelope.ack=Success&payKey=AP-4"}
+	live := paypal.NewClient("user", "pass", "sig", false, &http.Client{Transport: transport})
+	live.SetRequireLiveConfirmation(true)
+	adaptive := paypal.NewAdaptiveClient(live, "APP-LIVE")
+
+	pay := paypal.AdaptivePay{CurrencyCode: "USD", Receivers: []paypal.AdaptiveReceiver{{Email: "seller@example.com", Amount: 10}}}
+	if _, err := adaptive.Pay(pay); err == nil {
+		t.Errorf("Expected an unconfirmed live Pay to be refused")
+	} else if _, ok := err.(*paypal.LiveNotConfirmedError); !ok {
+		t.Errorf("Expected a *LiveNotConfirmedError, got %v", err)
+	}
+	if _, err := adaptive.Refund("AP-4", "USD", nil); err == nil {
+		t.Errorf("Expected an unconfirmed live Refund to be refused")
+	}
+	if transport.request != nil {
+		t.Errorf("Unconfirmed live Adaptive call sent to PayPal")
+	}
+	if _, err := adaptive.PaymentDetails("AP-4"); err != nil {
+		t.Errorf("Expected PaymentDetails to go through, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package paypal
+
+// Sets up a "Mark" checkout, where the buyer picks PayPal on the merchant's
+// own payment method page after the order (shipping, totals) is final.
+// Unlike the shortcut flow started by SetExpressCheckout there is no review
+// page after PayPal:
+//
+//  1. SetExpressCheckoutMark, redirect the buyer to response.CheckoutUrl().
+//     The URL carries useraction=commit so PayPal shows "Pay Now".
+//  2. On the return URL, call DoExpressCheckoutOrderPayment with the token
+//     and PayerID PayPal appended, without asking the buyer to confirm again.
+//
+// Buyers without a PayPal account land on the card form unless
+// order.SolutionType is SOLUTION_TYPE_MARK.
+func (pClient *PayPalClient) SetExpressCheckoutMark(order PayPalOrder, goods []PayPalGood, opts ...CallOption) (*PayPalResponse, error) {
+	values, err := setExpressCheckoutValues(order, goods)
+	if err != nil {
+		return nil, err
+	}
+	if order.SolutionType != SOLUTION_TYPE_MARK {
+		values.Add("LANDINGPAGE", "Billing")
+	}
+
+	response, err := pClient.PerformRequest(values, opts...)
+	if response != nil {
+		response.userAction = "commit"
+	}
+	if err == nil {
+		err = pClient.saveOrder(response, order, goods)
+	}
+	return response, err
+}
@@ -0,0 +1,34 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestSetExpressCheckoutMark(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success&TOKEN=EC-1"
+	})})
+
+	response, err := client.SetExpressCheckoutMark(paypal.PayPalOrder{SubTotal: 10, Total: 10, CurrencyCode: "USD"}, []paypal.PayPalGood{{Name: "Mug", Amount: 10, Quantity: 1}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+
+	if sent.Get("SOLUTIONTYPE") != "Sole" || sent.Get("LANDINGPAGE") != "Billing" {
+		t.Errorf("Unexpected Mark flow flags: %#v", sent)
+	}
+	if response.CheckoutUrl() != paypal.CHECKOUT_SANDBOX_URL+"?cmd=_express-checkout&token=EC-1&useraction=commit" {
+		t.Errorf("Expected a commit checkout URL, got %s", response.CheckoutUrl())
+	}
+
+	shortcut, _ := client.SetExpressCheckout(paypal.PayPalOrder{CurrencyCode: "USD"}, nil)
+	if shortcut.CheckoutUrl() != paypal.CHECKOUT_SANDBOX_URL+"?cmd=_express-checkout&token=EC-1" {
+		t.Errorf("Shortcut checkout URL changed: %s", shortcut.CheckoutUrl())
+	}
+}
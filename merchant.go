@@ -0,0 +1,42 @@
+package paypal
+
+// Returned before calling PayPal when a MerchantScopedClient is asked to
+// call an API the seller has not granted permission for
+type PermissionError struct {
+	Subject string
+	Method  string
+}
+
+func (e *PermissionError) Error() string {
+	return "PayPal account " + e.Subject + " has not granted permission to call " + e.Method
+}
+
+// A client acting for one seller of a platform: every call carries the
+// seller's SUBJECT, and only the APIs the seller granted third-party
+// permission for are called.
+type MerchantScopedClient struct {
+	*PayPalClient
+	Subject string
+}
+
+// grantedMethods are the NVP METHOD names covered by the seller's
+// permission grant, e.g. "SetExpressCheckout" and "RefundTransaction", and
+// the Adaptive operations, e.g. "Pay", when an AdaptiveClient wraps it
+func NewMerchantScopedClient(client *PayPalClient, subject string, grantedMethods []string) *MerchantScopedClient {
+	granted := make(map[string]bool, len(grantedMethods))
+	for _, method := range grantedMethods {
+		granted[method] = true
+	}
+
+	scoped := client.With(WithDefaultSubject(subject), func(pClient *PayPalClient) {
+		pClient.grantedMethods = granted
+	})
+	return &MerchantScopedClient{PayPalClient: scoped, Subject: subject}
+}
+
+func (pClient *PayPalClient) checkGranted(method string) error {
+	if pClient.grantedMethods == nil || pClient.grantedMethods[method] {
+		return nil
+	}
+	return &PermissionError{Subject: pClient.subject, Method: method}
+}
@@ -0,0 +1,36 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestMerchantScopedClient(t *testing.T) {
+	var sent []url.Values
+	client := paypal.NewClient("platform", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = append(sent, values)
+		return "ACK=Success&TOKEN=EC-1"
+	})})
+
+	seller := paypal.NewMerchantScopedClient(client, "seller@example.com", []string{"SetExpressCheckout", "GetExpressCheckoutDetails"})
+
+	if _, err := seller.SetExpressCheckout(paypal.PayPalOrder{CurrencyCode: "USD"}, nil); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if _, err := seller.RefundTransaction(paypal.PayPalRefund{TransactionId: "TXN", RefundType: paypal.REFUND_TYPE_FULL}); err == nil {
+		t.Errorf("Expected a *PermissionError for a method outside the grant")
+	} else if permissionErr, ok := err.(*paypal.PermissionError); !ok || permissionErr.Method != "RefundTransaction" {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+	client.RefundTransaction(paypal.PayPalRefund{TransactionId: "TXN", RefundType: paypal.REFUND_TYPE_FULL})
+
+	if len(sent) != 2 {
+		t.Fatalf("Expected 2 calls to reach PayPal, got %d", len(sent))
+	}
+	if sent[0].Get("SUBJECT") != "seller@example.com" || len(sent[1].Get("SUBJECT")) != 0 {
+		t.Errorf("Unexpected subjects: %#v", sent)
+	}
+}
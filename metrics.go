@@ -0,0 +1,152 @@
+package paypal
+
+import (
+	"sync"
+	"time"
+)
+
+// Environment labels reported to Metrics
+const (
+	ENVIRONMENT_SANDBOX    = "sandbox"
+	ENVIRONMENT_PRODUCTION = "production"
+)
+
+// Upper bounds of the MemoryMetrics latency buckets
+var DefaultLatencyBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Receives the client's instrumentation; adapt it to Prometheus, statsd etc.
+type Metrics interface {
+	// Called once for every PayPal error code returned by a call
+	IncErrorCode(method, errorCode string)
+	// Called after every round trip to PayPal, including failed ones
+	ObserveLatency(method, environment string, duration time.Duration)
+}
+
+// Called with the failed call's response and error
+type ErrorCodeHook func(response *PayPalResponse, err *PayPalError)
+
+type LatencyHistogram struct {
+	// Upper bounds of the buckets; Counts has one extra entry for slower calls
+	Buckets []time.Duration
+	Counts  []int64
+	Count   int64
+	Sum     time.Duration
+}
+
+// Metrics implementation keeping the counters in memory
+type MemoryMetrics struct {
+	mu        sync.Mutex
+	errors    map[string]int64
+	latencies map[string]*LatencyHistogram
+}
+
+func NewMemoryMetrics() *MemoryMetrics {
+	return &MemoryMetrics{errors: make(map[string]int64), latencies: make(map[string]*LatencyHistogram)}
+}
+
+func (m *MemoryMetrics) IncErrorCode(method, errorCode string) {
+	m.mu.Lock()
+	m.errors[errorCode]++
+	m.mu.Unlock()
+}
+
+func (m *MemoryMetrics) ObserveLatency(method, environment string, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := method + "/" + environment
+	histogram, ok := m.latencies[key]
+	if !ok {
+		histogram = &LatencyHistogram{Buckets: DefaultLatencyBuckets, Counts: make([]int64, len(DefaultLatencyBuckets)+1)}
+		m.latencies[key] = histogram
+	}
+
+	bucket := len(histogram.Buckets)
+	for i, bound := range histogram.Buckets {
+		if duration <= bound {
+			bucket = i
+			break
+		}
+	}
+	histogram.Counts[bucket]++
+	histogram.Count++
+	histogram.Sum += duration
+}
+
+// Snapshot of the error counts keyed by error code
+func (m *MemoryMetrics) ErrorCounts() map[string]int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counts := make(map[string]int64, len(m.errors))
+	for code, count := range m.errors {
+		counts[code] = count
+	}
+	return counts
+}
+
+// Snapshot of the latency histogram for method in environment
+func (m *MemoryMetrics) Latency(method, environment string) LatencyHistogram {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	histogram, ok := m.latencies[method+"/"+environment]
+	if !ok {
+		return LatencyHistogram{}
+	}
+	snapshot := *histogram
+	snapshot.Counts = append([]int64(nil), histogram.Counts...)
+	return snapshot
+}
+
+func (pClient *PayPalClient) SetMetrics(metrics Metrics) {
+	pClient.metrics = metrics
+}
+
+// Registers hook to run whenever a call fails with errorCode, e.g. to alert
+// on a spike of 10486 or 10417 responses. Register hooks before the client
+// is used.
+func (pClient *PayPalClient) OnErrorCode(errorCode string, hook ErrorCodeHook) {
+	// the hooks are copied rather than appended to in place, so clients
+	// made by With never see each other's hooks
+	hooks := make(map[string][]ErrorCodeHook, len(pClient.errorHooks)+1)
+	for code, codeHooks := range pClient.errorHooks {
+		hooks[code] = codeHooks
+	}
+	hooks[errorCode] = append(append([]ErrorCodeHook(nil), pClient.errorHooks[errorCode]...), hook)
+	pClient.errorHooks = hooks
+}
+
+func (pClient *PayPalClient) environment() string {
+	if pClient.usesSandbox {
+		return ENVIRONMENT_SANDBOX
+	}
+	return ENVIRONMENT_PRODUCTION
+}
+
+func (pClient *PayPalClient) reportLatency(method string, started time.Time) {
+	if pClient.metrics != nil {
+		pClient.metrics.ObserveLatency(method, pClient.environment(), time.Since(started))
+	}
+}
+
+func (pClient *PayPalClient) reportError(method string, response *PayPalResponse, pError *PayPalError) {
+	if len(pError.ErrorCode) == 0 {
+		return
+	}
+	if pClient.metrics != nil {
+		pClient.metrics.IncErrorCode(method, pError.ErrorCode)
+	}
+	for _, hook := range pClient.errorHooks[pError.ErrorCode] {
+		hook(response, pError)
+	}
+}
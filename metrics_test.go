@@ -0,0 +1,60 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestErrorCodeMetricsAndHooks(t *testing.T) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		return "ACK=Failure&L_ERRORCODE0=10486&L_SHORTMESSAGE0=This+transaction+couldn%27t+be+completed."
+	})})
+
+	metrics := paypal.NewMemoryMetrics()
+	client.SetMetrics(metrics)
+
+	var hooked *paypal.PayPalError
+	client.OnErrorCode("10486", func(response *paypal.PayPalResponse, err *paypal.PayPalError) {
+		hooked = err
+	})
+	client.OnErrorCode("10417", func(response *paypal.PayPalResponse, err *paypal.PayPalError) {
+		t.Errorf("Hook for 10417 ran for a 10486 error")
+	})
+
+	client.DoExpressCheckoutSale("EC-TOKEN", "PAYER", "USD", 10)
+	client.DoExpressCheckoutSale("EC-TOKEN", "PAYER", "USD", 10)
+
+	if metrics.ErrorCounts()["10486"] != 2 {
+		t.Errorf("Expected 2 counts of 10486, got %#v", metrics.ErrorCounts())
+	}
+	if hooked == nil || hooked.ErrorCode != "10486" {
+		t.Errorf("10486 hook not called, got %#v", hooked)
+	}
+}
+
+func TestLatencyMetrics(t *testing.T) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		return "ACK=Success&TOKEN=EC-TOKEN"
+	})})
+
+	metrics := paypal.NewMemoryMetrics()
+	client.SetMetrics(metrics)
+
+	client.GetExpressCheckoutDetails("EC-TOKEN")
+	client.GetExpressCheckoutDetails("EC-TOKEN")
+	client.DoExpressCheckoutSale("EC-TOKEN", "PAYER", "USD", 10)
+
+	details := metrics.Latency("GetExpressCheckoutDetails", paypal.ENVIRONMENT_SANDBOX)
+	if details.Count != 2 || details.Counts[0] != 2 {
+		t.Errorf("Expected 2 fast GetExpressCheckoutDetails observations, got %#v", details)
+	}
+	if sale := metrics.Latency("DoExpressCheckoutPayment", paypal.ENVIRONMENT_SANDBOX); sale.Count != 1 {
+		t.Errorf("Expected 1 DoExpressCheckoutPayment observation, got %#v", sale)
+	}
+	if live := metrics.Latency("GetExpressCheckoutDetails", paypal.ENVIRONMENT_PRODUCTION); live.Count != 0 {
+		t.Errorf("Sandbox calls recorded as production: %#v", live)
+	}
+}
@@ -0,0 +1,69 @@
+package paypal
+
+import (
+	"errors"
+	"net/url"
+)
+
+var errNoMethod = errors.New("paypal: NVPRequest without a Method")
+
+// Fields the client sends itself, which an NVPRequest may not set
+var reservedFields = map[string]bool{"METHOD": true, "VERSION": true, "USER": true, "PWD": true, "SIGNATURE": true, "SUBJECT": true}
+
+// Returned for an NVPRequest setting a field the client sends itself; use
+// Method, Version or a CallOption instead
+type ReservedFieldError struct {
+	Field string
+}
+
+func (e *ReservedFieldError) Error() string {
+	return "paypal: NVPRequest field " + e.Field + " is set by the client"
+}
+
+// A call to any NVP method, including ones this package has no method for
+type NVPRequest struct {
+	Method string
+	// Sent instead of NVP_VERSION when set, for methods or fields that need
+	// a newer API version
+	Version string
+	Fields  map[string]string
+	// Numbered fields by prefix: {"L_AMT": {"1.00", "2.00"}} is sent as
+	// L_AMT0=1.00&L_AMT1=2.00
+	Lists map[string][]string
+}
+
+// The values the request is sent with, before credentials
+func (r NVPRequest) Values() (url.Values, error) {
+	if len(r.Method) == 0 {
+		return nil, errNoMethod
+	}
+
+	values := url.Values{}
+	values.Set("METHOD", r.Method)
+	for field, value := range r.Fields {
+		if reservedFields[field] {
+			return nil, &ReservedFieldError{field}
+		}
+		values.Set(field, value)
+	}
+	for prefix, list := range r.Lists {
+		for i, value := range list {
+			values.Set(listKey(prefix, i), value)
+		}
+	}
+	return values, nil
+}
+
+// Calls PayPal with request, going through the same checks, retries and
+// error handling as the client's own methods
+func (pClient *PayPalClient) Execute(request NVPRequest, opts ...CallOption) (*PayPalResponse, error) {
+	values, err := request.Values()
+	if err != nil {
+		return nil, err
+	}
+	if len(request.Version) != 0 {
+		// copied so the version never lands in the caller's backing array
+		opts = append(append([]CallOption(nil), opts...), withVersion(request.Version))
+	}
+	return pClient.PerformRequest(values, opts...)
+}
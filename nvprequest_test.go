@@ -0,0 +1,48 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestExecuteNVPRequest(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success&BILLINGAGREEMENTID=B-1"
+	})})
+
+	request := paypal.NVPRequest{
+		Method:  "BillAgreementUpdate",
+		Version: "204",
+		Fields:  map[string]string{"REFERENCEID": "B-1", "BILLINGAGREEMENTSTATUS": "Canceled"},
+		Lists:   map[string][]string{"L_NOTE": {"first", "second"}},
+	}
+	response, err := client.Execute(request)
+	if err != nil || response.Values.Get("BILLINGAGREEMENTID") != "B-1" {
+		t.Fatalf("Unexpected response: %#v, %v", response, err)
+	}
+	if sent.Get("METHOD") != "BillAgreementUpdate" || sent.Get("VERSION") != "204" || len(sent["VERSION"]) != 1 || sent.Get("L_NOTE1") != "second" || sent.Get("USER") != "user" {
+		t.Errorf("Unexpected request: %#v", sent)
+	}
+
+	// spare capacity in the caller's options must not be written to
+	opts := make([]paypal.CallOption, 1, 2)
+	opts[0] = paypal.WithSubject("seller@example.com")
+	if _, err = client.Execute(request, opts...); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if opts[:2][1] != nil {
+		t.Errorf("Execute appended to the caller's options")
+	}
+
+	if _, err = client.Execute(paypal.NVPRequest{Method: "GetBalance", Fields: map[string]string{"PWD": "other"}}); err == nil {
+		t.Errorf("Expected a *ReservedFieldError for PWD")
+	}
+	if _, err = client.Execute(paypal.NVPRequest{}); err == nil {
+		t.Errorf("Expected an error without a Method")
+	}
+}
@@ -0,0 +1,118 @@
+package paypal
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// The new authorization id is in the response's TRANSACTIONID value
+func (pClient *PayPalClient) DoAuthorization(transactionId string, amount float64, currencyCode string, opts ...CallOption) (*PayPalResponse, error) {
+	values := url.Values{}
+	values.Set("METHOD", "DoAuthorization")
+	values.Add("TRANSACTIONID", transactionId)
+	values.Add("AMT", formatAmount(amount))
+	values.Add("CURRENCYCODE", currencyCode)
+	return pClient.PerformRequest(values, opts...)
+}
+
+// Returned by OrderHandle.Authorize before calling PayPal when the amount
+// exceeds what is left on the order
+type OverAuthorizationError struct {
+	OrderId   string
+	Amount    float64
+	Remaining float64
+}
+
+func (e *OverAuthorizationError) Error() string {
+	return fmt.Sprintf("PayPal order %s has %.2f left to authorize, %.2f requested", e.OrderId, e.Remaining, e.Amount)
+}
+
+// An Express Checkout payment made with the "Order" payment action. The
+// order itself moves no money: funds are authorized against it as goods
+// become ready to ship, and each authorization is then captured.
+//
+// A handle must not be shared between goroutines.
+type OrderHandle struct {
+	OrderId      string
+	Amount       float64
+	CurrencyCode string
+
+	Authorized     float64
+	Authorizations []*PayPalAuthorization
+
+	client *PayPalClient
+	opts   []CallOption
+}
+
+// Completes the checkout with the "Order" payment action
+func (pClient *PayPalClient) PlaceOrder(token, payerId, currencyCode string, amount float64, opts ...CallOption) (*OrderHandle, error) {
+	response, err := pClient.DoExpressCheckoutPayment(token, payerId, PAYMENT_ACTION_ORDER, currencyCode, amount, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return pClient.OrderHandle(response, opts...), nil
+}
+
+// Builds the handle of an order placed by a DoExpressCheckoutPayment call.
+// The handle makes its calls with opts.
+func (pClient *PayPalClient) OrderHandle(response *PayPalResponse, opts ...CallOption) *OrderHandle {
+	amount, _ := strconv.ParseFloat(response.Values.Get("PAYMENTINFO_0_AMT"), 64)
+	return &OrderHandle{
+		OrderId:      response.Values.Get("PAYMENTINFO_0_TRANSACTIONID"),
+		Amount:       amount,
+		CurrencyCode: response.Values.Get("PAYMENTINFO_0_CURRENCYCODE"),
+		client:       pClient,
+		opts:         opts,
+	}
+}
+
+// Amount of the order not authorized yet
+func (order *OrderHandle) Remaining() float64 {
+	return float64(toCents(order.Amount)-toCents(order.Authorized)) / 100
+}
+
+// Amount captured over all of the order's authorizations
+func (order *OrderHandle) Captured() float64 {
+	var cents int64
+	for _, auth := range order.Authorizations {
+		cents += toCents(auth.Captured)
+	}
+	return float64(cents) / 100
+}
+
+// Authorizes part of the order. The authorization can be captured with
+// Capture, or tracked by an AuthorizationTracker like any other.
+func (order *OrderHandle) Authorize(amount float64) (*PayPalAuthorization, error) {
+	remaining := order.Remaining()
+	if toCents(amount) > toCents(remaining) {
+		return nil, &OverAuthorizationError{order.OrderId, amount, remaining}
+	}
+
+	response, err := order.client.DoAuthorization(order.OrderId, amount, order.CurrencyCode, order.opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	auth := &PayPalAuthorization{
+		AuthorizationId: response.Values.Get("TRANSACTIONID"),
+		Amount:          amount,
+		CurrencyCode:    order.CurrencyCode,
+		AuthorizedAt:    time.Now(),
+	}
+	order.Authorized += amount
+	order.Authorizations = append(order.Authorizations, auth)
+	return auth, nil
+}
+
+// Captures from one of the order's authorizations, see CaptureAuthorization
+func (order *OrderHandle) Capture(auth *PayPalAuthorization, amount float64, final bool) (string, error) {
+	return order.client.CaptureAuthorization(auth, amount, final, order.opts...)
+}
+
+// Voids the order so no further authorizations can be made against it
+func (order *OrderHandle) Void(note string) error {
+	_, err := order.client.DoVoid(order.OrderId, note, order.opts...)
+	return err
+}
@@ -0,0 +1,85 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestOrderFlow(t *testing.T) {
+	var methods []string
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		methods = append(methods, values.Get("METHOD"))
+		switch values.Get("METHOD") {
+		case "DoExpressCheckoutPayment":
+			if values.Get("PAYMENTREQUEST_0_PAYMENTACTION") != "Order" {
+				return "ACK=Failure&L_ERRORCODE0=10004"
+			}
+			return "ACK=Success&PAYMENTINFO_0_TRANSACTIONID=O-1&PAYMENTINFO_0_AMT=100.00&PAYMENTINFO_0_CURRENCYCODE=USD"
+		case "DoAuthorization":
+			if values.Get("TRANSACTIONID") != "O-1" {
+				return "ACK=Failure&L_ERRORCODE0=10609"
+			}
+			return "ACK=Success&TRANSACTIONID=AUTH-" + strconv.Itoa(len(methods))
+		case "DoCapture":
+			return "ACK=Success&TRANSACTIONID=CAPTURE-" + values.Get("AUTHORIZATIONID")
+		}
+		return "ACK=Failure"
+	})})
+
+	order, err := client.PlaceOrder("TOKEN", "PAYER", "USD", 100)
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if order.OrderId != "O-1" || order.Amount != 100 || order.CurrencyCode != "USD" {
+		t.Fatalf("Unexpected order: %#v", order)
+	}
+
+	auth, err := order.Authorize(60)
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if order.Remaining() != 40 {
+		t.Errorf("Expected 40 left on the order, got %.2f", order.Remaining())
+	}
+
+	if _, err := order.Authorize(50); err == nil {
+		t.Errorf("Expected an *OverAuthorizationError authorizing more than remains")
+	}
+
+	if _, err := order.Capture(auth, 60, true); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if order.Captured() != 60 || !auth.Completed {
+		t.Errorf("Unexpected capture state: %.2f captured, %#v", order.Captured(), auth)
+	}
+
+	expected := []string{"DoExpressCheckoutPayment", "DoAuthorization", "DoCapture"}
+	if len(methods) != len(expected) {
+		t.Fatalf("Unexpected calls: %#v", methods)
+	}
+	for i := range expected {
+		if methods[i] != expected[i] {
+			t.Errorf("Unexpected calls: %#v", methods)
+		}
+	}
+}
+
+func TestInvalidPaymentAction(t *testing.T) {
+	calls := 0
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		calls++
+		return "ACK=Success"
+	})})
+
+	_, err := client.DoExpressCheckoutPayment("TOKEN", "PAYER", "sale", "USD", 10)
+	if _, ok := err.(*paypal.PaymentActionError); !ok {
+		t.Errorf("Expected a *PaymentActionError, got %#v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Invalid payment action sent to PayPal")
+	}
+}
@@ -0,0 +1,123 @@
+package paypal
+
+import (
+	"sync"
+	"time"
+)
+
+// Order as it was sent to SetExpressCheckout
+type StoredOrder struct {
+	Order PayPalOrder
+	Goods []PayPalGood
+}
+
+// Keeps orders between SetExpressCheckout and DoExpressCheckoutPayment,
+// keyed by checkout token. Implementations must be safe for concurrent use.
+type OrderStore interface {
+	Save(token string, order *StoredOrder) error
+	// Returns nil, nil for unknown tokens
+	Load(token string) (*StoredOrder, error)
+	Delete(token string) error
+}
+
+type storedOrder struct {
+	order   *StoredOrder
+	savedAt time.Time
+}
+
+type MemoryOrderStore struct {
+	ttl    time.Duration
+	mu     sync.Mutex
+	orders map[string]storedOrder
+}
+
+// ttl controls how long an unpaid order is kept, at most
+// CHECKOUT_TOKEN_LIFETIME since its token can't be paid after that; 0 uses
+// CHECKOUT_TOKEN_LIFETIME
+func NewMemoryOrderStore(ttl time.Duration) *MemoryOrderStore {
+	if ttl <= 0 || ttl > CHECKOUT_TOKEN_LIFETIME {
+		ttl = CHECKOUT_TOKEN_LIFETIME
+	}
+	return &MemoryOrderStore{ttl: ttl, orders: make(map[string]storedOrder)}
+}
+
+func (s *MemoryOrderStore) Save(token string, order *StoredOrder) error {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// abandoned checkouts are dropped as new ones come in
+	for savedToken, entry := range s.orders {
+		if now.Sub(entry.savedAt) > s.ttl {
+			delete(s.orders, savedToken)
+		}
+	}
+	s.orders[token] = storedOrder{order, now}
+	return nil
+}
+
+func (s *MemoryOrderStore) Load(token string) (*StoredOrder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.orders[token]
+	if !ok {
+		return nil, nil
+	}
+	if time.Since(entry.savedAt) > s.ttl {
+		delete(s.orders, token)
+		return nil, nil
+	}
+	return entry.order, nil
+}
+
+func (s *MemoryOrderStore) Delete(token string) error {
+	s.mu.Lock()
+	delete(s.orders, token)
+	s.mu.Unlock()
+	return nil
+}
+
+// With a store set, SetExpressCheckout saves each order under its token and
+// DoExpressCheckoutPayment sends the saved line items and amounts along
+// with the payment, as long as the final amount and currency still match
+// the saved order. Orders are removed once the token is paid, whether or
+// not the saved order was sent with the payment.
+func (pClient *PayPalClient) SetOrderStore(store OrderStore) {
+	pClient.orderStore = store
+}
+
+func (pClient *PayPalClient) saveOrder(response *PayPalResponse, order PayPalOrder, goods []PayPalGood) error {
+	if pClient.orderStore == nil || response == nil || len(response.Token) == 0 {
+		return nil
+	}
+	return pClient.orderStore.Save(response.Token, &StoredOrder{order, goods})
+}
+
+// Saved order for token if it can be sent with a payment of amount, or a
+// *MaxAmountError when amount is over the saved order's MaxAmount
+func (pClient *PayPalClient) matchingOrder(token, currencyCode string, amount float64) (*StoredOrder, error) {
+	if pClient.orderStore == nil {
+		return nil, nil
+	}
+	stored, err := pClient.orderStore.Load(token)
+	if err != nil || stored == nil {
+		return nil, err
+	}
+	if stored.Order.CurrencyCode == currencyCode {
+		if err := checkMaxAmount(token, amount, stored.Order.MaxAmount); err != nil {
+			return nil, err
+		}
+	}
+	if stored.Order.CurrencyCode != currencyCode || toCents(stored.Order.Total) != toCents(amount) {
+		return nil, nil
+	}
+	return stored, nil
+}
+
+func (pClient *PayPalClient) forgetOrder(token string) {
+	if pClient.orderStore != nil {
+		pClient.orderStore.Delete(token)
+	}
+}
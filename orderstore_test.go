@@ -0,0 +1,97 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestOrderStoreReattachesItems(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success&TOKEN=EC-STORED"
+	})})
+	store := paypal.NewMemoryOrderStore(0)
+	client.SetOrderStore(store)
+
+	order := paypal.PayPalOrder{SubTotal: 19.98, Shipping: 5, Total: 24.98, CurrencyCode: "USD", Description: "Order #7"}
+	if _, err := client.SetExpressCheckout(order, benchmarkGoods(1)); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if stored, _ := store.Load("EC-STORED"); stored == nil || stored.Order.Description != "Order #7" {
+		t.Fatalf("Order not saved under its token: %#v", stored)
+	}
+
+	if _, err := client.DoExpressCheckoutSale("EC-STORED", "PAYERID", "USD", 24.98); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if sent.Get("L_PAYMENTREQUEST_0_NAME0") != "Subscription & add-ons" || sent.Get("PAYMENTREQUEST_0_ITEMAMT") != "19.98" || sent.Get("PAYMENTREQUEST_0_DESC") != "Order #7" {
+		t.Errorf("Stored order not sent with the payment: %#v", sent)
+	}
+	if stored, _ := store.Load("EC-STORED"); stored != nil {
+		t.Errorf("Order still stored after payment")
+	}
+}
+
+func TestOrderStoreSkipsChangedTotals(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success&TOKEN=EC-STORED"
+	})})
+	store := paypal.NewMemoryOrderStore(0)
+	client.SetOrderStore(store)
+
+	client.SetExpressCheckout(paypal.PayPalOrder{SubTotal: 19.98, Total: 19.98, CurrencyCode: "USD"}, benchmarkGoods(1))
+	client.DoExpressCheckoutSale("EC-STORED", "PAYERID", "USD", 25.00)
+
+	if len(sent["L_PAYMENTREQUEST_0_NAME0"]) != 0 || sent.Get("PAYMENTREQUEST_0_AMT") != "25.00" {
+		t.Errorf("Stored items attached to a payment for a different amount: %#v", sent)
+	}
+	if stored, _ := store.Load("EC-STORED"); stored != nil {
+		t.Errorf("Order still stored after the token was paid")
+	}
+}
+
+func TestMemoryOrderStoreExpiry(t *testing.T) {
+	store := paypal.NewMemoryOrderStore(time.Millisecond)
+
+	store.Save("EC-1", &paypal.StoredOrder{})
+	if stored, _ := store.Load("EC-1"); stored == nil {
+		t.Fatalf("Expected EC-1 to be stored")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if stored, _ := store.Load("EC-1"); stored != nil {
+		t.Errorf("EC-1 should have expired after the ttl elapsed")
+	}
+}
+
+func TestOrderStoreMaxAmount(t *testing.T) {
+	var sent []url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = append(sent, values)
+		return "ACK=Success&TOKEN=EC-STORED"
+	})})
+	client.SetOrderStore(paypal.NewMemoryOrderStore(0))
+
+	client.SetExpressCheckout(paypal.PayPalOrder{SubTotal: 19.98, Total: 19.98, MaxAmount: 30, CurrencyCode: "USD"}, benchmarkGoods(1))
+	if sent[0].Get("MAXAMT") != "30.00" {
+		t.Errorf("MAXAMT not sent: %#v", sent[0])
+	}
+
+	_, err := client.DoExpressCheckoutSale("EC-STORED", "PAYERID", "USD", 35)
+	if _, ok := err.(*paypal.MaxAmountError); !ok {
+		t.Errorf("Expected a *MaxAmountError, got %#v", err)
+	}
+	if len(sent) != 1 {
+		t.Errorf("Payment over MAXAMT sent to PayPal")
+	}
+
+	if _, err := client.DoExpressCheckoutSale("EC-STORED", "PAYERID", "USD", 27.48); err != nil {
+		t.Errorf("Unexpected error for a payment under MAXAMT: %#v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package paypal
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// What an OutstandingBalanceCollector did with a profile
+const (
+	BILLING_BILLED  = "billed"
+	BILLING_SKIPPED = "skipped"
+	BILLING_FAILED  = "failed"
+)
+
+// Bills amount of a profile's outstanding balance; 0 bills all of it
+func (pClient *PayPalClient) BillOutstandingAmount(profileId string, amount float64, note string, opts ...CallOption) (*PayPalResponse, error) {
+	values := url.Values{}
+	values.Set("METHOD", "BillOutstandingAmount")
+	values.Add("PROFILEID", profileId)
+	if amount > 0 {
+		values.Add("AMT", formatAmount(amount))
+	}
+	if len(note) != 0 {
+		values.Add("NOTE", note)
+	}
+	return pClient.PerformRequest(values, opts...)
+}
+
+type BillingOutcome struct {
+	ProfileId string
+	// One of the BILLING_ values
+	Outcome string
+	// Why the profile was skipped, empty otherwise
+	Reason string
+	// Balance billed, or found when skipping
+	Amount float64
+	Err    error
+}
+
+// Collects the outstanding balances of recurring profiles that missed a
+// cycle, for a periodic recovery job. Each profile's details are read first
+// and its balance is billed only when Policy's ShouldRetryBilling agrees.
+//
+// A balance is billed at most once: attempts are recorded in Attempts by
+// profile, failed payment count and amount before calling PayPal, so a
+// timeout or crash mid-call is never followed by a second charge. The
+// balance is tried again once the profile's state changes, e.g. after
+// another failed cycle.
+type OutstandingBalanceCollector struct {
+	Policy BillingRetryPolicy
+	// Sent with every BillOutstandingAmount
+	Note string
+	// Defaults to a MemoryDeduplicator; use a shared one when the job runs
+	// on several machines or restarts
+	Attempts Deduplicator
+
+	client  *PayPalClient
+	limiter RateLimiter
+}
+
+// limiter may be nil; both the lookup and the billing of each profile wait
+// for it
+func NewOutstandingBalanceCollector(client *PayPalClient, policy BillingRetryPolicy, limiter RateLimiter) *OutstandingBalanceCollector {
+	return &OutstandingBalanceCollector{
+		Policy:   policy,
+		Attempts: NewMemoryDeduplicator(0),
+		client:   client,
+		limiter:  limiter,
+	}
+}
+
+// Handles profileIds one after the other and reports on each, in input
+// order. A failing profile does not stop the run.
+func (c *OutstandingBalanceCollector) Collect(profileIds []string, opts ...CallOption) []BillingOutcome {
+	outcomes := make([]BillingOutcome, len(profileIds))
+	for i, profileId := range profileIds {
+		outcomes[i] = c.collect(profileId, opts)
+	}
+	return outcomes
+}
+
+func (c *OutstandingBalanceCollector) collect(profileId string, opts []CallOption) BillingOutcome {
+	outcome := BillingOutcome{ProfileId: profileId}
+
+	c.wait(opts)
+	response, err := c.client.GetRecurringPaymentsProfileDetails(profileId, opts...)
+	if err != nil {
+		outcome.Outcome, outcome.Err = BILLING_FAILED, err
+		return outcome
+	}
+	var profile PayPalRecurringProfile
+	profile.Populate(response.Values)
+	outcome.Amount = profile.OutstandingBalance
+
+	if !profile.ShouldRetryBilling(c.Policy) {
+		outcome.Outcome, outcome.Reason = BILLING_SKIPPED, "not due under the billing policy"
+		return outcome
+	}
+	attempt := fmt.Sprintf("%s/%d/%s", profileId, profile.FailedPaymentCount, formatAmount(profile.OutstandingBalance))
+	if c.Attempts.Seen(attempt) {
+		outcome.Outcome, outcome.Reason = BILLING_SKIPPED, "balance already billed once"
+		return outcome
+	}
+
+	c.wait(opts)
+	if _, err = c.client.BillOutstandingAmount(profileId, profile.OutstandingBalance, c.Note, opts...); err != nil {
+		outcome.Outcome, outcome.Err = BILLING_FAILED, err
+		return outcome
+	}
+	outcome.Outcome = BILLING_BILLED
+	return outcome
+}
+
+// A done context is left for the call that follows to report
+func (c *OutstandingBalanceCollector) wait(opts []CallOption) {
+	waitLimiter(c.client.callSettings(opts).ctx, c.limiter)
+}
@@ -0,0 +1,62 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestOutstandingBalanceCollector(t *testing.T) {
+	profiles := map[string]string{
+		"I-OWING":     "STATUS=Active&OUTSTANDINGBALANCE=19.98&FAILEDPAYMENTCOUNT=1",
+		"I-PAID":      "STATUS=Active&OUTSTANDINGBALANCE=0.00",
+		"I-GIVEN-UP":  "STATUS=Suspended&OUTSTANDINGBALANCE=9.99&FAILEDPAYMENTCOUNT=3&MAXFAILEDPAYMENTS=3",
+		"I-DECLINED":  "STATUS=Active&OUTSTANDINGBALANCE=5.00&FAILEDPAYMENTCOUNT=1",
+		"I-CANCELLED": "STATUS=Cancelled&OUTSTANDINGBALANCE=5.00",
+	}
+
+	var billed []url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		id := values.Get("PROFILEID")
+		if values.Get("METHOD") == "BillOutstandingAmount" {
+			billed = append(billed, values)
+			if id == "I-DECLINED" {
+				return "ACK=Failure&L_ERRORCODE0=11556&L_SEVERITYCODE0=Error"
+			}
+			return "ACK=Success&PROFILEID=" + id
+		}
+		return "ACK=Success&PROFILEID=" + id + "&" + profiles[id]
+	})})
+
+	collector := paypal.NewOutstandingBalanceCollector(client, paypal.BillingRetryPolicy{}, nil)
+	collector.Note = "Missed payment"
+	ids := []string{"I-OWING", "I-PAID", "I-GIVEN-UP", "I-DECLINED", "I-CANCELLED"}
+	outcomes := collector.Collect(ids)
+
+	expected := []string{paypal.BILLING_BILLED, paypal.BILLING_SKIPPED, paypal.BILLING_SKIPPED, paypal.BILLING_FAILED, paypal.BILLING_SKIPPED}
+	for i, outcome := range outcomes {
+		if outcome.Outcome != expected[i] {
+			t.Errorf("Expected %s to be %s, got %+v", outcome.ProfileId, expected[i], outcome)
+		}
+	}
+	if len(billed) != 2 || billed[0].Get("AMT") != "19.98" || billed[0].Get("NOTE") != "Missed payment" {
+		t.Fatalf("Unexpected billing calls %v", billed)
+	}
+
+	// the same balances are not billed again, even the one that failed
+	for _, outcome := range collector.Collect(ids) {
+		if outcome.Outcome == paypal.BILLING_BILLED || outcome.Outcome == paypal.BILLING_FAILED {
+			t.Errorf("Expected %s not to be billed twice", outcome.ProfileId)
+		}
+	}
+	if len(billed) != 2 {
+		t.Errorf("Expected no more billing calls, got %d", len(billed))
+	}
+
+	profiles["I-OWING"] = "STATUS=Active&OUTSTANDINGBALANCE=29.97&FAILEDPAYMENTCOUNT=2"
+	if outcomes = collector.Collect([]string{"I-OWING"}); outcomes[0].Outcome != paypal.BILLING_BILLED {
+		t.Errorf("Expected a new balance to be billed, got %+v", outcomes[0])
+	}
+}
@@ -0,0 +1,86 @@
+package paypal
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+var errNoPaymentRequests = errors.New("paypal: parallel checkout without payment requests")
+
+// One seller's part of a parallel checkout. Order.SellerAccountId routes it
+// to the seller and Order.PaymentRequestId finds it again in the response.
+type PayPalPaymentRequest struct {
+	Order PayPalOrder
+	Goods []PayPalGood
+}
+
+// Sets up a checkout paying several sellers at once. Parallel payments use
+// the "Order" payment action; the checkout-wide settings (return URLs,
+// shipping, solution type...) are taken from the first request's order.
+func (pClient *PayPalClient) SetExpressCheckoutParallel(requests []PayPalPaymentRequest, opts ...CallOption) (*PayPalResponse, error) {
+	if len(requests) == 0 {
+		return nil, errNoPaymentRequests
+	}
+	if err := checkPaymentRequestCount(len(requests)); err != nil {
+		return nil, err
+	}
+
+	values, err := setExpressCheckoutValues(requests[0].Order, requests[0].Goods)
+	if err != nil {
+		return nil, err
+	}
+	values.Set("PAYMENTREQUEST_0_PAYMENTACTION", string(PAYMENT_ACTION_ORDER))
+	for n := 1; n < len(requests); n++ {
+		if err := addNthPaymentRequest(values, n, requests[n].Order, requests[n].Goods); err != nil {
+			return nil, err
+		}
+		prefix := "PAYMENTREQUEST_" + strconv.Itoa(n) + "_"
+		values.Add(prefix+"PAYMENTACTION", string(PAYMENT_ACTION_ORDER))
+		if requests[n].Order.InsuranceOffered {
+			values.Add(prefix+"INSURANCEOPTIONOFFERED", "true")
+		}
+	}
+
+	return pClient.PerformRequest(values, opts...)
+}
+
+// Completes a parallel checkout; see PaymentResponses for the outcome of
+// each payment request
+func (pClient *PayPalClient) DoExpressCheckoutParallelPayment(token, payerId string, requests []PayPalPaymentRequest, opts ...CallOption) (*PayPalResponse, error) {
+	if len(requests) == 0 {
+		return nil, errNoPaymentRequests
+	}
+	if err := checkPaymentRequestCount(len(requests)); err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	values.Set("METHOD", "DoExpressCheckoutPayment")
+	values.Add("TOKEN", token)
+	values.Add("PAYERID", payerId)
+	for n, request := range requests {
+		if err := addNthPaymentRequest(values, n, request.Order, request.Goods); err != nil {
+			return nil, err
+		}
+		values.Add("PAYMENTREQUEST_"+strconv.Itoa(n)+"_PAYMENTACTION", string(PAYMENT_ACTION_ORDER))
+	}
+
+	return pClient.PerformRequest(values, opts...)
+}
+
+// Decodes every PAYMENTINFO_n_ group of a DoExpressCheckoutPayment
+// response, in payment request order
+func PaymentResponses(values url.Values) []PayPalPaymentResponse {
+	p := &fieldParser{values: values}
+	var responses []PayPalPaymentResponse
+	for n := 0; ; n++ {
+		prefix := "PAYMENTINFO_" + strconv.Itoa(n) + "_"
+		if len(values.Get(prefix+"TRANSACTIONID")) == 0 && len(values.Get(prefix+"PAYMENTREQUESTID")) == 0 && len(values.Get(prefix+"ERRORCODE")) == 0 {
+			return responses
+		}
+		var response PayPalPaymentResponse
+		response.populate(p, prefix)
+		responses = append(responses, response)
+	}
+}
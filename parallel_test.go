@@ -0,0 +1,65 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func parallelRequests(count int) []paypal.PayPalPaymentRequest {
+	requests := make([]paypal.PayPalPaymentRequest, count)
+	for i := range requests {
+		requests[i] = paypal.PayPalPaymentRequest{
+			Order: paypal.PayPalOrder{SubTotal: 10, Total: 10, CurrencyCode: "USD", ReturnUrl: TEST_RETURN_URL, CancelUrl: TEST_CANCEL_URL},
+			Goods: []paypal.PayPalGood{{Name: "Item", Amount: 10, Quantity: 1}},
+		}
+	}
+	return requests
+}
+
+func TestSetExpressCheckoutParallel(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success&TOKEN=EC-PARALLEL"
+	})})
+
+	requests := parallelRequests(2)
+	requests[0].Order.SellerAccountId = "seller-a@example.com"
+	requests[0].Order.PaymentRequestId = "order-1-a"
+	requests[1].Order.SellerAccountId = "SELLERB123"
+	requests[1].Order.PaymentRequestId = "order-1-b"
+
+	if _, err := client.SetExpressCheckoutParallel(requests); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if sent.Get("PAYMENTREQUEST_0_SELLERPAYPALACCOUNTID") != "seller-a@example.com" || sent.Get("PAYMENTREQUEST_1_SELLERPAYPALACCOUNTID") != "SELLERB123" ||
+		sent.Get("PAYMENTREQUEST_1_PAYMENTREQUESTID") != "order-1-b" || sent.Get("L_PAYMENTREQUEST_1_NAME0") != "Item" {
+		t.Errorf("Payment requests not routed: %#v", sent)
+	}
+	if sent.Get("PAYMENTREQUEST_0_PAYMENTACTION") != "Order" || sent.Get("PAYMENTREQUEST_1_PAYMENTACTION") != "Order" || sent.Get("RETURNURL") != TEST_RETURN_URL {
+		t.Errorf("Unexpected checkout settings: %#v", sent)
+	}
+
+	if _, err := client.SetExpressCheckoutParallel(parallelRequests(11)); err == nil {
+		t.Errorf("Expected a *CountLimitError for 11 payment requests")
+	}
+}
+
+func TestPaymentResponses(t *testing.T) {
+	values, _ := url.ParseQuery("PAYMENTINFO_0_TRANSACTIONID=TXN-A&PAYMENTINFO_0_PAYMENTREQUESTID=order-1-a&PAYMENTINFO_0_AMT=10.00" +
+		"&PAYMENTINFO_1_PAYMENTREQUESTID=order-1-b&PAYMENTINFO_1_ERRORCODE=10417")
+
+	responses := paypal.PaymentResponses(values)
+	if len(responses) != 2 {
+		t.Fatalf("Expected 2 payment responses, got %#v", responses)
+	}
+	if responses[0].PaymentRequestId != "order-1-a" || responses[0].TransactionId != "TXN-A" || responses[0].Amount != 10 {
+		t.Errorf("Unexpected first response: %#v", responses[0])
+	}
+	if responses[1].PaymentRequestId != "order-1-b" || responses[1].ErrorCode != "10417" {
+		t.Errorf("Unexpected second response: %#v", responses[1])
+	}
+}
@@ -0,0 +1,100 @@
+package paypal
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// What PayPalResponse.Decode does with a number or timestamp it cannot
+// parse
+type ParseMode int
+
+const (
+	// The field is left at its zero value and a warning is added to the
+	// response (the default)
+	PARSE_LENIENT ParseMode = iota
+	// Decode fails with a *ParseError
+	PARSE_STRICT
+)
+
+// Lets batch jobs keep going past a malformed field while checkout paths
+// refuse to act on one
+func (pClient *PayPalClient) SetParseMode(mode ParseMode) {
+	pClient.parseMode = mode
+}
+
+// Returned by PayPalResponse.Decode in PARSE_STRICT mode
+type ParseError struct {
+	Failures []string
+}
+
+func (e *ParseError) Error() string {
+	return "PayPal response fields could not be parsed: " + strings.Join(e.Failures, "; ")
+}
+
+// Reads typed fields out of a response, remembering what failed to parse.
+// Empty fields are not failures: PayPal leaves out what doesn't apply.
+type fieldParser struct {
+	values   url.Values
+	failures []string
+}
+
+func (p *fieldParser) float(key string) float64 {
+	value := p.values.Get(key)
+	if len(value) == 0 {
+		return 0
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		p.failures = append(p.failures, key+": invalid number "+strconv.Quote(value))
+		return 0
+	}
+	return parsed
+}
+
+func (p *fieldParser) int(key string) int {
+	value := p.values.Get(key)
+	if len(value) == 0 {
+		return 0
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		p.failures = append(p.failures, key+": invalid number "+strconv.Quote(value))
+		return 0
+	}
+	return parsed
+}
+
+func (p *fieldParser) time(key string) time.Time {
+	value := p.values.Get(key)
+	if len(value) == 0 {
+		return time.Time{}
+	}
+	parsed, err := time.Parse(NVP_DATE_LAYOUT, value)
+	if err != nil {
+		p.failures = append(p.failures, key+": invalid timestamp "+strconv.Quote(value))
+	}
+	return parsed
+}
+
+// A typed view of a response, such as *PayPalPaymentResponse or
+// *PayPalCheckoutDetails
+type ResponseView interface {
+	decode(p *fieldParser)
+}
+
+// Fills view from the response according to the client's ParseMode
+func (r *PayPalResponse) Decode(view ResponseView) error {
+	p := &fieldParser{values: r.Values}
+	view.decode(p)
+	if len(p.failures) == 0 {
+		return nil
+	}
+	if r.parseMode == PARSE_STRICT {
+		return &ParseError{p.failures}
+	}
+	r.Warnings = append(r.Warnings, p.failures...)
+	return nil
+}
@@ -0,0 +1,40 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestParseMode(t *testing.T) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		return "ACK=Success&TRANSACTIONID=8AB&AMT=12%2C50&ORDERTIME=yesterday&CURRENCYCODE=EUR"
+	})})
+
+	response, err := client.GetTransactionDetails("8AB")
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	details := new(paypal.PayPalTransactionDetails)
+	if err := response.Decode(details); err != nil {
+		t.Errorf("Lenient decode failed: %#v", err)
+	}
+	if details.Amount != 0 || details.Currency != "EUR" || len(response.Warnings) != 2 {
+		t.Errorf("Expected zero values and 2 warnings, got %#v, %#v", details, response.Warnings)
+	}
+
+	client.SetParseMode(paypal.PARSE_STRICT)
+	response, _ = client.GetTransactionDetails("8AB")
+	err = response.Decode(new(paypal.PayPalTransactionDetails))
+	if parseErr, ok := err.(*paypal.ParseError); !ok || len(parseErr.Failures) != 2 {
+		t.Errorf("Expected a *ParseError with 2 failures, got %#v", err)
+	}
+
+	var results paypal.PayPalSearchResults
+	search := &paypal.PayPalResponse{Values: url.Values{"L_TRANSACTIONID0": {"8AB"}, "L_AMT0": {"-5.00"}}}
+	if err := search.Decode(&results); err != nil || len(results) != 1 || results[0].Amount != -5 {
+		t.Errorf("Search results not decoded: %#v, %#v", results, err)
+	}
+}
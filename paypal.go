@@ -1,12 +1,13 @@
 package paypal
 
 import (
+	"context"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
 	"net/url"
 	"strconv"
-	"strings"
+	"time"
 )
 
 const (
@@ -17,22 +18,187 @@ const (
 	NVP_VERSION             = "94"
 )
 
+// Safe for concurrent use once configured: PerformRequest and the API
+// methods only read the client's settings. The Set* and OnErrorCode methods
+// are not synchronized, so call them before sharing the client. To change
+// settings while calls are in flight, e.g. to rotate credentials, build a
+// new client with With and swap it in; calls running on the old one finish
+// with the old settings.
 type PayPalClient struct {
 	username    string
 	password    string
 	signature   string
 	usesSandbox bool
 	client      *http.Client
+	limiter     RateLimiter
+	metrics     Metrics
+	errorHooks  map[string][]ErrorCodeHook
+
+	// See SetSharedRateLimit
+	sharedRate float64
+
+	truncateFields  bool
+	strictResponses bool
+	parseMode       ParseMode
+	orderStore      OrderStore
+	detailsCache    DetailsCache
+	tokens          *tokenTracker
+	retryPolicy     *RetryPolicy
+	callLogger      CallLogger
+	warningPolicy   WarningPolicy
+	// SUBJECT sent with calls that don't set their own
+	subject string
+	// When set, the only METHODs PerformRequest will call
+	grantedMethods map[string]bool
+	// See SetRequireLiveConfirmation
+	requireLiveConfirmation bool
+	liveConfirmed           bool
+	// See SetDryRun
+	dryRun bool
+}
+
+// How SetExpressCheckout passes PayPalOrder.Discount to PayPal
+type DiscountStrategy int
+
+const (
+	// A negative "DISCOUNT" line item, included in SubTotal
+	DISCOUNT_LINE_ITEM DiscountStrategy = iota
+	// PAYMENTREQUEST_0_SHIPDISCAMT, deducted from the total but not SubTotal.
+	// Use when the account rejects negative line items.
+	DISCOUNT_SHIPPING_DISCOUNT
+)
+
+// Whether PayPal collects a shipping address from the buyer
+type ShippingBehavior int
+
+const (
+	// No shipping address fields, for digital goods and services
+	SHIPPING_NONE ShippingBehavior = iota
+	// Shipping address shown, the buyer may leave it out
+	SHIPPING_OPTIONAL
+	// The buyer must provide a shipping address
+	SHIPPING_REQUIRED
+	// The buyer must provide a shipping address PayPal has confirmed
+	SHIPPING_CONFIRMED
+)
+
+// SOLUTIONTYPE: whether the buyer needs a PayPal account
+type SolutionType string
+
+const (
+	// Buyers can pay without a PayPal account (the default)
+	SOLUTION_TYPE_SOLE SolutionType = "Sole"
+	// Buyers need a PayPal account
+	SOLUTION_TYPE_MARK SolutionType = "Mark"
+)
+
+// PAYMENTACTION: how the payment is settled
+type PaymentAction string
+
+const (
+	// Charges the buyer immediately
+	PAYMENT_ACTION_SALE PaymentAction = "Sale"
+	// Holds the funds for a later DoCapture
+	PAYMENT_ACTION_AUTHORIZATION PaymentAction = "Authorization"
+	// Places an order to be authorized and captured later, see PlaceOrder
+	PAYMENT_ACTION_ORDER PaymentAction = "Order"
+)
+
+// Returned before calling PayPal when a payment action is not one of the
+// PAYMENT_ACTION_* constants
+type PaymentActionError struct {
+	Action PaymentAction
+}
+
+func (e *PaymentActionError) Error() string {
+	return "PayPal payment action " + strconv.Quote(string(e.Action)) + " is not Sale, Authorization or Order"
+}
+
+func (action PaymentAction) validate() error {
+	switch action {
+	case PAYMENT_ACTION_SALE, PAYMENT_ACTION_AUTHORIZATION, PAYMENT_ACTION_ORDER:
+		return nil
+	}
+	return &PaymentActionError{action}
+}
+
+// CHANNELTYPE
+type ChannelType string
+
+const (
+	CHANNEL_TYPE_MERCHANT ChannelType = "Merchant"
+	CHANNEL_TYPE_EBAY     ChannelType = "eBayItem"
+)
+
+// L_BILLINGTYPEn: what a billing agreement set up at checkout allows
+type BillingType string
+
+const (
+	// Reference transactions against the buyer's account
+	BILLING_TYPE_MERCHANT_INITIATED BillingType = "MerchantInitiatedBilling"
+	// Like BILLING_TYPE_MERCHANT_INITIATED, reusing an existing agreement
+	// with the buyer when there is one
+	BILLING_TYPE_MERCHANT_INITIATED_SINGLE BillingType = "MerchantInitiatedBillingSingleAgreement"
+	BILLING_TYPE_RECURRING_PAYMENTS        BillingType = "RecurringPayments"
+)
+
+// A billing agreement the buyer accepts during checkout
+type PayPalBillingAgreement struct {
+	Type BillingType
+	// Shown to the buyer
+	Description string
+	// Optional, returned with the agreement's transactions
+	Custom string
+	// "Any" or "InstantOnly", not sent when empty
+	PaymentType string
 }
 
 type PayPalOrder struct {
-	SubTotal     float64
-	Shipping     float64
-	Discount     float64
-	Total        float64
+	SubTotal  float64
+	Tax       float64
+	Shipping  float64
+	Handling  float64
+	Insurance float64
+	// Lets the buyer decide at PayPal whether to pay the Insurance amount
+	InsuranceOffered bool
+	Discount         float64
+	DiscountStrategy DiscountStrategy
+	Total            float64
+	// MAXAMT: the most the final total may reach, e.g. once the buyer picks
+	// a shipping option at PayPal. Not sent when 0.
+	MaxAmount    float64
 	CurrencyCode string
 	ReturnUrl    string
 	CancelUrl    string
+	// PAYMENTREQUEST_0_DESC, shown to the buyer
+	Description string
+	// PAYMENTREQUEST_0_NOTETEXT, a note to the buyer
+	NoteText string
+	// Lets the buyer leave a note for the merchant at PayPal
+	AllowNote        bool
+	ShippingBehavior ShippingBehavior
+	// Sent as PAYMENTREQUEST_n_SHIPTO* when set, after being normalized
+	ShippingAddress *Address
+	// Shows the buyer ShippingAddress instead of the one on their account
+	AddressOverride bool
+	// Defaults to SOLUTION_TYPE_SOLE
+	SolutionType SolutionType
+	// Not sent when empty
+	ChannelType ChannelType
+	// Refuses eChecks and other payments that would arrive Pending, sent as
+	// ALLOWEDPAYMENTMETHOD=InstantPaymentOnly
+	InstantPaymentOnly bool
+	// In a parallel checkout, the seller receiving this payment request
+	// (email or merchant id) and the id correlating it in the response
+	SellerAccountId  string
+	PaymentRequestId string
+	// Agreements set up along with the checkout, e.g. for recurring
+	// payments
+	BillingAgreements []PayPalBillingAgreement
+	// Where giropay and bank transfer buyers are sent back to
+	GiropaySuccessUrl string
+	GiropayCancelUrl  string
+	BankTxnPendingUrl string
 }
 
 type PayPalDigitalGood struct {
@@ -56,7 +222,15 @@ type PayPalResponse struct {
 	Build         string
 	Token         string
 	Values        url.Values
-	usedSandbox   bool
+	// Non-fatal problems noticed by the client, e.g. truncated fields
+	Warnings    []string
+	usedSandbox bool
+	// "commit" when the buyer should pay at PayPal instead of returning to
+	// a review page
+	userAction string
+	parseMode  ParseMode
+	// When this client got the token from SetExpressCheckout
+	tokenCreatedAt time.Time
 }
 
 type PayPalPaymentResponse struct {
@@ -67,6 +241,19 @@ type PayPalPaymentResponse struct {
 	Amount        float64
 	Currency      string
 	ReasonCode    string
+	// In a parallel checkout, the request's PaymentRequestId, and the error
+	// code when this payment request failed while others succeeded
+	PaymentRequestId string
+	ErrorCode        string
+	// Set when the payment was converted to the account's holding currency:
+	// the rate applied and the amount credited in that currency
+	ExchangeRate float64
+	SettleAmount float64
+
+	InsuranceOptionSelected bool
+	// The buyer chose giropay or a bank transfer and must be sent to
+	// PayPalResponse.CompleteCheckoutUrl() to finish paying
+	RedirectRequired bool
 }
 
 type PayPalError struct {
@@ -75,6 +262,27 @@ type PayPalError struct {
 	ShortMessage string
 	LongMessage  string
 	SeverityCode string
+	// PayPal's id for the call, needed by PayPal support to investigate it
+	CorrelationId string
+	// The caller's id for the call, see ContextWithRequestId
+	RequestId string
+}
+
+// Returned when PayPal's answer could not be read as an NVP response, e.g.
+// an HTML maintenance page or a truncated body
+type TransportError struct {
+	StatusCode int
+	// Beginning of the response body
+	Snippet string
+	Err     error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("PayPal transport error (HTTP %d): %s: %q", e.StatusCode, e.Err, e.Snippet)
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
 }
 
 func (e *PayPalError) Error() string {
@@ -86,6 +294,9 @@ func (e *PayPalError) Error() string {
 	} else {
 		message = "PayPal is undergoing maintenance.\nPlease try again later."
 	}
+	if len(e.CorrelationId) != 0 {
+		message += " (correlation id " + e.CorrelationId + ")"
+	}
 
 	return message
 }
@@ -94,6 +305,22 @@ func (r *PayPalResponse) CheckoutUrl() string {
 	query := url.Values{}
 	query.Set("cmd", "_express-checkout")
 	query.Add("token", r.Token)
+	if len(r.userAction) != 0 {
+		query.Add("useraction", r.userAction)
+	}
+	checkoutUrl := CHECKOUT_PRODUCTION_URL
+	if r.usedSandbox {
+		checkoutUrl = CHECKOUT_SANDBOX_URL
+	}
+	return fmt.Sprintf("%s?%s", checkoutUrl, query.Encode())
+}
+
+// Where to send the buyer after DoExpressCheckoutPayment answered
+// REDIRECTREQUIRED=true, to complete a giropay or bank transfer payment
+func (r *PayPalResponse) CompleteCheckoutUrl() string {
+	query := url.Values{}
+	query.Set("cmd", "_complete-express-checkout")
+	query.Add("token", r.Token)
 	checkoutUrl := CHECKOUT_PRODUCTION_URL
 	if r.usedSandbox {
 		checkoutUrl = CHECKOUT_SANDBOX_URL
@@ -109,79 +336,221 @@ func SumPayPalDigitalGoodAmounts(goods *[]PayPalDigitalGood) (sum float64) {
 }
 
 func NewDefaultClient(username, password, signature string, usesSandbox bool) *PayPalClient {
-	return &PayPalClient{username, password, signature, usesSandbox, new(http.Client)}
+	return NewClient(username, password, signature, usesSandbox, new(http.Client))
 }
 
 func NewClient(username, password, signature string, usesSandbox bool, client *http.Client) *PayPalClient {
-	return &PayPalClient{username, password, signature, usesSandbox, client}
+	return &PayPalClient{username: username, password: password, signature: signature, usesSandbox: usesSandbox, client: client, tokens: newTokenTracker()}
+}
+
+// Limits the rate at which this client calls PayPal, nil removes the limit.
+// See SetSharedRateLimit to share a limit between clients.
+func (pClient *PayPalClient) SetRateLimiter(limiter RateLimiter) {
+	pClient.limiter = limiter
+}
+
+// What buildRequest passes on to Do through the request context
+type builtRequestKey struct{}
+
+type builtRequest struct {
+	method   string
+	warnings []string
+	// the account the call is made as, for SetSharedRateLimit
+	username, subject string
+	// errors are not reported, see withProbe
+	probe bool
+}
+
+func (pClient *PayPalClient) PerformRequest(values url.Values, opts ...CallOption) (*PayPalResponse, error) {
+	settings := pClient.callSettings(opts)
+	if pClient.dryRun || settings.dryRun {
+		return nil, pClient.buildDryRun(values, settings)
+	}
+	return pClient.performWithRetries(values, settings)
 }
 
-func (pClient *PayPalClient) PerformRequest(values url.Values) (*PayPalResponse, error) {
-	values.Add("USER", pClient.username)
-	values.Add("PWD", pClient.password)
-	values.Add("SIGNATURE", pClient.signature)
-	values.Add("VERSION", NVP_VERSION)
+func (pClient *PayPalClient) performRequest(values url.Values, settings callSettings) (*PayPalResponse, error) {
+	request, err := pClient.buildRequest(values, settings)
+	if err != nil {
+		return nil, err
+	}
+	return pClient.Do(request)
+}
+
+// The request PerformRequest would send for values, with the credentials
+// and VERSION added and the client's checks applied, without sending it.
+// Pass it to Do, or Close its Body when it isn't sent.
+func (pClient *PayPalClient) BuildRequest(values url.Values, opts ...CallOption) (*http.Request, error) {
+	settings := pClient.callSettings(opts)
+	// the request can be sent with Do, so it gets every check
+	settings.dryRun = false
+	return pClient.buildRequest(values, settings)
+}
 
+func (pClient *PayPalClient) buildRequest(values url.Values, settings callSettings) (*http.Request, error) {
 	endpoint := NVP_PRODUCTION_URL
 	if pClient.usesSandbox {
 		endpoint = NVP_SANDBOX_URL
 	}
 
-	formResponse, err := pClient.client.PostForm(endpoint, values)
+	if err := pClient.checkGranted(values.Get("METHOD")); err != nil {
+		return nil, err
+	}
+	if !settings.dryRun && !settings.probe {
+		if err := pClient.checkLive(values.Get("METHOD")); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkCountryCodes(values); err != nil {
+		return nil, err
+	}
+	if err := pClient.tokens.check(values.Get("TOKEN")); err != nil {
+		return nil, err
+	}
+
+	values, warnings, err := enforceFieldLengths(values, pClient.truncateFields)
 	if err != nil {
 		return nil, err
 	}
-	defer formResponse.Body.Close()
 
-	body, err := ioutil.ReadAll(formResponse.Body)
+	encoder := getEncoder()
+	encoder.writeValues(values)
+	encoder.writePair("USER", settings.credentials.Username)
+	encoder.writePair("PWD", settings.credentials.Password)
+	encoder.writePair("SIGNATURE", settings.credentials.Signature)
+	if len(settings.subject) != 0 {
+		encoder.writePair("SUBJECT", settings.subject)
+	}
+	encoder.writePair("VERSION", settings.version)
+
+	body := encoder.body()
+	request, err := http.NewRequest("POST", endpoint, nil)
 	if err != nil {
+		body.Close()
 		return nil, err
 	}
+	ctx := context.WithValue(settings.ctx, builtRequestKey{}, builtRequest{method: values.Get("METHOD"), warnings: warnings, username: settings.credentials.Username, subject: settings.subject, probe: settings.probe})
+	request = request.WithContext(ctx)
+	request.Body = body
+	request.ContentLength = int64(body.Len())
+	request.Header.Set("Content-Type", REQUEST_CONTENT_TYPE)
+	return request, nil
+}
 
-	responseValues, err := url.ParseQuery(string(body))
-	response := &PayPalResponse{usedSandbox: pClient.usesSandbox}
-	if err == nil {
-		response.Ack = responseValues.Get("ACK")
-		response.CorrelationId = responseValues.Get("CORRELATIONID")
-		response.Timestamp = responseValues.Get("TIMESTAMP")
-		response.Version = responseValues.Get("VERSION")
-		response.Build = responseValues.Get("BUILD")
-		response.Token = responseValues.Get("TOKEN")
-		response.Values = responseValues
-
-		errorCode := responseValues.Get("L_ERRORCODE0")
-		if len(errorCode) != 0 || strings.ToLower(response.Ack) == "failure" || strings.ToLower(response.Ack) == "failurewithwarning" {
-			pError := new(PayPalError)
-			pError.Ack = response.Ack
-			pError.ErrorCode = errorCode
-			pError.ShortMessage = responseValues.Get("L_SHORTMESSAGE0")
-			pError.LongMessage = responseValues.Get("L_LONGMESSAGE0")
-			pError.SeverityCode = responseValues.Get("L_SEVERITYCODE0")
-
-			err = pError
+// Sends a request made by BuildRequest, possibly altered, e.g. re-signed or
+// sent through a queue, and reads PayPal's answer like PerformRequest.
+// Retries and call logging only apply to PerformRequest.
+func (pClient *PayPalClient) Do(request *http.Request) (*PayPalResponse, error) {
+	built, _ := request.Context().Value(builtRequestKey{}).(builtRequest)
+	method, warnings := built.method, built.warnings
+	if err := request.Context().Err(); err != nil {
+		if request.Body != nil {
+			request.Body.Close()
+		}
+		return nil, err
+	}
+
+	if err := pClient.waitForLimits(request.Context(), request.URL.String(), built.username, built.subject); err != nil {
+		if request.Body != nil {
+			request.Body.Close()
+		}
+		return nil, err
+	}
+
+	started := time.Now()
+	formResponse, err := pClient.client.Do(request)
+	if err != nil {
+		pClient.reportLatency(method, started)
+		return nil, err
+	}
+	defer formResponse.Body.Close()
+
+	snippet := &snippetWriter{limit: TRANSPORT_ERROR_SNIPPET_LENGTH}
+	responseValues, err := DecodeNVP(io.TeeReader(formResponse.Body, snippet))
+	pClient.reportLatency(method, started)
+
+	// Without an ACK the body wasn't an NVP response. When there is one, a
+	// malformed pair elsewhere in the body is skipped rather than failing
+	// what may be a completed payment.
+	if len(responseValues.Get("ACK")) == 0 {
+		if err == nil {
+			err = errNotNVP
+		}
+		return nil, &TransportError{StatusCode: formResponse.StatusCode, Snippet: snippet.String(), Err: err}
+	}
+	err = nil
+
+	convertCharset(responseValues, responseCharset(formResponse.Header.Get("Content-Type")))
+	response := &PayPalResponse{Warnings: warnings, usedSandbox: pClient.usesSandbox, parseMode: pClient.parseMode}
+	response.Ack = responseValues.Get("ACK")
+	response.CorrelationId = responseValues.Get("CORRELATIONID")
+	response.Timestamp = responseValues.Get("TIMESTAMP")
+	response.Version = responseValues.Get("VERSION")
+	response.Build = responseValues.Get("BUILD")
+	response.Token = responseValues.Get("TOKEN")
+	response.Values = responseValues
+
+	if method == "SetExpressCheckout" && len(response.Token) != 0 {
+		pClient.tokens.record(response.Token)
+	}
+	response.tokenCreatedAt = pClient.tokens.createdAt(response.Token)
+
+	if n, failed := pClient.failingEntry(response); failed {
+		pError := new(PayPalError)
+		pError.Ack = response.Ack
+		pError.ErrorCode = responseValues.Get(listKey("L_ERRORCODE", n))
+		pError.ShortMessage = responseValues.Get(listKey("L_SHORTMESSAGE", n))
+		pError.LongMessage = responseValues.Get(listKey("L_LONGMESSAGE", n))
+		pError.SeverityCode = responseValues.Get(listKey("L_SEVERITYCODE", n))
+		pError.CorrelationId = response.CorrelationId
+		pError.RequestId = RequestIdFromContext(request.Context())
+
+		if !built.probe {
+			pClient.reportError(method, response, pError)
 		}
+		err = pError
+	} else if pClient.strictResponses {
+		err = checkResponseSchema(method, response)
 	}
 
 	return response, err
 }
 
 func (response *PayPalPaymentResponse) Populate(values url.Values) {
-	response.TransactionId = values.Get("PAYMENTINFO_0_TRANSACTIONID")
-	response.Status = values.Get("PAYMENTINFO_0_PAYMENTSTATUS")
-	paymentAmt := values.Get("PAYMENTINFO_0_AMT")
-	response.Amount, _ = strconv.ParseFloat(paymentAmt, 10)
-	feeAmt := values.Get("PAYMENTINFO_0_FEEAMT")
-	response.Fee, _ = strconv.ParseFloat(feeAmt, 10)
-	response.Currency = values.Get("PAYMENTINFO_0_CURRENCYCODE")
-	response.Type = values.Get("PAYMENTINFO_0_PAYMENTTYPE")
-	response.ReasonCode = values.Get("PAYMENTINFO_0_REASONCODE")
-}
-
-func (pClient *PayPalClient) SetExpressCheckoutDigitalGoods(paymentAmount float64, currencyCode string, returnURL, cancelURL string, goods []PayPalDigitalGood) (*PayPalResponse, error) {
+	response.decode(&fieldParser{values: values})
+}
+
+func (response *PayPalPaymentResponse) decode(p *fieldParser) {
+	values := p.values
+	response.populate(p, "PAYMENTINFO_0_")
+	response.InsuranceOptionSelected = parseNVPBool(values.Get("INSURANCEOPTIONSELECTED"))
+	response.RedirectRequired = parseNVPBool(values.Get("REDIRECTREQUIRED"))
+}
+
+func (response *PayPalPaymentResponse) populate(p *fieldParser, prefix string) {
+	values := p.values
+	response.TransactionId = values.Get(prefix + "TRANSACTIONID")
+	response.PaymentRequestId = values.Get(prefix + "PAYMENTREQUESTID")
+	response.Status = values.Get(prefix + "PAYMENTSTATUS")
+	response.Amount = p.float(prefix + "AMT")
+	response.Fee = p.float(prefix + "FEEAMT")
+	response.Currency = values.Get(prefix + "CURRENCYCODE")
+	response.Type = values.Get(prefix + "PAYMENTTYPE")
+	response.ReasonCode = values.Get(prefix + "REASONCODE")
+	response.ErrorCode = values.Get(prefix + "ERRORCODE")
+	response.ExchangeRate = p.float(prefix + "EXCHANGERATE")
+	response.SettleAmount = p.float(prefix + "SETTLEAMT")
+}
+
+func (pClient *PayPalClient) SetExpressCheckoutDigitalGoods(paymentAmount float64, currencyCode string, returnURL, cancelURL string, goods []PayPalDigitalGood, opts ...CallOption) (*PayPalResponse, error) {
+	if err := checkLineItemCount(len(goods)); err != nil {
+		return nil, err
+	}
+
 	values := url.Values{}
 	values.Set("METHOD", "SetExpressCheckout")
-	values.Add("PAYMENTREQUEST_0_AMT", fmt.Sprintf("%.2f", paymentAmount))
-	values.Add("PAYMENTREQUEST_0_PAYMENTACTION", "Sale")
+	values.Add("PAYMENTREQUEST_0_AMT", formatAmount(paymentAmount))
+	values.Add("PAYMENTREQUEST_0_PAYMENTACTION", string(PAYMENT_ACTION_SALE))
 	values.Add("PAYMENTREQUEST_0_CURRENCYCODE", currencyCode)
 	values.Add("RETURNURL", returnURL)
 	values.Add("CANCELURL", cancelURL)
@@ -192,71 +561,247 @@ func (pClient *PayPalClient) SetExpressCheckoutDigitalGoods(paymentAmount float6
 	for i := 0; i < len(goods); i++ {
 		good := goods[i]
 
-		values.Add(fmt.Sprintf("%s%d", "L_PAYMENTREQUEST_0_NAME", i), good.Name)
-		values.Add(fmt.Sprintf("%s%d", "L_PAYMENTREQUEST_0_AMT", i), fmt.Sprintf("%.2f", good.Amount))
-		values.Add(fmt.Sprintf("%s%d", "L_PAYMENTREQUEST_0_QTY", i), fmt.Sprintf("%d", good.Quantity))
-		values.Add(fmt.Sprintf("%s%d", "L_PAYMENTREQUEST_0_ITEMCATEGORY", i), "Digital")
+		values.Add(listKey("L_PAYMENTREQUEST_0_NAME", i), good.Name)
+		values.Add(listKey("L_PAYMENTREQUEST_0_AMT", i), formatAmount(good.Amount))
+		values.Add(listKey("L_PAYMENTREQUEST_0_QTY", i), strconv.Itoa(good.Quantity))
+		values.Add(listKey("L_PAYMENTREQUEST_0_ITEMCATEGORY", i), "Digital")
+	}
+
+	return pClient.PerformRequest(values, opts...)
+}
+
+func (pClient *PayPalClient) SetExpressCheckout(order PayPalOrder, goods []PayPalGood, opts ...CallOption) (*PayPalResponse, error) {
+	values, err := setExpressCheckoutValues(order, goods)
+	if err != nil {
+		return nil, err
 	}
 
-	return pClient.PerformRequest(values)
+	response, err := pClient.PerformRequest(values, opts...)
+	if err == nil {
+		err = pClient.saveOrder(response, order, goods)
+	}
+	return response, err
 }
 
-func (pClient *PayPalClient) SetExpressCheckout(order PayPalOrder, goods []PayPalGood) (*PayPalResponse, error) {
+func setExpressCheckoutValues(order PayPalOrder, goods []PayPalGood) (url.Values, error) {
 	values := url.Values{}
 	values.Set("METHOD", "SetExpressCheckout")
-	values.Add("PAYMENTREQUEST_0_ITEMAMT", fmt.Sprintf("%.2f", order.SubTotal))
-	values.Add("PAYMENTREQUEST_0_SHIPPINGAMT", fmt.Sprintf("%.2f", order.Shipping))
-	values.Add("PAYMENTREQUEST_0_AMT", fmt.Sprintf("%.2f", order.Total))
-	values.Add("PAYMENTREQUEST_0_PAYMENTACTION", "Sale")
-	values.Add("PAYMENTREQUEST_0_CURRENCYCODE", order.CurrencyCode)
+	if err := addPaymentRequest(values, order, goods); err != nil {
+		return nil, err
+	}
+	if order.InsuranceOffered {
+		values.Add("PAYMENTREQUEST_0_INSURANCEOPTIONOFFERED", "true")
+	}
+	if order.AllowNote {
+		values.Add("ALLOWNOTE", "1")
+	}
+	if order.MaxAmount > 0 {
+		values.Add("MAXAMT", formatAmount(order.MaxAmount))
+	}
+	values.Add("PAYMENTREQUEST_0_PAYMENTACTION", string(PAYMENT_ACTION_SALE))
 	values.Add("RETURNURL", order.ReturnUrl)
 	values.Add("CANCELURL", order.CancelUrl)
-	values.Add("REQCONFIRMSHIPPING", "0")
-	values.Add("NOSHIPPING", "1")
-	values.Add("SOLUTIONTYPE", "Sole")
+	addShippingBehavior(values, order.ShippingBehavior)
+	if order.AddressOverride {
+		values.Add("ADDROVERRIDE", "1")
+	}
+	solutionType := order.SolutionType
+	if len(solutionType) == 0 {
+		solutionType = SOLUTION_TYPE_SOLE
+	}
+	values.Add("SOLUTIONTYPE", string(solutionType))
+	if len(order.ChannelType) != 0 {
+		values.Add("CHANNELTYPE", string(order.ChannelType))
+	}
+	if len(order.GiropaySuccessUrl) != 0 {
+		values.Add("GIROPAYSUCCESSURL", order.GiropaySuccessUrl)
+	}
+	if len(order.GiropayCancelUrl) != 0 {
+		values.Add("GIROPAYCANCELURL", order.GiropayCancelUrl)
+	}
+	if len(order.BankTxnPendingUrl) != 0 {
+		values.Add("BANKTXNPENDINGURL", order.BankTxnPendingUrl)
+	}
+	for i, agreement := range order.BillingAgreements {
+		values.Add(listKey("L_BILLINGTYPE", i), string(agreement.Type))
+		values.Add(listKey("L_BILLINGAGREEMENTDESCRIPTION", i), agreement.Description)
+		if len(agreement.Custom) != 0 {
+			values.Add(listKey("L_BILLINGAGREEMENTCUSTOM", i), agreement.Custom)
+		}
+		if len(agreement.PaymentType) != 0 {
+			values.Add(listKey("L_PAYMENTTYPE", i), agreement.PaymentType)
+		}
+	}
+
+	return values, nil
+}
+
+func addShippingBehavior(values url.Values, behavior ShippingBehavior) {
+	noShipping, confirm := "1", "0"
+	switch behavior {
+	case SHIPPING_OPTIONAL:
+		noShipping = "0"
+	case SHIPPING_REQUIRED:
+		noShipping = "2"
+	case SHIPPING_CONFIRMED:
+		noShipping, confirm = "2", "1"
+	}
+	values.Add("REQCONFIRMSHIPPING", confirm)
+	values.Add("NOSHIPPING", noShipping)
+}
 
+// Adds the amounts, description and line items of order as PAYMENTREQUEST_0
+func addPaymentRequest(values url.Values, order PayPalOrder, goods []PayPalGood) error {
+	return addNthPaymentRequest(values, 0, order, goods)
+}
+
+// Adds the PAYMENTREQUEST_n_ fields of a checkout's nth payment request
+func addNthPaymentRequest(values url.Values, n int, order PayPalOrder, goods []PayPalGood) error {
 	goodsCount := len(goods)
+	lineItems := goodsCount
+	if order.Discount > 0 && order.DiscountStrategy == DISCOUNT_LINE_ITEM {
+		lineItems++
+	}
+	if err := checkLineItemCount(lineItems); err != nil {
+		return err
+	}
+
+	prefix := "PAYMENTREQUEST_" + strconv.Itoa(n) + "_"
+	itemPrefix := "L_" + prefix
+	values.Add(prefix+"ITEMAMT", formatAmount(order.SubTotal))
+	values.Add(prefix+"SHIPPINGAMT", formatAmount(order.Shipping))
+	if order.Tax > 0 {
+		values.Add(prefix+"TAXAMT", formatAmount(order.Tax))
+	}
+	if order.Handling > 0 {
+		values.Add(prefix+"HANDLINGAMT", formatAmount(order.Handling))
+	}
+	if order.Insurance > 0 {
+		values.Add(prefix+"INSURANCEAMT", formatAmount(order.Insurance))
+	}
+	if order.Discount > 0 && order.DiscountStrategy == DISCOUNT_SHIPPING_DISCOUNT {
+		values.Add(prefix+"SHIPDISCAMT", formatAmount(-order.Discount))
+	}
+	values.Add(prefix+"AMT", formatAmount(order.Total))
+	values.Add(prefix+"CURRENCYCODE", order.CurrencyCode)
+	if len(order.Description) != 0 {
+		values.Add(prefix+"DESC", order.Description)
+	}
+	if len(order.NoteText) != 0 {
+		values.Add(prefix+"NOTETEXT", order.NoteText)
+	}
+	if order.InstantPaymentOnly {
+		values.Add(prefix+"ALLOWEDPAYMENTMETHOD", "InstantPaymentOnly")
+	}
+	if len(order.SellerAccountId) != 0 {
+		values.Add(prefix+"SELLERPAYPALACCOUNTID", order.SellerAccountId)
+	}
+	if len(order.PaymentRequestId) != 0 {
+		values.Add(prefix+"PAYMENTREQUESTID", order.PaymentRequestId)
+	}
+	if order.ShippingAddress != nil {
+		address := order.ShippingAddress.Normalize()
+		if err := address.Validate(); err != nil {
+			return err
+		}
+		address.AddNVP(values, prefix+"SHIPTO")
+	}
 
 	for i := 0; i < goodsCount; i++ {
 		good := goods[i]
 		if good.Id != "" {
-			values.Add(fmt.Sprintf("%s%d", "L_PAYMENTREQUEST_0_NUMBER", i), good.Id)
+			values.Add(listKey(itemPrefix+"NUMBER", i), good.Id)
 		}
-		values.Add(fmt.Sprintf("%s%d", "L_PAYMENTREQUEST_0_NAME", i), good.Name)
-		values.Add(fmt.Sprintf("%s%d", "L_PAYMENTREQUEST_0_AMT", i), fmt.Sprintf("%.2f", good.Amount))
-		values.Add(fmt.Sprintf("%s%d", "L_PAYMENTREQUEST_0_QTY", i), fmt.Sprintf("%d", good.Quantity))
+		values.Add(listKey(itemPrefix+"NAME", i), good.Name)
+		values.Add(listKey(itemPrefix+"AMT", i), formatAmount(good.Amount))
+		values.Add(listKey(itemPrefix+"QTY", i), strconv.Itoa(good.Quantity))
 	}
 
-	if order.Discount > 0 {
-		values.Add(fmt.Sprintf("%s%d", "L_PAYMENTREQUEST_0_NAME", goodsCount), "DISCOUNT")
-		values.Add(fmt.Sprintf("%s%d", "L_PAYMENTREQUEST_0_AMT", goodsCount), fmt.Sprintf("%.2f", -order.Discount))
-		values.Add(fmt.Sprintf("%s%d", "L_PAYMENTREQUEST_0_QTY", goodsCount), "1")
+	if order.Discount > 0 && order.DiscountStrategy == DISCOUNT_LINE_ITEM {
+		values.Add(listKey(itemPrefix+"NAME", goodsCount), "DISCOUNT")
+		values.Add(listKey(itemPrefix+"AMT", goodsCount), formatAmount(-order.Discount))
+		values.Add(listKey(itemPrefix+"QTY", goodsCount), "1")
 	}
 
-	return pClient.PerformRequest(values)
+	return nil
 }
 
 // Convenience function for Sale (Charge)
-func (pClient *PayPalClient) DoExpressCheckoutSale(token, payerId, currencyCode string, finalPaymentAmount float64) (*PayPalResponse, error) {
-	return pClient.DoExpressCheckoutPayment(token, payerId, "Sale", currencyCode, finalPaymentAmount)
+func (pClient *PayPalClient) DoExpressCheckoutSale(token, payerId, currencyCode string, finalPaymentAmount float64, opts ...CallOption) (*PayPalResponse, error) {
+	return pClient.DoExpressCheckoutPayment(token, payerId, PAYMENT_ACTION_SALE, currencyCode, finalPaymentAmount, opts...)
 }
 
-// paymentType can be "Sale" or "Authorization" or "Order" (ship later)
-func (pClient *PayPalClient) DoExpressCheckoutPayment(token, payerId, paymentType, currencyCode string, finalPaymentAmount float64) (*PayPalResponse, error) {
+func (pClient *PayPalClient) DoExpressCheckoutPayment(token, payerId string, paymentAction PaymentAction, currencyCode string, finalPaymentAmount float64, opts ...CallOption) (*PayPalResponse, error) {
+	if err := paymentAction.validate(); err != nil {
+		return nil, err
+	}
+
+	stored, err := pClient.matchingOrder(token, currencyCode, finalPaymentAmount)
+	if err != nil {
+		return nil, err
+	}
+	if stored != nil {
+		return pClient.DoExpressCheckoutOrderPayment(token, payerId, paymentAction, stored.Order, stored.Goods, opts...)
+	}
+
 	values := url.Values{}
 	values.Set("METHOD", "DoExpressCheckoutPayment")
 	values.Add("TOKEN", token)
 	values.Add("PAYERID", payerId)
-	values.Add("PAYMENTREQUEST_0_PAYMENTACTION", paymentType)
+	values.Add("PAYMENTREQUEST_0_PAYMENTACTION", string(paymentAction))
 	values.Add("PAYMENTREQUEST_0_CURRENCYCODE", currencyCode)
-	values.Add("PAYMENTREQUEST_0_AMT", fmt.Sprintf("%.2f", finalPaymentAmount))
+	values.Add("PAYMENTREQUEST_0_AMT", formatAmount(finalPaymentAmount))
 
-	return pClient.PerformRequest(values)
+	response, err := pClient.PerformRequest(values, opts...)
+	if err == nil {
+		pClient.forgetOrder(token)
+		pClient.forgetDetails(token, opts)
+	}
+	return response, err
 }
 
-func (pClient *PayPalClient) GetExpressCheckoutDetails(token string) (*PayPalResponse, error) {
+// Like DoExpressCheckoutPayment, but sends the whole order (amounts, line
+// items, description and note) with the payment rather than only its total
+func (pClient *PayPalClient) DoExpressCheckoutOrderPayment(token, payerId string, paymentAction PaymentAction, order PayPalOrder, goods []PayPalGood, opts ...CallOption) (*PayPalResponse, error) {
+	if err := paymentAction.validate(); err != nil {
+		return nil, err
+	}
+	if err := checkMaxAmount(token, order.Total, order.MaxAmount); err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	values.Set("METHOD", "DoExpressCheckoutPayment")
+	values.Add("TOKEN", token)
+	values.Add("PAYERID", payerId)
+	values.Add("PAYMENTREQUEST_0_PAYMENTACTION", string(paymentAction))
+	if err := addPaymentRequest(values, order, goods); err != nil {
+		return nil, err
+	}
+
+	response, err := pClient.PerformRequest(values, opts...)
+	if err == nil {
+		pClient.forgetOrder(token)
+		pClient.forgetDetails(token, opts)
+	}
+	return response, err
+}
+
+func (pClient *PayPalClient) GetExpressCheckoutDetails(token string, opts ...CallOption) (*PayPalResponse, error) {
+	if err := pClient.tokens.check(token); err != nil {
+		return nil, err
+	}
+	if cached := pClient.lookupDetails(token, opts); cached != nil {
+		return cached, nil
+	}
+
 	values := url.Values{}
 	values.Add("TOKEN", token)
 	values.Set("METHOD", "GetExpressCheckoutDetails")
-	return pClient.PerformRequest(values)
+
+	response, err := pClient.PerformRequest(values, opts...)
+	if err == nil {
+		pClient.cacheDetails(token, opts, response)
+	}
+	return response, err
 }
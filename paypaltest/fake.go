@@ -0,0 +1,202 @@
+// Package paypaltest provides a fake paypal.PayPalAPI for unit tests of code
+// calling PayPal, with programmable answers and a record of every call.
+//
+//	fake := paypaltest.NewFake()
+//	fake.On("SetExpressCheckout", paypaltest.Response("ACK=Success&TOKEN=EC-1"), nil)
+//	checkout := NewCheckoutService(fake)
+//	...
+//	if calls := fake.CallsTo("SetExpressCheckout"); len(calls) != 1 { ... }
+//
+// For tests of the requests themselves, NewClient returns a real
+// PayPalClient whose calls are recorded by a Transport, and
+// AssertGoldenNVP compares them against known-good payloads on disk.
+package paypaltest
+
+import (
+	"net/url"
+	"sync"
+
+	"github.com/badoet/go-paypal"
+)
+
+// One call made to the Fake. Args are the method's arguments in order,
+// without the CallOptions.
+type Call struct {
+	Method string
+	Args   []interface{}
+	Opts   []paypal.CallOption
+}
+
+// Computes the answer to a call
+type AnswerFunc func(call Call) (*paypal.PayPalResponse, error)
+
+// Safe for concurrent use. Methods without a programmed answer succeed with
+// an empty ACK=Success response.
+type Fake struct {
+	mu      sync.Mutex
+	answers map[string]AnswerFunc
+	calls   []Call
+}
+
+var _ paypal.PayPalAPI = (*Fake)(nil)
+
+func NewFake() *Fake {
+	return &Fake{answers: make(map[string]AnswerFunc)}
+}
+
+// Makes every call to method return response and err. method is the Go
+// method name, e.g. "DoExpressCheckoutPayment".
+func (f *Fake) On(method string, response *paypal.PayPalResponse, err error) {
+	f.OnFunc(method, func(Call) (*paypal.PayPalResponse, error) {
+		return response, err
+	})
+}
+
+// Makes every call to method fail with the PayPal error errorCode, as
+// PayPalClient does when PayPal answers ACK=Failure
+func (f *Fake) OnFailure(method, errorCode, shortMessage string) {
+	response := Response("ACK=Failure&L_ERRORCODE0=" + url.QueryEscape(errorCode) + "&L_SHORTMESSAGE0=" + url.QueryEscape(shortMessage))
+	f.On(method, response, &paypal.PayPalError{Ack: "Failure", ErrorCode: errorCode, ShortMessage: shortMessage})
+}
+
+func (f *Fake) OnFunc(method string, answer AnswerFunc) {
+	f.mu.Lock()
+	f.answers[method] = answer
+	f.mu.Unlock()
+}
+
+// Every call made so far, in order
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+func (f *Fake) CallsTo(method string) []Call {
+	var matching []Call
+	for _, call := range f.Calls() {
+		if call.Method == method {
+			matching = append(matching, call)
+		}
+	}
+	return matching
+}
+
+// Forgets the recorded calls, keeping the programmed answers
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	f.calls = nil
+	f.mu.Unlock()
+}
+
+func (f *Fake) call(method string, opts []paypal.CallOption, args ...interface{}) (*paypal.PayPalResponse, error) {
+	call := Call{Method: method, Args: args, Opts: opts}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	answer := f.answers[method]
+	f.mu.Unlock()
+
+	if answer == nil {
+		return Response("ACK=Success"), nil
+	}
+	return answer(call)
+}
+
+// Builds a response as PayPalClient would from an NVP answer body, e.g.
+// "ACK=Success&TOKEN=EC-1". Panics on a malformed body.
+func Response(nvp string) *paypal.PayPalResponse {
+	values, err := url.ParseQuery(nvp)
+	if err != nil {
+		panic("paypaltest: malformed NVP response: " + err.Error())
+	}
+	return &paypal.PayPalResponse{
+		Ack:           values.Get("ACK"),
+		CorrelationId: values.Get("CORRELATIONID"),
+		Timestamp:     values.Get("TIMESTAMP"),
+		Version:       values.Get("VERSION"),
+		Build:         values.Get("BUILD"),
+		Token:         values.Get("TOKEN"),
+		Values:        values,
+	}
+}
+
+func (f *Fake) PerformRequest(values url.Values, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("PerformRequest", opts, values)
+}
+
+func (f *Fake) SetExpressCheckout(order paypal.PayPalOrder, goods []paypal.PayPalGood, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("SetExpressCheckout", opts, order, goods)
+}
+
+func (f *Fake) SetExpressCheckoutDigitalGoods(paymentAmount float64, currencyCode string, returnURL, cancelURL string, goods []paypal.PayPalDigitalGood, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("SetExpressCheckoutDigitalGoods", opts, paymentAmount, currencyCode, returnURL, cancelURL, goods)
+}
+
+func (f *Fake) SetExpressCheckoutMark(order paypal.PayPalOrder, goods []paypal.PayPalGood, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("SetExpressCheckoutMark", opts, order, goods)
+}
+
+func (f *Fake) GetExpressCheckoutDetails(token string, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("GetExpressCheckoutDetails", opts, token)
+}
+
+func (f *Fake) DoExpressCheckoutSale(token, payerId, currencyCode string, finalPaymentAmount float64, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("DoExpressCheckoutSale", opts, token, payerId, currencyCode, finalPaymentAmount)
+}
+
+func (f *Fake) DoExpressCheckoutPayment(token, payerId string, paymentAction paypal.PaymentAction, currencyCode string, finalPaymentAmount float64, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("DoExpressCheckoutPayment", opts, token, payerId, paymentAction, currencyCode, finalPaymentAmount)
+}
+
+func (f *Fake) DoExpressCheckoutOrderPayment(token, payerId string, paymentAction paypal.PaymentAction, order paypal.PayPalOrder, goods []paypal.PayPalGood, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("DoExpressCheckoutOrderPayment", opts, token, payerId, paymentAction, order, goods)
+}
+
+func (f *Fake) DoAuthorization(transactionId string, amount float64, currencyCode string, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("DoAuthorization", opts, transactionId, amount, currencyCode)
+}
+
+func (f *Fake) DoReauthorization(authorizationId string, amount float64, currencyCode string, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("DoReauthorization", opts, authorizationId, amount, currencyCode)
+}
+
+func (f *Fake) DoCapture(capture paypal.PayPalCapture, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("DoCapture", opts, capture)
+}
+
+func (f *Fake) DoVoid(authorizationId, note string, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("DoVoid", opts, authorizationId, note)
+}
+
+func (f *Fake) RefundTransaction(refund paypal.PayPalRefund, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("RefundTransaction", opts, refund)
+}
+
+func (f *Fake) TransactionSearch(search paypal.PayPalTransactionSearch, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("TransactionSearch", opts, search)
+}
+
+func (f *Fake) GetTransactionDetails(transactionId string, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("GetTransactionDetails", opts, transactionId)
+}
+
+func (f *Fake) GetBalance(allCurrencies bool, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("GetBalance", opts, allCurrencies)
+}
+
+func (f *Fake) CreateRecurringPaymentsProfile(request paypal.PayPalRecurringProfileRequest, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("CreateRecurringPaymentsProfile", opts, request)
+}
+
+func (f *Fake) GetRecurringPaymentsProfileDetails(profileId string, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("GetRecurringPaymentsProfileDetails", opts, profileId)
+}
+
+func (f *Fake) ManageRecurringPaymentsProfileStatus(profileId string, action paypal.ProfileAction, note string, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("ManageRecurringPaymentsProfileStatus", opts, profileId, action, note)
+}
+
+func (f *Fake) BillOutstandingAmount(profileId string, amount float64, note string, opts ...paypal.CallOption) (*paypal.PayPalResponse, error) {
+	return f.call("BillOutstandingAmount", opts, profileId, amount, note)
+}
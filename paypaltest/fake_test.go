@@ -0,0 +1,40 @@
+package paypaltest
+
+import (
+	"testing"
+
+	"github.com/badoet/go-paypal"
+)
+
+// What an application would write against the interface
+func refundOrder(api paypal.PayPalAPI, transactionId string) (string, error) {
+	response, err := api.RefundTransaction(paypal.PayPalRefund{TransactionId: transactionId, RefundType: paypal.REFUND_TYPE_FULL})
+	if err != nil {
+		return "", err
+	}
+	return response.Values.Get("REFUNDTRANSACTIONID"), nil
+}
+
+func TestFake(t *testing.T) {
+	fake := NewFake()
+	fake.On("RefundTransaction", Response("ACK=Success&REFUNDTRANSACTIONID=RF1"), nil)
+
+	refundId, err := refundOrder(fake, "TX1")
+	if err != nil || refundId != "RF1" {
+		t.Fatalf("Unexpected answer: %q, %v", refundId, err)
+	}
+	calls := fake.CallsTo("RefundTransaction")
+	if len(calls) != 1 || calls[0].Args[0].(paypal.PayPalRefund).TransactionId != "TX1" {
+		t.Errorf("Call not recorded: %#v", fake.Calls())
+	}
+
+	fake.OnFailure("RefundTransaction", "10009", "Transaction refused")
+	if _, err := refundOrder(fake, "TX2"); err == nil || err.(*paypal.PayPalError).ErrorCode != "10009" {
+		t.Errorf("Expected the programmed failure, got %v", err)
+	}
+
+	fake.Reset()
+	if response, err := fake.GetBalance(false); err != nil || response.Ack != "Success" || len(fake.Calls()) != 1 {
+		t.Errorf("Expected unprogrammed calls to succeed and be recorded")
+	}
+}
@@ -0,0 +1,218 @@
+package paypaltest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/badoet/go-paypal"
+)
+
+// Set to 1 to have the golden comparisons rewrite their files instead of
+// failing, after checking the new payloads are right
+const UPDATE_GOLDEN_ENV = "PAYPALTEST_UPDATE_GOLDEN"
+
+// Fields left out of golden comparisons since they change with the
+// credentials rather than the request
+var ignoredGoldenFields = []string{"USER", "PWD", "SIGNATURE", "SUBJECT", "VERSION"}
+
+// http.RoundTripper recording the NVP requests a real PayPalClient sends,
+// answering them with Answer, or ACK=Success when Answer is nil
+type Transport struct {
+	Answer func(values url.Values) string
+
+	mu       sync.Mutex
+	requests []url.Values
+}
+
+// A client sending its calls to a new Transport
+func NewClient(answer func(values url.Values) string) (*paypal.PayPalClient, *Transport) {
+	transport := &Transport{Answer: answer}
+	return paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: transport}), transport
+}
+
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.requests = append(t.requests, values)
+	t.mu.Unlock()
+
+	answer := "ACK=Success"
+	if t.Answer != nil {
+		answer = t.Answer(values)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+		Body:       ioutil.NopCloser(strings.NewReader(answer)),
+		Request:    r,
+	}, nil
+}
+
+// Every request sent so far, in order
+func (t *Transport) Requests() []url.Values {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]url.Values(nil), t.requests...)
+}
+
+// The most recent request, nil before the first one
+func (t *Transport) LastRequest() url.Values {
+	requests := t.Requests()
+	if len(requests) == 0 {
+		return nil
+	}
+	return requests[len(requests)-1]
+}
+
+// Reads an NVP fixture: either a single urlencoded line, as PayPal sends
+// them, or one KEY=value pair per line as written by AssertGoldenNVP. Blank
+// lines and lines starting with # are skipped.
+func LoadNVP(t testing.TB, path string) url.Values {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("paypaltest: %v", err)
+	}
+	values := url.Values{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parsed, err := url.ParseQuery(line)
+		if err != nil {
+			t.Fatalf("paypaltest: %s: %v", path, err)
+		}
+		for key, list := range parsed {
+			values[key] = append(values[key], list...)
+		}
+	}
+	return values
+}
+
+// Reads a JSON fixture into v
+func LoadJSON(t testing.TB, path string, v interface{}) {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("paypaltest: %v", err)
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		t.Fatalf("paypaltest: %s: %v", path, err)
+	}
+}
+
+// One KEY=value line per field, sorted, without the credential fields
+func formatGoldenNVP(values url.Values) string {
+	var lines []string
+	for key, list := range values {
+		if isIgnoredGoldenField(key) {
+			continue
+		}
+		for _, value := range list {
+			lines = append(lines, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func isIgnoredGoldenField(key string) bool {
+	for _, ignored := range ignoredGoldenFields {
+		if key == ignored {
+			return true
+		}
+	}
+	return false
+}
+
+// Fails t unless values, e.g. Transport.LastRequest(), match the golden
+// file at path field for field. Credential fields are ignored.
+func AssertGoldenNVP(t testing.TB, path string, values url.Values) {
+	t.Helper()
+
+	got := formatGoldenNVP(values)
+	if os.Getenv(UPDATE_GOLDEN_ENV) == "1" {
+		if err := ioutil.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("paypaltest: %v", err)
+		}
+		return
+	}
+
+	want := LoadNVP(t, path)
+	var diffs []string
+	for key := range union(want, values) {
+		if isIgnoredGoldenField(key) {
+			continue
+		}
+		if wanted, sent := strings.Join(want[key], "\x00"), strings.Join(values[key], "\x00"); wanted != sent {
+			diffs = append(diffs, key+": want "+quote(want[key])+", got "+quote(values[key]))
+		}
+	}
+	if len(diffs) != 0 {
+		sort.Strings(diffs)
+		t.Errorf("paypaltest: request differs from %s (set %s=1 to update):\n\t%s", path, UPDATE_GOLDEN_ENV, strings.Join(diffs, "\n\t"))
+	}
+}
+
+// Fails t unless v, encoded as JSON, matches the JSON in the golden file at
+// path, ignoring formatting and key order
+func AssertGoldenJSON(t testing.TB, path string, v interface{}) {
+	t.Helper()
+
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("paypaltest: %v", err)
+	}
+	if os.Getenv(UPDATE_GOLDEN_ENV) == "1" {
+		if err = ioutil.WriteFile(path, append(got, '\n'), 0644); err != nil {
+			t.Fatalf("paypaltest: %v", err)
+		}
+		return
+	}
+
+	var want, have interface{}
+	LoadJSON(t, path, &want)
+	json.Unmarshal(got, &have)
+	wantJSON, _ := json.Marshal(want)
+	haveJSON, _ := json.Marshal(have)
+	if string(wantJSON) != string(haveJSON) {
+		t.Errorf("paypaltest: JSON differs from %s (set %s=1 to update):\nwant %s\ngot  %s", path, UPDATE_GOLDEN_ENV, wantJSON, haveJSON)
+	}
+}
+
+func union(a, b url.Values) map[string]bool {
+	keys := make(map[string]bool, len(a)+len(b))
+	for key := range a {
+		keys[key] = true
+	}
+	for key := range b {
+		keys[key] = true
+	}
+	return keys
+}
+
+func quote(list []string) string {
+	if len(list) == 0 {
+		return "(missing)"
+	}
+	return `"` + strings.Join(list, `", "`) + `"`
+}
@@ -0,0 +1,39 @@
+package paypaltest
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/badoet/go-paypal"
+)
+
+func TestGoldenRequest(t *testing.T) {
+	client, transport := NewClient(nil)
+
+	order := paypal.PayPalOrder{SubTotal: 19.98, Shipping: 5, Total: 24.98, CurrencyCode: "USD", ReturnUrl: "https://example.com/return", CancelUrl: "https://example.com/cancel", Description: "Order #7"}
+	goods := []paypal.PayPalGood{{Id: "SKU-1", Name: "Mug", Amount: 9.99, Quantity: 2}}
+	if _, err := client.SetExpressCheckout(order, goods); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	AssertGoldenNVP(t, "testdata/set_express_checkout.golden", transport.LastRequest())
+
+	if transport.LastRequest().Get("USER") != "user" {
+		t.Errorf("Expected the credentials to be sent, just not compared")
+	}
+}
+
+func TestGoldenResponse(t *testing.T) {
+	client, _ := NewClient(func(values url.Values) string {
+		return LoadNVP(t, "testdata/get_express_checkout_details.nvp").Encode()
+	})
+
+	response, err := client.GetExpressCheckoutDetails("EC-5YJ748178G052312W")
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	details := new(paypal.PayPalCheckoutDetails)
+	if err = response.Decode(details); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	AssertGoldenJSON(t, "testdata/get_express_checkout_details.json", details)
+}
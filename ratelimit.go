@@ -0,0 +1,171 @@
+package paypal
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Shared limiters nobody asked for by name are dropped after sitting idle
+// this long, see SetSharedRateLimit
+const SHARED_LIMITER_IDLE = 10 * time.Minute
+
+type sharedLimiter struct {
+	limiter *intervalLimiter
+	// returned by SharedRateLimiter, and maybe held by a client
+	pinned bool
+}
+
+var sharedLimiters = struct {
+	sync.Mutex
+	byAccount map[string]*sharedLimiter
+	swept     time.Time
+}{byAccount: make(map[string]*sharedLimiter)}
+
+type RateLimiter interface {
+	// Wait blocks until the caller may make its next call
+	Wait()
+}
+
+// Implemented by limiters whose wait can be cut short, such as those from
+// NewRateLimiter; the client uses it with the call's context
+type ContextRateLimiter interface {
+	RateLimiter
+	// Returns ctx's error, without using up the caller's turn, when ctx
+	// is done before the caller may make its call
+	WaitContext(ctx context.Context) error
+}
+
+type intervalLimiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time
+}
+
+// Limiter spacing calls evenly so no more than callsPerSecond start each
+// second, shared by every goroutine using it. callsPerSecond <= 0 means no
+// limit.
+func NewRateLimiter(callsPerSecond float64) RateLimiter {
+	return newIntervalLimiter(callsPerSecond)
+}
+
+func newIntervalLimiter(callsPerSecond float64) *intervalLimiter {
+	if callsPerSecond <= 0 {
+		return &intervalLimiter{}
+	}
+	return &intervalLimiter{interval: time.Duration(float64(time.Second) / callsPerSecond)}
+}
+
+func (l *intervalLimiter) reserve() (now, start time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now = time.Now()
+	start = l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(l.interval)
+	return now, start
+}
+
+func (l *intervalLimiter) Wait() {
+	now, start := l.reserve()
+	time.Sleep(start.Sub(now))
+}
+
+func (l *intervalLimiter) WaitContext(ctx context.Context) error {
+	now, start := l.reserve()
+	if !start.After(now) {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(start.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// hand the turn back unless a later caller already queued behind it
+		l.mu.Lock()
+		if l.next.Equal(start.Add(l.interval)) {
+			l.next = start
+		}
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (l *intervalLimiter) idle(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return now.Sub(l.next) > SHARED_LIMITER_IDLE
+}
+
+// Waits on limiter, which may be nil, until ctx is done
+func waitLimiter(ctx context.Context, limiter RateLimiter) error {
+	if limiter == nil {
+		return ctx.Err()
+	}
+	if contextLimiter, ok := limiter.(ContextRateLimiter); ok {
+		return contextLimiter.WaitContext(ctx)
+	}
+	limiter.Wait()
+	return ctx.Err()
+}
+
+// Returns the process-wide limiter for calls to endpoint made with the API
+// username on behalf of subject (empty for the username's own account),
+// creating it with callsPerSecond on first use. Clients calling as the same
+// account (e.g. one per tenant request) and given this limiter share a
+// single budget, matching PayPal's per-account limits. Later calls for the
+// same account return the existing limiter unchanged. Limiters returned
+// here are kept for the life of the process.
+func SharedRateLimiter(endpoint, username, subject string, callsPerSecond float64) RateLimiter {
+	return lookupSharedLimiter(endpoint+"|"+username+"|"+subject, callsPerSecond, true)
+}
+
+func lookupSharedLimiter(key string, callsPerSecond float64, pin bool) *intervalLimiter {
+	now := time.Now()
+
+	sharedLimiters.Lock()
+	defer sharedLimiters.Unlock()
+
+	if now.Sub(sharedLimiters.swept) > SHARED_LIMITER_IDLE/10 {
+		for accountKey, shared := range sharedLimiters.byAccount {
+			if !shared.pinned && shared.limiter.idle(now) {
+				delete(sharedLimiters.byAccount, accountKey)
+			}
+		}
+		sharedLimiters.swept = now
+	}
+
+	shared, ok := sharedLimiters.byAccount[key]
+	if !ok {
+		shared = &sharedLimiter{limiter: newIntervalLimiter(callsPerSecond)}
+		sharedLimiters.byAccount[key] = shared
+	}
+	shared.pinned = shared.pinned || pin
+	return shared.limiter
+}
+
+// Makes every call wait on the SharedRateLimiter of the account it is made
+// as, with the credentials and subject in effect for the call, e.g. from
+// WithCredentials or WithSubject. 0 removes the limit. Applies on top of
+// SetRateLimiter. The limiters made for accounts no client asked
+// SharedRateLimiter for are dropped after SHARED_LIMITER_IDLE without calls,
+// so a platform calling for many sellers doesn't keep one per seller.
+func (pClient *PayPalClient) SetSharedRateLimit(callsPerSecond float64) {
+	pClient.sharedRate = callsPerSecond
+}
+
+// Waits for the client's limiters, returning ctx's error once it is done
+func (pClient *PayPalClient) waitForLimits(ctx context.Context, endpoint, username, subject string) error {
+	if err := waitLimiter(ctx, pClient.limiter); err != nil {
+		return err
+	}
+	if pClient.sharedRate > 0 {
+		return waitLimiter(ctx, lookupSharedLimiter(endpoint+"|"+username+"|"+subject, pClient.sharedRate, false))
+	}
+	return nil
+}
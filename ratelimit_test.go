@@ -0,0 +1,111 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSharedRateLimiter(t *testing.T) {
+	first := paypal.SharedRateLimiter(paypal.NVP_SANDBOX_URL, "tenant-a-api1.example.com", "", 100)
+	if paypal.SharedRateLimiter(paypal.NVP_SANDBOX_URL, "tenant-a-api1.example.com", "", 5) != first {
+		t.Errorf("Expected the same limiter for the same account")
+	}
+	if paypal.SharedRateLimiter(paypal.NVP_SANDBOX_URL, "tenant-b-api1.example.com", "", 100) == first {
+		t.Errorf("Expected different limiters for different accounts")
+	}
+	if paypal.SharedRateLimiter(paypal.NVP_SANDBOX_URL, "tenant-a-api1.example.com", "seller@example.com", 100) == first {
+		t.Errorf("Expected different limiters for different subjects")
+	}
+	if paypal.SharedRateLimiter(paypal.NVP_PRODUCTION_URL, "tenant-a-api1.example.com", "", 100) == first {
+		t.Errorf("Expected different limiters for different endpoints")
+	}
+}
+
+func TestClientsShareRateLimit(t *testing.T) {
+	transport := &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		return "ACK=Success"
+	})}
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		client := paypal.NewClient("shared-api1.example.com", "pass", "sig", true, transport)
+		client.SetSharedRateLimit(100)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.PerformRequest(url.Values{"METHOD": {"GetBalance"}})
+		}()
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("6 calls across clients sharing 100/s finished in %s", elapsed)
+	}
+
+	// calls made for other accounts don't wait on each other
+	client := paypal.NewClient("own-api1.example.com", "pass", "sig", true, transport)
+	client.SetSharedRateLimit(1)
+	start = time.Now()
+	// the limiters outlive the test, so the subjects are new on every run
+	run := time.Now().Format(time.RFC3339Nano)
+	for _, seller := range []string{"a", "b", "c"} {
+		client.PerformRequest(url.Values{"METHOD": {"GetBalance"}}, paypal.WithSubject(seller+"-"+run+"@example.com"))
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Calls for different subjects shared a 1/s limit, took %s", elapsed)
+	}
+}
+
+func TestRateLimiterEdgeCases(t *testing.T) {
+	start := time.Now()
+	for _, rate := range []float64{0, -5} {
+		limiter := paypal.NewRateLimiter(rate)
+		for i := 0; i < 10; i++ {
+			limiter.Wait()
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Expected rates <= 0 not to limit, took %s", elapsed)
+	}
+
+	limiter := paypal.NewRateLimiter(1).(paypal.ContextRateLimiter)
+	limiter.Wait()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if err := limiter.WaitContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected the context's error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("WaitContext kept waiting after the context was done, took %s", elapsed)
+	}
+}
+
+func TestBulkCancelWhileThrottled(t *testing.T) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		return "ACK=Success"
+	})})
+	requests := make([]url.Values, 5)
+	for i := range requests {
+		requests[i] = url.Values{"METHOD": {"GetBalance"}}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	results, _ := paypal.NewBulk(client, 2, paypal.NewRateLimiter(1)).Run(requests, paypal.WithContext(ctx))
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Throttled bulk run not stopped by its context, took %s", elapsed)
+	}
+	if results[4].Err == nil {
+		t.Errorf("Expected the calls left to fail with the context's error")
+	}
+}
@@ -0,0 +1,274 @@
+package paypal
+
+import (
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// STATUS values of a recurring payments profile
+const (
+	PROFILE_STATUS_ACTIVE    = "Active"
+	PROFILE_STATUS_PENDING   = "Pending"
+	PROFILE_STATUS_CANCELLED = "Cancelled"
+	PROFILE_STATUS_SUSPENDED = "Suspended"
+	PROFILE_STATUS_EXPIRED   = "Expired"
+)
+
+type BillingPeriod string
+
+const (
+	BILLING_PERIOD_DAY        BillingPeriod = "Day"
+	BILLING_PERIOD_WEEK       BillingPeriod = "Week"
+	BILLING_PERIOD_SEMI_MONTH BillingPeriod = "SemiMonth"
+	BILLING_PERIOD_MONTH      BillingPeriod = "Month"
+	BILLING_PERIOD_YEAR       BillingPeriod = "Year"
+)
+
+// Longest PROFILEREFERENCE PayPal keeps
+const PROFILE_REFERENCE_LENGTH = 127
+
+type PayPalRecurringProfileRequest struct {
+	// Token of a checkout whose order has a BILLING_TYPE_RECURRING_PAYMENTS
+	// billing agreement
+	Token          string
+	SubscriberName string
+	StartDate      time.Time
+	// The merchant's own id for the subscription. PayPal returns it as
+	// PROFILEREFERENCE in the profile details and as rp_invoice_id in the
+	// profile's IPNs.
+	ProfileReference string
+	// Must be the billing agreement's description
+	Description      string
+	BillingPeriod    BillingPeriod
+	BillingFrequency int
+	// 0 bills until the profile is cancelled
+	TotalBillingCycles int
+	Amount             float64
+	CurrencyCode       string
+	// Charged when the profile is created
+	InitialAmount     float64
+	MaxFailedPayments int
+	// Have PayPal add failed amounts to the next cycle's payment
+	AutoBillOutstanding bool
+}
+
+// Unlike the fields in FieldLengthLimits, a too-long ProfileReference is
+// never truncated: a cut reference would no longer match the merchant's
+// records.
+func (pClient *PayPalClient) CreateRecurringPaymentsProfile(request PayPalRecurringProfileRequest, opts ...CallOption) (*PayPalResponse, error) {
+	if length := utf8.RuneCountInString(request.ProfileReference); length > PROFILE_REFERENCE_LENGTH {
+		return nil, &FieldLengthError{"PROFILEREFERENCE", length, PROFILE_REFERENCE_LENGTH}
+	}
+
+	values := url.Values{}
+	values.Set("METHOD", "CreateRecurringPaymentsProfile")
+	values.Add("TOKEN", request.Token)
+	values.Add("PROFILESTARTDATE", formatNVPDate(request.StartDate))
+	values.Add("DESC", request.Description)
+	values.Add("BILLINGPERIOD", string(request.BillingPeriod))
+	values.Add("BILLINGFREQUENCY", strconv.Itoa(request.BillingFrequency))
+	values.Add("AMT", formatAmount(request.Amount))
+	values.Add("CURRENCYCODE", request.CurrencyCode)
+	if len(request.SubscriberName) != 0 {
+		values.Add("SUBSCRIBERNAME", request.SubscriberName)
+	}
+	if len(request.ProfileReference) != 0 {
+		values.Add("PROFILEREFERENCE", request.ProfileReference)
+	}
+	if request.TotalBillingCycles > 0 {
+		values.Add("TOTALBILLINGCYCLES", strconv.Itoa(request.TotalBillingCycles))
+	}
+	if request.InitialAmount > 0 {
+		values.Add("INITAMT", formatAmount(request.InitialAmount))
+	}
+	if request.MaxFailedPayments > 0 {
+		values.Add("MAXFAILEDPAYMENTS", strconv.Itoa(request.MaxFailedPayments))
+	}
+	if request.AutoBillOutstanding {
+		values.Add("AUTOBILLOUTAMT", "AddToNextBilling")
+	}
+	return pClient.PerformRequest(values, opts...)
+}
+
+func (pClient *PayPalClient) GetRecurringPaymentsProfileDetails(profileId string, opts ...CallOption) (*PayPalResponse, error) {
+	values := url.Values{}
+	values.Set("METHOD", "GetRecurringPaymentsProfileDetails")
+	values.Add("PROFILEID", profileId)
+	return pClient.PerformRequest(values, opts...)
+}
+
+type ProfileAction string
+
+const (
+	PROFILE_ACTION_CANCEL ProfileAction = "Cancel"
+	// Stops billing until the profile is reactivated
+	PROFILE_ACTION_SUSPEND    ProfileAction = "Suspend"
+	PROFILE_ACTION_REACTIVATE ProfileAction = "Reactivate"
+)
+
+// Cancels, suspends or reactivates a profile. note is the reason, shown to
+// the subscriber in PayPal's email and the profile's history.
+func (pClient *PayPalClient) ManageRecurringPaymentsProfileStatus(profileId string, action ProfileAction, note string, opts ...CallOption) (*PayPalResponse, error) {
+	values := url.Values{}
+	values.Set("METHOD", "ManageRecurringPaymentsProfileStatus")
+	values.Add("PROFILEID", profileId)
+	values.Add("ACTION", string(action))
+	if len(note) != 0 {
+		values.Add("NOTE", note)
+	}
+	return pClient.PerformRequest(values, opts...)
+}
+
+// Streams the payments made for a profile between start and end (now when
+// zero), however many cycles that covers, e.g. to reconcile subscription
+// revenue
+func (pClient *PayPalClient) ProfilePayments(profileId string, start, end time.Time, opts ...CallOption) *SearchIterator {
+	return pClient.SearchAll(PayPalTransactionSearch{StartDate: start, EndDate: end, ProfileId: profileId}, opts...)
+}
+
+// Typed view of a GetRecurringPaymentsProfileDetails response
+type PayPalRecurringProfile struct {
+	ProfileId string
+	// The PROFILEREFERENCE the profile was created with
+	ProfileReference string
+	Status           string
+	Description      string
+	StartDate        time.Time
+	// Amount billed each cycle
+	Amount   float64
+	Currency string
+
+	NextBillingDate         time.Time
+	NumCyclesCompleted      int
+	NumCyclesRemaining      int
+	FinalPaymentDueDate     time.Time
+	OutstandingBalance      float64
+	FailedPaymentCount      int
+	MaxFailedPayments       int
+	LastPaymentDate         time.Time
+	LastPaymentAmount       float64
+	AggregateAmount         float64
+	AggregateOptionalAmount float64
+	// PayPal adds the outstanding balance to the next cycle's payment by
+	// itself (AUTOBILLOUTAMT=AddToNextBilling)
+	AutoBillOutstanding bool
+}
+
+func (profile *PayPalRecurringProfile) Populate(values url.Values) {
+	profile.decode(&fieldParser{values: values})
+}
+
+func (profile *PayPalRecurringProfile) decode(p *fieldParser) {
+	values := p.values
+	profile.ProfileId = values.Get("PROFILEID")
+	profile.ProfileReference = values.Get("PROFILEREFERENCE")
+	profile.Status = values.Get("STATUS")
+	profile.Description = values.Get("DESC")
+	profile.StartDate = p.time("PROFILESTARTDATE")
+	profile.Amount = p.float("AMT")
+	profile.Currency = values.Get("CURRENCYCODE")
+
+	profile.NextBillingDate = p.time("NEXTBILLINGDATE")
+	profile.NumCyclesCompleted = p.int("NUMCYCLESCOMPLETED")
+	profile.NumCyclesRemaining = p.int("NUMCYCLESREMAINING")
+	profile.FinalPaymentDueDate = p.time("FINALPAYMENTDUEDATE")
+	profile.OutstandingBalance = p.float("OUTSTANDINGBALANCE")
+	profile.FailedPaymentCount = p.int("FAILEDPAYMENTCOUNT")
+	profile.MaxFailedPayments = p.int("MAXFAILEDPAYMENTS")
+	profile.LastPaymentDate = p.time("LASTPAYMENTDATE")
+	profile.LastPaymentAmount = p.float("LASTPAYMENTAMT")
+	profile.AggregateAmount = p.float("AGGREGATEAMT")
+	profile.AggregateOptionalAmount = p.float("AGGREGATEOPTIONALAMT")
+	profile.AutoBillOutstanding = values.Get("AUTOBILLOUTAMT") == "AddToNextBilling"
+}
+
+// When a dunning pipeline should try to collect a profile's outstanding
+// balance itself, e.g. with BillOutstandingAmount
+type BillingRetryPolicy struct {
+	// Give up once this many payments failed; 0 uses the profile's
+	// MaxFailedPayments, and no limit when that is 0 too
+	MaxFailedPayments int
+	// Balances below this are left for the next cycle
+	MinOutstanding float64
+	// Leave the balance to PayPal when it bills it with a cycle due within
+	// this long
+	AutoBillWindow time.Duration
+}
+
+// Reports whether the profile has an outstanding balance worth collecting
+// under policy: it must be active or suspended, within its failure limit
+// and not about to be billed by PayPal anyway
+func (profile *PayPalRecurringProfile) ShouldRetryBilling(policy BillingRetryPolicy) bool {
+	if profile.Status != PROFILE_STATUS_ACTIVE && profile.Status != PROFILE_STATUS_SUSPENDED {
+		return false
+	}
+	if profile.OutstandingBalance <= 0 || toCents(profile.OutstandingBalance) < toCents(policy.MinOutstanding) {
+		return false
+	}
+
+	maxFailed := policy.MaxFailedPayments
+	if maxFailed == 0 {
+		maxFailed = profile.MaxFailedPayments
+	}
+	if maxFailed > 0 && profile.FailedPaymentCount >= maxFailed {
+		return false
+	}
+
+	if profile.AutoBillOutstanding && profile.Status == PROFILE_STATUS_ACTIVE && !profile.NextBillingDate.IsZero() &&
+		time.Until(profile.NextBillingDate) <= policy.AutoBillWindow {
+		return false
+	}
+	return true
+}
+
+// The PROFILEREFERENCE of the profile a recurring payment IPN is about,
+// empty for other notifications
+func (message *IPNMessage) ProfileReference() string {
+	return message.Subscription.RpInvoiceId
+}
+
+// Maps profile ids to the merchant's subscription ids, for the
+// notifications that come without rp_invoice_id. Safe for concurrent use.
+type ProfileIndex struct {
+	mu          sync.RWMutex
+	byProfileId map[string]string
+}
+
+func NewProfileIndex() *ProfileIndex {
+	return &ProfileIndex{byProfileId: make(map[string]string)}
+}
+
+func (index *ProfileIndex) Add(profileId, reference string) {
+	if len(profileId) == 0 || len(reference) == 0 {
+		return
+	}
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	index.byProfileId[profileId] = reference
+}
+
+// Records profile, typically right after creating it or while reading the
+// details of existing profiles at startup
+func (index *ProfileIndex) AddProfile(profile *PayPalRecurringProfile) {
+	index.Add(profile.ProfileId, profile.ProfileReference)
+}
+
+func (index *ProfileIndex) Reference(profileId string) (string, bool) {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+	reference, ok := index.byProfileId[profileId]
+	return reference, ok
+}
+
+// The subscription message is about: its rp_invoice_id, or the reference
+// recorded for its recurring_payment_id
+func (index *ProfileIndex) ForIPN(message *IPNMessage) (string, bool) {
+	if reference := message.ProfileReference(); len(reference) != 0 {
+		index.Add(message.Subscription.RecurringPaymentId, reference)
+		return reference, true
+	}
+	return index.Reference(message.Subscription.RecurringPaymentId)
+}
@@ -0,0 +1,168 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetRecurringPaymentsProfileDetails(t *testing.T) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		if values.Get("METHOD") != "GetRecurringPaymentsProfileDetails" || values.Get("PROFILEID") != "I-BZ9H2TKW4B3S" {
+			t.Errorf("Unexpected request %v", values)
+		}
+		return "ACK=Success&PROFILEID=I-BZ9H2TKW4B3S&STATUS=Active&AMT=9.99&CURRENCYCODE=EUR" +
+			"&NEXTBILLINGDATE=2016-04-01T10%3A00%3A00Z&NUMCYCLESCOMPLETED=5&NUMCYCLESREMAINING=7" +
+			"&OUTSTANDINGBALANCE=19.98&FAILEDPAYMENTCOUNT=2&MAXFAILEDPAYMENTS=3" +
+			"&LASTPAYMENTDATE=2016-01-01T10%3A00%3A00Z&LASTPAYMENTAMT=9.99&AGGREGATEAMT=49.95&AUTOBILLOUTAMT=NoAutoBill"
+	})})
+
+	response, err := client.GetRecurringPaymentsProfileDetails("I-BZ9H2TKW4B3S")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var profile paypal.PayPalRecurringProfile
+	if err = response.Decode(&profile); err != nil || len(response.Warnings) != 0 {
+		t.Fatalf("Unexpected decode result %v %v", err, response.Warnings)
+	}
+	if profile.FailedPaymentCount != 2 || profile.NumCyclesCompleted != 5 || profile.OutstandingBalance != 19.98 ||
+		profile.AggregateAmount != 49.95 || profile.LastPaymentDate.Month() != time.January || profile.AutoBillOutstanding {
+		t.Errorf("Unexpected profile %+v", profile)
+	}
+}
+
+func TestShouldRetryBilling(t *testing.T) {
+	due := paypal.PayPalRecurringProfile{Status: paypal.PROFILE_STATUS_ACTIVE, OutstandingBalance: 9.99, FailedPaymentCount: 1, MaxFailedPayments: 3}
+
+	tests := []struct {
+		name   string
+		change func(*paypal.PayPalRecurringProfile)
+		policy paypal.BillingRetryPolicy
+		retry  bool
+	}{
+		{"due", func(*paypal.PayPalRecurringProfile) {}, paypal.BillingRetryPolicy{}, true},
+		{"suspended", func(p *paypal.PayPalRecurringProfile) { p.Status = paypal.PROFILE_STATUS_SUSPENDED }, paypal.BillingRetryPolicy{}, true},
+		{"cancelled", func(p *paypal.PayPalRecurringProfile) { p.Status = paypal.PROFILE_STATUS_CANCELLED }, paypal.BillingRetryPolicy{}, false},
+		{"nothing owed", func(p *paypal.PayPalRecurringProfile) { p.OutstandingBalance = 0 }, paypal.BillingRetryPolicy{}, false},
+		{"small balance", func(*paypal.PayPalRecurringProfile) {}, paypal.BillingRetryPolicy{MinOutstanding: 10}, false},
+		{"profile limit", func(p *paypal.PayPalRecurringProfile) { p.FailedPaymentCount = 3 }, paypal.BillingRetryPolicy{}, false},
+		{"policy limit", func(*paypal.PayPalRecurringProfile) {}, paypal.BillingRetryPolicy{MaxFailedPayments: 1}, false},
+		{"billed soon", func(p *paypal.PayPalRecurringProfile) {
+			p.AutoBillOutstanding, p.NextBillingDate = true, time.Now().Add(time.Hour)
+		}, paypal.BillingRetryPolicy{AutoBillWindow: 24 * time.Hour}, false},
+		{"billed later", func(p *paypal.PayPalRecurringProfile) {
+			p.AutoBillOutstanding, p.NextBillingDate = true, time.Now().Add(72*time.Hour)
+		}, paypal.BillingRetryPolicy{AutoBillWindow: 24 * time.Hour}, true},
+	}
+	for _, test := range tests {
+		profile := due
+		test.change(&profile)
+		if retry := profile.ShouldRetryBilling(test.policy); retry != test.retry {
+			t.Errorf("%s: expected ShouldRetryBilling to be %v", test.name, test.retry)
+		}
+	}
+}
+
+func TestCreateRecurringPaymentsProfile(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success&PROFILEID=I-BZ9H2TKW4B3S&PROFILESTATUS=ActiveProfile"
+	})})
+
+	request := paypal.PayPalRecurringProfileRequest{
+		Token:               "EC-4WL17777V4111184H",
+		StartDate:           time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC),
+		ProfileReference:    "sub_8a3f",
+		Description:         "Monthly plan",
+		BillingPeriod:       paypal.BILLING_PERIOD_MONTH,
+		BillingFrequency:    1,
+		Amount:              9.99,
+		CurrencyCode:        "EUR",
+		AutoBillOutstanding: true,
+	}
+	if _, err := client.CreateRecurringPaymentsProfile(request); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]string{
+		"PROFILEREFERENCE": "sub_8a3f",
+		"PROFILESTARTDATE": "2016-03-01T00:00:00Z",
+		"BILLINGPERIOD":    "Month",
+		"BILLINGFREQUENCY": "1",
+		"AMT":              "9.99",
+		"AUTOBILLOUTAMT":   "AddToNextBilling",
+	}
+	for key, value := range expected {
+		if sent.Get(key) != value {
+			t.Errorf("Expected %s=%s, got %q", key, value, sent.Get(key))
+		}
+	}
+	if _, ok := sent["TOTALBILLINGCYCLES"]; ok {
+		t.Errorf("Expected TOTALBILLINGCYCLES to be left out for open-ended profiles")
+	}
+
+	client.SetTruncateLongFields(true)
+	request.ProfileReference = strings.Repeat("x", paypal.PROFILE_REFERENCE_LENGTH+1)
+	if _, err := client.CreateRecurringPaymentsProfile(request); err == nil {
+		t.Errorf("Expected a too long profile reference to be refused, even when truncating")
+	}
+}
+
+func TestProfileIndex(t *testing.T) {
+	index := paypal.NewProfileIndex()
+	index.AddProfile(&paypal.PayPalRecurringProfile{ProfileId: "I-1", ProfileReference: "sub_1"})
+
+	payment := paypal.ParseIPNMessage(url.Values{"txn_type": {"recurring_payment"}, "recurring_payment_id": {"I-2"}, "rp_invoice_id": {"sub_2"}})
+	if reference, ok := index.ForIPN(payment); !ok || reference != "sub_2" {
+		t.Errorf("Expected rp_invoice_id to be used, got %q", reference)
+	}
+
+	for profileId, expected := range map[string]string{"I-1": "sub_1", "I-2": "sub_2"} {
+		cancel := paypal.ParseIPNMessage(url.Values{"txn_type": {"recurring_payment_profile_cancel"}, "recurring_payment_id": {profileId}})
+		if reference, ok := index.ForIPN(cancel); !ok || reference != expected {
+			t.Errorf("Expected %s to map to %s, got %q", profileId, expected, reference)
+		}
+	}
+	if _, ok := index.Reference("I-3"); ok {
+		t.Errorf("Expected unknown profiles to be missing")
+	}
+}
+
+func TestManageRecurringPaymentsProfileStatus(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success&PROFILEID=" + values.Get("PROFILEID")
+	})})
+
+	if _, err := client.ManageRecurringPaymentsProfileStatus("I-BZ9H2TKW4B3S", paypal.PROFILE_ACTION_SUSPEND, "Paused at the subscriber's request"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if sent.Get("METHOD") != "ManageRecurringPaymentsProfileStatus" || sent.Get("ACTION") != "Suspend" || sent.Get("NOTE") != "Paused at the subscriber's request" {
+		t.Errorf("Unexpected request %v", sent)
+	}
+}
+
+func TestProfilePayments(t *testing.T) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		if values.Get("PROFILEID") != "I-BZ9H2TKW4B3S" {
+			t.Errorf("Expected the search to be filtered by profile, got %v", values)
+		}
+		return "ACK=Success&L_TRANSACTIONID0=8RT51812D3056610N&L_TYPE0=Recurring+Payment&L_AMT0=9.99&L_STATUS0=Completed" +
+			"&L_TRANSACTIONID1=9PL23456E4167721P&L_TYPE1=Recurring+Payment&L_AMT1=9.99&L_STATUS1=Completed"
+	})})
+
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	payments := client.ProfilePayments("I-BZ9H2TKW4B3S", start, start.AddDate(0, 3, 0))
+	var total float64
+	for payments.Next() {
+		total += payments.Result().Amount
+	}
+	if payments.Err() != nil || total != 19.98 {
+		t.Errorf("Expected 19.98 in payments, got %v, %v", total, payments.Err())
+	}
+}
@@ -0,0 +1,133 @@
+package paypal
+
+import (
+	"errors"
+	"net/url"
+	"time"
+)
+
+type RefundType string
+
+const (
+	REFUND_TYPE_FULL             RefundType = "Full"
+	REFUND_TYPE_PARTIAL          RefundType = "Partial"
+	REFUND_TYPE_EXTERNAL_DISPUTE RefundType = "ExternalDispute"
+	REFUND_TYPE_OTHER            RefundType = "Other"
+)
+
+var ErrRefundAmountRequired = errors.New("paypal: partial refunds need an amount")
+
+type PayPalRefund struct {
+	TransactionId string
+	RefundType    RefundType
+	// Required for partial refunds, not sent for full ones
+	Amount       float64
+	CurrencyCode string
+	InvoiceId    string
+	Note         string
+	// Optional idempotency key, up to 38 characters: PayPal refunds once per
+	// MsgSubId and answers repeats with the first call's result
+	MsgSubId string
+}
+
+// Typed view of a RefundTransaction response
+type RefundResult struct {
+	RefundTransactionId string
+	GrossRefundAmount   float64
+	FeeRefundAmount     float64
+	NetRefundAmount     float64
+	TotalRefundedAmount float64
+	Currency            string
+	// "instant", "delayed" or "None"
+	RefundStatus  string
+	PendingReason string
+}
+
+func (pClient *PayPalClient) RefundTransaction(refund PayPalRefund, opts ...CallOption) (*PayPalResponse, error) {
+	values := url.Values{}
+	values.Set("METHOD", "RefundTransaction")
+	values.Add("TRANSACTIONID", refund.TransactionId)
+	values.Add("REFUNDTYPE", string(refund.RefundType))
+	if refund.RefundType != REFUND_TYPE_FULL {
+		if refund.RefundType == REFUND_TYPE_PARTIAL && refund.Amount <= 0 {
+			return nil, ErrRefundAmountRequired
+		}
+		if refund.Amount > 0 {
+			values.Add("AMT", formatAmount(refund.Amount))
+			values.Add("CURRENCYCODE", refund.CurrencyCode)
+		}
+	}
+	if len(refund.InvoiceId) != 0 {
+		values.Add("INVOICEID", refund.InvoiceId)
+	}
+	if len(refund.Note) != 0 {
+		values.Add("NOTE", refund.Note)
+	}
+	if len(refund.MsgSubId) != 0 {
+		values.Add("MSGSUBID", refund.MsgSubId)
+	}
+	return pClient.PerformRequest(values, opts...)
+}
+
+func (result *RefundResult) Populate(values url.Values) {
+	result.decode(&fieldParser{values: values})
+}
+
+func (result *RefundResult) decode(p *fieldParser) {
+	values := p.values
+	result.RefundTransactionId = values.Get("REFUNDTRANSACTIONID")
+	result.GrossRefundAmount = p.float("GROSSREFUNDAMT")
+	result.FeeRefundAmount = p.float("FEEREFUNDAMT")
+	result.NetRefundAmount = p.float("NETREFUNDAMT")
+	result.TotalRefundedAmount = p.float("TOTALREFUNDEDAMOUNT")
+	result.Currency = values.Get("CURRENCYCODE")
+	result.RefundStatus = values.Get("REFUNDSTATUS")
+	result.PendingReason = values.Get("PENDINGREASON")
+}
+
+// What FindRefunds learned about a transaction
+type RefundLookup struct {
+	TransactionId string
+	// PAYMENTSTATUS of the original transaction, e.g. Refunded or
+	// PartiallyRefunded
+	PaymentStatus string
+	Refunds       []PayPalSearchResult
+	// Sum of the refunds, as a positive amount
+	TotalRefunded float64
+}
+
+func (lookup *RefundLookup) Refunded() bool {
+	return len(lookup.Refunds) != 0
+}
+
+// Answers "was this refunded?" for transactionId: reads the transaction's
+// status and order time, then searches from that time for the refunds
+// issued against it
+func (pClient *PayPalClient) FindRefunds(transactionId string, opts ...CallOption) (*RefundLookup, error) {
+	details, err := pClient.GetTransactionDetails(transactionId, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := &RefundLookup{TransactionId: transactionId, PaymentStatus: details.Values.Get("PAYMENTSTATUS")}
+	orderTime, err := time.Parse(NVP_DATE_LAYOUT, details.Values.Get("ORDERTIME"))
+	if err != nil {
+		return nil, err
+	}
+
+	search, err := pClient.TransactionSearch(PayPalTransactionSearch{StartDate: orderTime, TransactionId: transactionId}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var refundedCents int64
+	for _, result := range ParseSearchResults(search.Values) {
+		if result.Type != "Refund" || result.TransactionId == transactionId {
+			continue
+		}
+		lookup.Refunds = append(lookup.Refunds, result)
+		refundedCents -= toCents(result.Amount)
+	}
+	lookup.TotalRefunded = float64(refundedCents) / 100
+	return lookup, nil
+}
@@ -0,0 +1,71 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestRefundTransaction(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success&REFUNDTRANSACTIONID=9E679139T5135712L&FEEREFUNDAMT=0.33&GROSSREFUNDAMT=10.00&NETREFUNDAMT=9.67" +
+			"&CURRENCYCODE=USD&TOTALREFUNDEDAMOUNT=10.00&REFUNDSTATUS=delayed&PENDINGREASON=echeck"
+	})})
+
+	response, err := client.RefundTransaction(paypal.PayPalRefund{TransactionId: "8AB", RefundType: paypal.REFUND_TYPE_PARTIAL, Amount: 10, CurrencyCode: "USD", Note: "Damaged"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if sent.Get("REFUNDTYPE") != "Partial" || sent.Get("AMT") != "10.00" || sent.Get("NOTE") != "Damaged" {
+		t.Errorf("Unexpected refund request: %#v", sent)
+	}
+
+	result := new(paypal.RefundResult)
+	result.Populate(response.Values)
+	if result.RefundTransactionId != "9E679139T5135712L" || result.GrossRefundAmount != 10 || result.FeeRefundAmount != 0.33 ||
+		result.NetRefundAmount != 9.67 || result.RefundStatus != "delayed" || result.PendingReason != "echeck" {
+		t.Errorf("Refund result not decoded: %#v", result)
+	}
+
+	client.RefundTransaction(paypal.PayPalRefund{TransactionId: "8AB", RefundType: paypal.REFUND_TYPE_FULL, Amount: 10, CurrencyCode: "USD"})
+	if len(sent["AMT"]) != 0 {
+		t.Errorf("Full refunds must not send an amount: %#v", sent)
+	}
+
+	if _, err := client.RefundTransaction(paypal.PayPalRefund{TransactionId: "8AB", RefundType: paypal.REFUND_TYPE_PARTIAL}); err != paypal.ErrRefundAmountRequired {
+		t.Errorf("Expected ErrRefundAmountRequired, got %#v", err)
+	}
+}
+
+func TestFindRefunds(t *testing.T) {
+	var searched url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		switch values.Get("METHOD") {
+		case "GetTransactionDetails":
+			return "ACK=Success&TRANSACTIONID=8AB&PAYMENTSTATUS=PartiallyRefunded&ORDERTIME=2014-02-10T12%3A00%3A00Z"
+		case "TransactionSearch":
+			searched = values
+			return "ACK=Success" +
+				"&L_TRANSACTIONID0=R2&L_TYPE0=Refund&L_STATUS0=Completed&L_AMT0=-2.50&L_TIMESTAMP0=2014-02-12T08%3A00%3A00Z" +
+				"&L_TRANSACTIONID1=R1&L_TYPE1=Refund&L_STATUS1=Completed&L_AMT1=-5.00&L_TIMESTAMP1=2014-02-11T08%3A00%3A00Z" +
+				"&L_TRANSACTIONID2=8AB&L_TYPE2=Payment&L_STATUS2=Partially+Refunded&L_AMT2=20.00&L_TIMESTAMP2=2014-02-10T12%3A00%3A00Z"
+		}
+		return "ACK=Failure"
+	})})
+
+	lookup, err := client.FindRefunds("8AB")
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+
+	if searched.Get("STARTDATE") != "2014-02-10T12:00:00Z" || searched.Get("TRANSACTIONID") != "8AB" {
+		t.Errorf("Unexpected search: %#v", searched)
+	}
+	if !lookup.Refunded() || len(lookup.Refunds) != 2 || lookup.TotalRefunded != 7.5 || lookup.PaymentStatus != "PartiallyRefunded" {
+		t.Errorf("Unexpected lookup: %#v", lookup)
+	}
+}
@@ -0,0 +1,121 @@
+package paypal
+
+import "time"
+
+// PayPal accepts refunds for 180 days after the payment
+const REFUND_WINDOW = 180 * 24 * time.Hour
+
+// Answered when a MSGSUBID was already used by a call that succeeded
+const ERROR_DUPLICATE_MSGSUBID = "11607"
+
+// What a RefundSweep did with a transaction
+const (
+	SWEEP_REFUNDED = "refunded"
+	SWEEP_SKIPPED  = "skipped"
+	SWEEP_FAILED   = "failed"
+)
+
+type RefundOutcome struct {
+	TransactionId string
+	// One of the SWEEP_ values
+	Outcome string
+	// Why the transaction was skipped, empty otherwise
+	Reason string
+	// MSGSUBID the refund was sent with
+	MsgSubId string
+	// Set when Outcome is SWEEP_REFUNDED. Empty when PayPal only answered
+	// that an earlier sweep already refunded it
+	Refund RefundResult
+	Err    error
+}
+
+// Fully refunds a list of transactions, the usual support workflow after a
+// faulty release or a cancelled event. Each transaction is checked first:
+// refunds are only sent for Completed payments younger than MaxAge.
+//
+// Refunds carry a MsgSubId derived from the transaction id, so running a
+// sweep again after a crash or timeout never refunds a transaction twice.
+type RefundSweep struct {
+	// Defaults to REFUND_WINDOW
+	MaxAge time.Duration
+	// Sent with every refund
+	Note string
+	// Builds the MsgSubId of transactionId's refund; defaults to
+	// "refund-" + transactionId
+	MsgSubId func(transactionId string) string
+
+	client  *PayPalClient
+	limiter RateLimiter
+}
+
+// limiter may be nil; both the lookup and the refund of each transaction
+// wait for it
+func NewRefundSweep(client *PayPalClient, limiter RateLimiter) *RefundSweep {
+	return &RefundSweep{client: client, limiter: limiter}
+}
+
+// Handles transactionIds one after the other and reports on each, in input
+// order. A failing transaction does not stop the sweep.
+func (s *RefundSweep) Run(transactionIds []string, opts ...CallOption) []RefundOutcome {
+	outcomes := make([]RefundOutcome, len(transactionIds))
+	for i, transactionId := range transactionIds {
+		outcomes[i] = s.refund(transactionId, opts)
+	}
+	return outcomes
+}
+
+func (s *RefundSweep) refund(transactionId string, opts []CallOption) RefundOutcome {
+	outcome := RefundOutcome{TransactionId: transactionId}
+
+	s.wait(opts)
+	response, err := s.client.GetTransactionDetails(transactionId, opts...)
+	if err != nil {
+		outcome.Outcome, outcome.Err = SWEEP_FAILED, err
+		return outcome
+	}
+	var details PayPalTransactionDetails
+	details.Populate(response.Values)
+
+	maxAge := s.MaxAge
+	if maxAge <= 0 {
+		maxAge = REFUND_WINDOW
+	}
+	if details.PaymentStatus != "Completed" {
+		outcome.Outcome, outcome.Reason = SWEEP_SKIPPED, "payment status is "+details.PaymentStatus
+		return outcome
+	}
+	if time.Since(details.OrderTime) > maxAge {
+		outcome.Outcome, outcome.Reason = SWEEP_SKIPPED, "paid on "+formatNVPDate(details.OrderTime)+", too long ago to refund"
+		return outcome
+	}
+
+	outcome.MsgSubId = "refund-" + transactionId
+	if s.MsgSubId != nil {
+		outcome.MsgSubId = s.MsgSubId(transactionId)
+	}
+
+	s.wait(opts)
+	response, err = s.client.RefundTransaction(PayPalRefund{
+		TransactionId: transactionId,
+		RefundType:    REFUND_TYPE_FULL,
+		Note:          s.Note,
+		MsgSubId:      outcome.MsgSubId,
+	}, opts...)
+	if pError, ok := err.(*PayPalError); ok && pError.ErrorCode == ERROR_DUPLICATE_MSGSUBID {
+		outcome.Outcome = SWEEP_REFUNDED
+		return outcome
+	}
+	if err != nil {
+		outcome.Outcome, outcome.Err = SWEEP_FAILED, err
+		return outcome
+	}
+
+	outcome.Outcome = SWEEP_REFUNDED
+	outcome.Refund.Populate(response.Values)
+	return outcome
+}
+
+// A done context is left for the call that follows to report
+func (s *RefundSweep) wait(opts []CallOption) {
+	waitLimiter(s.client.callSettings(opts).ctx, s.limiter)
+}
@@ -0,0 +1,58 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRefundSweep(t *testing.T) {
+	recent := time.Now().Add(-24 * time.Hour).UTC().Format(paypal.NVP_DATE_LAYOUT)
+	old := time.Now().AddDate(-1, 0, 0).UTC().Format(paypal.NVP_DATE_LAYOUT)
+	transactions := map[string]string{
+		"PAID":     "PAYMENTSTATUS=Completed&ORDERTIME=" + recent,
+		"OLD":      "PAYMENTSTATUS=Completed&ORDERTIME=" + old,
+		"PENDING":  "PAYMENTSTATUS=Pending&ORDERTIME=" + recent,
+		"REPEATED": "PAYMENTSTATUS=Completed&ORDERTIME=" + recent,
+	}
+
+	var refunds []url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		id := values.Get("TRANSACTIONID")
+		if values.Get("METHOD") == "RefundTransaction" {
+			refunds = append(refunds, values)
+			if id == "REPEATED" {
+				return "ACK=Failure&L_ERRORCODE0=11607&L_SEVERITYCODE0=Error"
+			}
+			return "ACK=Success&REFUNDTRANSACTIONID=R-" + id + "&GROSSREFUNDAMT=10.00"
+		}
+		if details, ok := transactions[id]; ok {
+			return "ACK=Success&TRANSACTIONID=" + id + "&" + details
+		}
+		return "ACK=Failure&L_ERRORCODE0=10004&L_SEVERITYCODE0=Error"
+	})})
+
+	sweep := paypal.NewRefundSweep(client, paypal.NewRateLimiter(1000))
+	sweep.Note = "Event cancelled"
+	outcomes := sweep.Run([]string{"PAID", "OLD", "PENDING", "REPEATED", "UNKNOWN"})
+
+	expected := []string{paypal.SWEEP_REFUNDED, paypal.SWEEP_SKIPPED, paypal.SWEEP_SKIPPED, paypal.SWEEP_REFUNDED, paypal.SWEEP_FAILED}
+	for i, outcome := range outcomes {
+		if outcome.Outcome != expected[i] {
+			t.Errorf("Expected %s to be %s, got %+v", outcome.TransactionId, expected[i], outcome)
+		}
+	}
+	if outcomes[0].Refund.RefundTransactionId != "R-PAID" || outcomes[4].Err == nil || len(outcomes[1].Reason) == 0 {
+		t.Errorf("Unexpected outcomes %+v", outcomes)
+	}
+
+	if len(refunds) != 2 {
+		t.Fatalf("Expected 2 refunds to be sent, got %d", len(refunds))
+	}
+	if refunds[0].Get("MSGSUBID") != "refund-PAID" || refunds[0].Get("REFUNDTYPE") != "Full" || refunds[0].Get("NOTE") != "Event cancelled" {
+		t.Errorf("Unexpected refund %v", refunds[0])
+	}
+}
@@ -0,0 +1,61 @@
+package paypal
+
+import (
+	"context"
+	"time"
+)
+
+type requestIdKey struct{}
+
+// Attaches the caller's id for a request, e.g. the id of the incoming HTTP
+// request, so calls made with WithContext(ctx) can be matched with PayPal's
+// CORRELATIONID in logs and errors
+func ContextWithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+func RequestIdFromContext(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
+
+// One call to PayPal, retries included as separate entries
+type CallLogEntry struct {
+	Method string
+	// From the call's context, empty when none was set
+	RequestId string
+	// Empty when PayPal wasn't reached or its answer couldn't be read
+	CorrelationId string
+	Ack           string
+	Duration      time.Duration
+	Err           error
+}
+
+type CallLogger interface {
+	LogCall(entry CallLogEntry)
+}
+
+// Adapter allowing an ordinary function to be used as a CallLogger, e.g.
+// one writing to the application's structured logger
+type CallLoggerFunc func(entry CallLogEntry)
+
+func (f CallLoggerFunc) LogCall(entry CallLogEntry) {
+	f(entry)
+}
+
+// Reports every call made by the client to logger, nil stops reporting
+func (pClient *PayPalClient) SetCallLogger(logger CallLogger) {
+	pClient.callLogger = logger
+}
+
+func (pClient *PayPalClient) logCall(method string, settings callSettings, started time.Time, response *PayPalResponse, err error) {
+	if pClient.callLogger == nil {
+		return
+	}
+	entry := CallLogEntry{Method: method, RequestId: RequestIdFromContext(settings.ctx), Duration: time.Since(started), Err: err}
+	if response != nil {
+		entry.CorrelationId = response.CorrelationId
+		entry.Ack = response.Ack
+	}
+	pClient.callLogger.LogCall(entry)
+}
@@ -0,0 +1,50 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRequestIdAndCorrelationId(t *testing.T) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		return "ACK=Failure&CORRELATIONID=7b5d0f1c2a9e3&L_ERRORCODE0=10486&L_SHORTMESSAGE0=Transaction+refused"
+	})})
+	var logged []paypal.CallLogEntry
+	client.SetCallLogger(paypal.CallLoggerFunc(func(entry paypal.CallLogEntry) {
+		logged = append(logged, entry)
+	}))
+
+	ctx := paypal.ContextWithRequestId(context.Background(), "req-42")
+	_, err := client.DoExpressCheckoutSale("EC-1", "BUYER", "USD", 10, paypal.WithContext(ctx))
+
+	pError, ok := err.(*paypal.PayPalError)
+	if !ok || pError.CorrelationId != "7b5d0f1c2a9e3" || pError.RequestId != "req-42" {
+		t.Fatalf("Expected the ids on the error, got %#v", err)
+	}
+	if !strings.Contains(err.Error(), "7b5d0f1c2a9e3") {
+		t.Errorf("Expected the correlation id in the error message, got %q", err.Error())
+	}
+	if len(logged) != 1 || logged[0].RequestId != "req-42" || logged[0].CorrelationId != "7b5d0f1c2a9e3" || logged[0].Method != "DoExpressCheckoutPayment" {
+		t.Errorf("Unexpected log entries: %#v", logged)
+	}
+}
+
+func TestCancelledContext(t *testing.T) {
+	calls := 0
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		calls++
+		return "ACK=Success"
+	})})
+	client.SetRetryPolicy(&paypal.RetryPolicy{MaxAttempts: 3})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.GetBalance(false, paypal.WithContext(ctx)); err == nil || calls != 0 {
+		t.Errorf("Expected a cancelled call to fail without reaching PayPal, got %v after %d calls", err, calls)
+	}
+}
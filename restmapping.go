@@ -0,0 +1,161 @@
+package paypal
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Helpers for moving an integration from Express Checkout to PayPal's REST
+// Orders API (v2) one step at a time: the JSON shapes below are what
+// POST /v2/checkout/orders expects under purchase_units.
+
+type RESTMoney struct {
+	CurrencyCode string `json:"currency_code"`
+	Value        string `json:"value"`
+}
+
+type RESTAmountBreakdown struct {
+	ItemTotal        *RESTMoney `json:"item_total,omitempty"`
+	Shipping         *RESTMoney `json:"shipping,omitempty"`
+	Handling         *RESTMoney `json:"handling,omitempty"`
+	TaxTotal         *RESTMoney `json:"tax_total,omitempty"`
+	Insurance        *RESTMoney `json:"insurance,omitempty"`
+	ShippingDiscount *RESTMoney `json:"shipping_discount,omitempty"`
+	Discount         *RESTMoney `json:"discount,omitempty"`
+}
+
+type RESTAmount struct {
+	RESTMoney
+	Breakdown *RESTAmountBreakdown `json:"breakdown,omitempty"`
+}
+
+type RESTItem struct {
+	Name       string    `json:"name"`
+	UnitAmount RESTMoney `json:"unit_amount"`
+	Quantity   string    `json:"quantity"`
+	Sku        string    `json:"sku,omitempty"`
+}
+
+type RESTName struct {
+	FullName string `json:"full_name,omitempty"`
+}
+
+type RESTShipping struct {
+	Name    *RESTName    `json:"name,omitempty"`
+	Address *RESTAddress `json:"address,omitempty"`
+}
+
+type RESTPayee struct {
+	EmailAddress string `json:"email_address,omitempty"`
+	MerchantId   string `json:"merchant_id,omitempty"`
+}
+
+// A commission the platform takes out of the payee's payment when it is
+// captured; the payee defaults to the API caller's account
+type RESTPlatformFee struct {
+	Amount RESTMoney  `json:"amount"`
+	Payee  *RESTPayee `json:"payee,omitempty"`
+}
+
+type RESTPaymentInstruction struct {
+	PlatformFees []RESTPlatformFee `json:"platform_fees,omitempty"`
+}
+
+type RESTPurchaseUnit struct {
+	ReferenceId        string                  `json:"reference_id,omitempty"`
+	Description        string                  `json:"description,omitempty"`
+	Amount             RESTAmount              `json:"amount"`
+	Items              []RESTItem              `json:"items,omitempty"`
+	Shipping           *RESTShipping           `json:"shipping,omitempty"`
+	Payee              *RESTPayee              `json:"payee,omitempty"`
+	PaymentInstruction *RESTPaymentInstruction `json:"payment_instruction,omitempty"`
+}
+
+// Adds a platform fee to the unit, in the unit's currency, the REST
+// counterpart of PlatformFeePay. platformAccount is an email or merchant id,
+// or empty for the account making the call.
+func (unit *RESTPurchaseUnit) AddPlatformFee(fee float64, platformAccount string) {
+	if unit.PaymentInstruction == nil {
+		unit.PaymentInstruction = &RESTPaymentInstruction{}
+	}
+	unit.PaymentInstruction.PlatformFees = append(unit.PaymentInstruction.PlatformFees, RESTPlatformFee{
+		Amount: RESTMoney{unit.Amount.CurrencyCode, formatAmount(fee)},
+		Payee:  restPayee(platformAccount),
+	})
+}
+
+func restPayee(account string) *RESTPayee {
+	if strings.Contains(account, "@") {
+		return &RESTPayee{EmailAddress: account}
+	} else if len(account) != 0 {
+		return &RESTPayee{MerchantId: account}
+	}
+	return nil
+}
+
+// The purchase unit describing the same payment as order and goods do in
+// SetExpressCheckout. REST has no negative line items, so a
+// DISCOUNT_LINE_ITEM discount becomes the breakdown's discount and
+// item_total is the goods' sum before it.
+func (order *PayPalOrder) RESTPurchaseUnit(goods []PayPalGood) RESTPurchaseUnit {
+	money := func(amount float64) *RESTMoney {
+		return &RESTMoney{order.CurrencyCode, formatAmount(amount)}
+	}
+	optional := func(amount float64) *RESTMoney {
+		if amount <= 0 {
+			return nil
+		}
+		return money(amount)
+	}
+
+	breakdown := &RESTAmountBreakdown{
+		ItemTotal: money(order.SubTotal),
+		Shipping:  optional(order.Shipping),
+		Handling:  optional(order.Handling),
+		TaxTotal:  optional(order.Tax),
+		Insurance: optional(order.Insurance),
+	}
+	if order.Discount > 0 {
+		if order.DiscountStrategy == DISCOUNT_LINE_ITEM {
+			breakdown.ItemTotal = money(float64(toCents(order.SubTotal)+toCents(order.Discount)) / 100)
+			breakdown.Discount = money(order.Discount)
+		} else {
+			breakdown.ShippingDiscount = money(order.Discount)
+		}
+	}
+
+	unit := RESTPurchaseUnit{
+		ReferenceId: order.PaymentRequestId,
+		Description: order.Description,
+		Amount:      RESTAmount{*money(order.Total), breakdown},
+	}
+	for _, good := range goods {
+		unit.Items = append(unit.Items, RESTItem{
+			Name:       good.Name,
+			UnitAmount: *money(good.Amount),
+			Quantity:   strconv.Itoa(good.Quantity),
+			Sku:        good.Id,
+		})
+	}
+	if order.ShippingAddress != nil {
+		address := order.ShippingAddress.Normalize().REST()
+		unit.Shipping = &RESTShipping{Address: &address}
+		if len(order.ShippingAddress.Name) != 0 {
+			unit.Shipping.Name = &RESTName{order.ShippingAddress.Name}
+		}
+	}
+	unit.Payee = restPayee(order.SellerAccountId)
+	return unit
+}
+
+// A payment captured through Express Checkout keeps its transaction id in
+// REST, where it is the capture id: the path to look it up, or to refund it
+// with a POST to the path + "/refund"
+func RESTCapturePath(transactionId string) string {
+	return "/v2/payments/captures/" + transactionId
+}
+
+// Same for authorizations made with PAYMENT_ACTION_AUTHORIZATION
+func RESTAuthorizationPath(authorizationId string) string {
+	return "/v2/payments/authorizations/" + authorizationId
+}
@@ -0,0 +1,50 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"encoding/json"
+	"testing"
+)
+
+func TestRESTPurchaseUnit(t *testing.T) {
+	order := paypal.PayPalOrder{
+		SubTotal:         15,
+		Shipping:         4.5,
+		Tax:              1.5,
+		Discount:         5,
+		DiscountStrategy: paypal.DISCOUNT_LINE_ITEM,
+		Total:            21,
+		CurrencyCode:     "USD",
+		Description:      "Order 1001",
+		SellerAccountId:  "seller@example.com",
+		ShippingAddress:  &paypal.Address{Name: "Ann Buyer", Street: "1 Main St", City: "San Jose", State: "CA", Zip: "95131", CountryCode: "US"},
+	}
+	goods := []paypal.PayPalGood{{Id: "W-1", Name: "Widget", Amount: 10, Quantity: 2}}
+
+	encoded, err := json.Marshal(order.RESTPurchaseUnit(goods))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := `{"description":"Order 1001",` +
+		`"amount":{"currency_code":"USD","value":"21.00","breakdown":{` +
+		`"item_total":{"currency_code":"USD","value":"20.00"},"shipping":{"currency_code":"USD","value":"4.50"},` +
+		`"tax_total":{"currency_code":"USD","value":"1.50"},"discount":{"currency_code":"USD","value":"5.00"}}},` +
+		`"items":[{"name":"Widget","unit_amount":{"currency_code":"USD","value":"10.00"},"quantity":"2","sku":"W-1"}],` +
+		`"shipping":{"name":{"full_name":"Ann Buyer"},"address":{"address_line_1":"1 Main St","admin_area_2":"San Jose","admin_area_1":"CA","postal_code":"95131","country_code":"US"}},` +
+		`"payee":{"email_address":"seller@example.com"}}`
+	if string(encoded) != expected {
+		t.Errorf("Unexpected purchase unit:\n%s\nexpected:\n%s", encoded, expected)
+	}
+
+	unit := order.RESTPurchaseUnit(goods)
+	unit.AddPlatformFee(2.1, "PLATFORM123")
+	encoded, _ = json.Marshal(unit.PaymentInstruction)
+	if expected := `{"platform_fees":[{"amount":{"currency_code":"USD","value":"2.10"},"payee":{"merchant_id":"PLATFORM123"}}]}`; string(encoded) != expected {
+		t.Errorf("Unexpected payment instruction %s", encoded)
+	}
+
+	if path := paypal.RESTCapturePath("8RT51812D3056610N"); path != "/v2/payments/captures/8RT51812D3056610N" {
+		t.Errorf("Unexpected capture path %s", path)
+	}
+}
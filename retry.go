@@ -0,0 +1,122 @@
+package paypal
+
+import (
+	"net/url"
+	"time"
+)
+
+// Calls that only read, so repeating them can't charge or refund twice
+var idempotentMethods = map[string]bool{
+	"GetExpressCheckoutDetails":          true,
+	"GetTransactionDetails":              true,
+	"TransactionSearch":                  true,
+	"GetBalance":                         true,
+	"GetRecurringPaymentsProfileDetails": true,
+	"GetBillingAgreementCustomerDetails": true,
+	"GetPalDetails":                      true,
+	// Adaptive Payments
+	"PaymentDetails": true,
+}
+
+// PayPal errors saying the call failed on PayPal's side and may work when
+// repeated
+var transientErrorCodes = map[string]bool{
+	// Internal Error
+	"10001": true,
+	// This transaction cannot be processed at this time. Please try again later.
+	"10445": true,
+}
+
+// A failed call PerformRequest may repeat
+type RetryAttempt struct {
+	Method string
+	// 1 for the first call
+	Attempt int
+	// The call only reads, or carries a MSGSUBID PayPal deduplicates it by
+	Safe bool
+	Err  error
+	// Set when PayPal answered with an error
+	ErrorCode string
+}
+
+// Whether to repeat a failed call
+type RetryDecision func(attempt RetryAttempt) bool
+
+// Repeats safe calls that failed in transit, or with a transient PayPal
+// error. Other calls are never repeated, since a payment PayPal completed
+// before the connection dropped would be charged twice.
+func DefaultRetryDecision(attempt RetryAttempt) bool {
+	if !attempt.Safe {
+		return false
+	}
+	if len(attempt.ErrorCode) != 0 {
+		return transientErrorCodes[attempt.ErrorCode]
+	}
+	return true
+}
+
+type RetryPolicy struct {
+	// Calls made in total, including the first; below 2 disables retries
+	MaxAttempts int
+	// Wait before the first retry, doubled for each one after it
+	Backoff time.Duration
+	// Defaults to DefaultRetryDecision
+	Decide RetryDecision
+}
+
+// Enables retries of failed calls, nil disables them
+func (pClient *PayPalClient) SetRetryPolicy(policy *RetryPolicy) {
+	pClient.retryPolicy = policy
+}
+
+// Errors that happened before the call reached PayPal, or on the way
+// there and back, rather than PayPal refusing it
+func isRetryableFailure(err error) (errorCode string, ok bool) {
+	switch e := err.(type) {
+	case *PayPalError:
+		return e.ErrorCode, true
+	case *TransportError:
+		return "", true
+	case *url.Error:
+		return "", true
+	}
+	return "", false
+}
+
+func (pClient *PayPalClient) performWithRetries(values url.Values, settings callSettings) (*PayPalResponse, error) {
+	method := values.Get("METHOD")
+	policy := pClient.retryPolicy
+	response, err := pClient.loggedRequest(method, values, settings)
+	if policy == nil {
+		return response, err
+	}
+
+	decide := policy.Decide
+	if decide == nil {
+		decide = DefaultRetryDecision
+	}
+	safe := idempotentMethods[method] || len(values.Get("MSGSUBID")) != 0
+	backoff := policy.Backoff
+
+	for attempt := 1; err != nil && attempt < policy.MaxAttempts && settings.ctx.Err() == nil; attempt++ {
+		errorCode, ok := isRetryableFailure(err)
+		if !ok || !decide(RetryAttempt{Method: method, Attempt: attempt, Safe: safe, Err: err, ErrorCode: errorCode}) {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+		case <-settings.ctx.Done():
+			return response, err
+		}
+		backoff *= 2
+		response, err = pClient.loggedRequest(method, values, settings)
+	}
+	return response, err
+}
+
+func (pClient *PayPalClient) loggedRequest(method string, values url.Values, settings callSettings) (*PayPalResponse, error) {
+	started := time.Now()
+	response, err := pClient.performRequest(values, settings)
+	pClient.logCall(method, settings, started, response, err)
+	return response, err
+}
@@ -0,0 +1,54 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestRetriesOnlySafeCalls(t *testing.T) {
+	calls := map[string]int{}
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		calls[values.Get("METHOD")]++
+		if calls[values.Get("METHOD")] < 3 {
+			return "ACK=Failure&L_ERRORCODE0=10001&L_SHORTMESSAGE0=Internal+Error"
+		}
+		return "ACK=Success&TOKEN=EC-1"
+	})})
+	client.SetRetryPolicy(&paypal.RetryPolicy{MaxAttempts: 3})
+
+	if _, err := client.GetExpressCheckoutDetails("EC-1"); err != nil || calls["GetExpressCheckoutDetails"] != 3 {
+		t.Errorf("Expected the details call to succeed on its third attempt, got %v after %d calls", err, calls["GetExpressCheckoutDetails"])
+	}
+	if _, err := client.DoExpressCheckoutSale("EC-1", "BUYER", "USD", 10); err == nil || calls["DoExpressCheckoutPayment"] != 1 {
+		t.Errorf("A payment must not be retried, got %d calls", calls["DoExpressCheckoutPayment"])
+	}
+
+	values := url.Values{}
+	values.Set("METHOD", "DoCapture")
+	values.Set("MSGSUBID", "capture-1")
+	if _, err := client.PerformRequest(values); err != nil || calls["DoCapture"] != 3 {
+		t.Errorf("Expected a call carrying MSGSUBID to be retried, got %v after %d calls", err, calls["DoCapture"])
+	}
+}
+
+func TestRetryDecisionOverride(t *testing.T) {
+	calls := 0
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		calls++
+		return "ACK=Failure&L_ERRORCODE0=10001"
+	})})
+
+	var attempts []paypal.RetryAttempt
+	client.SetRetryPolicy(&paypal.RetryPolicy{MaxAttempts: 5, Decide: func(attempt paypal.RetryAttempt) bool {
+		attempts = append(attempts, attempt)
+		return attempt.ErrorCode == "10001" && attempt.Attempt < 2
+	}})
+
+	client.DoExpressCheckoutSale("EC-1", "BUYER", "USD", 10)
+	if calls != 2 || len(attempts) != 2 || attempts[0].Safe || attempts[0].Method != "DoExpressCheckoutPayment" {
+		t.Errorf("Expected the decision to allow one retry of an unsafe call, got %d calls and %#v", calls, attempts)
+	}
+}
@@ -0,0 +1,178 @@
+package paypal
+
+import (
+	"net/url"
+	"time"
+)
+
+// Date format of NVP date fields, always in UTC
+const NVP_DATE_LAYOUT = "2006-01-02T15:04:05Z"
+
+// TRANSACTIONCLASS values of TransactionSearch
+const (
+	TRANSACTION_CLASS_ALL                  = "All"
+	TRANSACTION_CLASS_SENT                 = "Sent"
+	TRANSACTION_CLASS_RECEIVED             = "Received"
+	TRANSACTION_CLASS_MASS_PAY             = "MassPay"
+	TRANSACTION_CLASS_MONEY_REQUEST        = "MoneyRequest"
+	TRANSACTION_CLASS_FUNDS_ADDED          = "FundsAdded"
+	TRANSACTION_CLASS_FUNDS_WITHDRAWN      = "FundsWithdrawn"
+	TRANSACTION_CLASS_REFERRAL             = "Referral"
+	TRANSACTION_CLASS_FEE                  = "Fee"
+	TRANSACTION_CLASS_SUBSCRIPTION         = "Subscription"
+	TRANSACTION_CLASS_DIVIDEND             = "Dividend"
+	TRANSACTION_CLASS_BILLPAY              = "Billpay"
+	TRANSACTION_CLASS_REFUND               = "Refund"
+	TRANSACTION_CLASS_CURRENCY_CONVERSIONS = "CurrencyConversions"
+	TRANSACTION_CLASS_BALANCE_TRANSFER     = "BalanceTransfer"
+	TRANSACTION_CLASS_REVERSAL             = "Reversal"
+	TRANSACTION_CLASS_SHIPPING             = "Shipping"
+	TRANSACTION_CLASS_BALANCE_AFFECTING    = "BalanceAffecting"
+	TRANSACTION_CLASS_ECHECK               = "ECheck"
+)
+
+type PayPalTransactionSearch struct {
+	// Required by PayPal
+	StartDate     time.Time
+	EndDate       time.Time
+	TransactionId string
+	Email         string
+	InvoiceId     string
+	Status        string
+	// One of the TRANSACTION_CLASS_ values
+	TransactionClass string
+	// Receipt number PayPal emailed the buyer
+	ReceiptId         string
+	AuctionItemNumber string
+	// Id of the recurring payments profile the payments were made for
+	ProfileId string
+}
+
+// One row of a TransactionSearch response
+type PayPalSearchResult struct {
+	Timestamp     time.Time
+	Timezone      string
+	Type          string
+	Email         string
+	Name          string
+	TransactionId string
+	Status        string
+	Amount        float64
+	Currency      string
+	FeeAmount     float64
+	NetAmount     float64
+}
+
+func formatNVPDate(t time.Time) string {
+	return t.UTC().Format(NVP_DATE_LAYOUT)
+}
+
+func (pClient *PayPalClient) TransactionSearch(search PayPalTransactionSearch, opts ...CallOption) (*PayPalResponse, error) {
+	values := url.Values{}
+	values.Set("METHOD", "TransactionSearch")
+	values.Add("STARTDATE", formatNVPDate(search.StartDate))
+	if !search.EndDate.IsZero() {
+		values.Add("ENDDATE", formatNVPDate(search.EndDate))
+	}
+	if len(search.TransactionId) != 0 {
+		values.Add("TRANSACTIONID", search.TransactionId)
+	}
+	if len(search.Email) != 0 {
+		values.Add("EMAIL", search.Email)
+	}
+	if len(search.InvoiceId) != 0 {
+		values.Add("INVNUM", search.InvoiceId)
+	}
+	if len(search.Status) != 0 {
+		values.Add("STATUS", search.Status)
+	}
+	if len(search.TransactionClass) != 0 {
+		values.Add("TRANSACTIONCLASS", search.TransactionClass)
+	}
+	if len(search.ReceiptId) != 0 {
+		values.Add("RECEIPTID", search.ReceiptId)
+	}
+	if len(search.AuctionItemNumber) != 0 {
+		values.Add("AUCTIONITEMNUMBER", search.AuctionItemNumber)
+	}
+	if len(search.ProfileId) != 0 {
+		values.Add("PROFILEID", search.ProfileId)
+	}
+	return pClient.PerformRequest(values, opts...)
+}
+
+// The rows of a TransactionSearch response, as a ResponseView
+type PayPalSearchResults []PayPalSearchResult
+
+// Decodes the L_ rows of a TransactionSearch response
+func ParseSearchResults(values url.Values) []PayPalSearchResult {
+	var results PayPalSearchResults
+	results.decode(&fieldParser{values: values})
+	return results
+}
+
+func (results *PayPalSearchResults) decode(p *fieldParser) {
+	values := p.values
+	for i := 0; len(values.Get(listKey("L_TRANSACTIONID", i))) != 0; i++ {
+		result := PayPalSearchResult{
+			Timezone:      values.Get(listKey("L_TIMEZONE", i)),
+			Type:          values.Get(listKey("L_TYPE", i)),
+			Email:         values.Get(listKey("L_EMAIL", i)),
+			Name:          values.Get(listKey("L_NAME", i)),
+			TransactionId: values.Get(listKey("L_TRANSACTIONID", i)),
+			Status:        values.Get(listKey("L_STATUS", i)),
+			Currency:      values.Get(listKey("L_CURRENCYCODE", i)),
+		}
+		result.Timestamp = p.time(listKey("L_TIMESTAMP", i))
+		result.Amount = p.float(listKey("L_AMT", i))
+		result.FeeAmount = p.float(listKey("L_FEEAMT", i))
+		result.NetAmount = p.float(listKey("L_NETAMT", i))
+		*results = append(*results, result)
+	}
+}
+
+func (pClient *PayPalClient) GetTransactionDetails(transactionId string, opts ...CallOption) (*PayPalResponse, error) {
+	values := url.Values{}
+	values.Set("METHOD", "GetTransactionDetails")
+	values.Add("TRANSACTIONID", transactionId)
+	return pClient.PerformRequest(values, opts...)
+}
+
+// Typed view of a GetTransactionDetails response, for payments and refunds
+// alike
+type PayPalTransactionDetails struct {
+	TransactionId       string
+	ParentTransactionId string
+	TransactionType     string
+	PayPalPayer
+	PaymentStatus string
+	PendingReason string
+	OrderTime     time.Time
+	Amount        float64
+	FeeAmount     float64
+	Currency      string
+	// Set when the transaction was converted to the account's holding
+	// currency: the rate applied and the amount settled in that currency
+	ExchangeRate float64
+	SettleAmount float64
+}
+
+func (details *PayPalTransactionDetails) Populate(values url.Values) {
+	details.decode(&fieldParser{values: values})
+}
+
+func (details *PayPalTransactionDetails) decode(p *fieldParser) {
+	values := p.values
+	details.TransactionId = values.Get("TRANSACTIONID")
+	details.ParentTransactionId = values.Get("PARENTTRANSACTIONID")
+	details.TransactionType = values.Get("TRANSACTIONTYPE")
+	details.PayPalPayer.populate(values, "PAYERBUSINESS")
+	details.PaymentStatus = values.Get("PAYMENTSTATUS")
+	details.PendingReason = values.Get("PENDINGREASON")
+	details.OrderTime = p.time("ORDERTIME")
+	details.Amount = p.float("AMT")
+	details.FeeAmount = p.float("FEEAMT")
+	details.Currency = values.Get("CURRENCYCODE")
+	details.ExchangeRate = p.float("EXCHANGERATE")
+	details.SettleAmount = p.float("SETTLEAMT")
+}
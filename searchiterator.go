@@ -0,0 +1,132 @@
+package paypal
+
+import "time"
+
+// Most rows a TransactionSearch response carries
+const SEARCH_RESULT_LIMIT = 100
+
+// Warning returned with the first SEARCH_RESULT_LIMIT rows when more match
+const ERROR_SEARCH_TRUNCATED = "11002"
+
+// Narrowest window SearchIterator splits a truncated search into
+const MIN_SEARCH_WINDOW = time.Second
+
+type searchWindow struct {
+	start, end time.Time
+}
+
+// Streams every result of a search, however many there are: windows that
+// hit SEARCH_RESULT_LIMIT are split in half and searched again.
+//
+//	results := client.SearchAll(search)
+//	for results.Next() {
+//		row := results.Result()
+//		...
+//	}
+//	if err := results.Err(); err != nil { ... }
+type SearchIterator struct {
+	client *PayPalClient
+	search PayPalTransactionSearch
+	opts   []CallOption
+
+	// still to search, the earliest last
+	windows []searchWindow
+	buffer  []PayPalSearchResult
+	current PayPalSearchResult
+	// rows on a window boundary can come back from both halves
+	seen map[string]bool
+	err  error
+	// windows that could not be split further and kept their first
+	// SEARCH_RESULT_LIMIT rows only, and the start of the earliest
+	truncated     int
+	truncatedFrom time.Time
+}
+
+// Searches from search.StartDate to search.EndDate, or now when EndDate is
+// zero, with search's other filters
+func (pClient *PayPalClient) SearchAll(search PayPalTransactionSearch, opts ...CallOption) *SearchIterator {
+	end := search.EndDate
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return &SearchIterator{
+		client:  pClient,
+		search:  search,
+		opts:    opts,
+		windows: []searchWindow{{search.StartDate, end}},
+		seen:    make(map[string]bool),
+	}
+}
+
+// Moves to the next result, false once all were read or a call failed
+func (it *SearchIterator) Next() bool {
+	for len(it.buffer) == 0 {
+		if it.err != nil || len(it.windows) == 0 {
+			return false
+		}
+		window := it.windows[len(it.windows)-1]
+		it.windows = it.windows[:len(it.windows)-1]
+		it.err = it.fetch(window)
+	}
+	it.current, it.buffer = it.buffer[0], it.buffer[1:]
+	return true
+}
+
+func (it *SearchIterator) fetch(window searchWindow) error {
+	search := it.search
+	search.StartDate, search.EndDate = window.start, window.end
+
+	response, err := it.client.TransactionSearch(search, it.opts...)
+	truncated := false
+	if pError, ok := err.(*PayPalError); ok && pError.ErrorCode == ERROR_SEARCH_TRUNCATED {
+		truncated, err = true, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var rows PayPalSearchResults
+	if err = response.Decode(&rows); err != nil {
+		return err
+	}
+	if truncated || len(rows) >= SEARCH_RESULT_LIMIT {
+		if half := window.end.Sub(window.start) / 2; half >= MIN_SEARCH_WINDOW {
+			middle := window.start.Add(half)
+			it.windows = append(it.windows, searchWindow{middle, window.end}, searchWindow{window.start, middle})
+			return nil
+		}
+		if it.truncated == 0 {
+			it.truncatedFrom = window.start
+		}
+		it.truncated++
+	}
+
+	for _, row := range rows {
+		if !it.seen[row.TransactionId] {
+			it.seen[row.TransactionId] = true
+			it.buffer = append(it.buffer, row)
+		}
+	}
+	return nil
+}
+
+func (it *SearchIterator) Result() PayPalSearchResult {
+	return it.current
+}
+
+// The error that stopped the iteration, nil when every window was read
+func (it *SearchIterator) Err() error {
+	return it.err
+}
+
+// Number of windows narrower than MIN_SEARCH_WINDOW that still had more
+// than SEARCH_RESULT_LIMIT results, of which only the first ones were read
+func (it *SearchIterator) Truncated() int {
+	return it.truncated
+}
+
+// Start of the earliest truncated window, the zero time when Truncated is 0.
+// Windows are searched oldest first, so every row before it was read.
+func (it *SearchIterator) TruncatedFrom() time.Time {
+	return it.truncatedFrom
+}
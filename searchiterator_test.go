@@ -0,0 +1,70 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// Answers searches over transactions made every hour from start, the way
+// PayPal does: newest first, cut at 100 rows with warning 11002
+func hourlyTransactions(start time.Time, count int, calls *int) nvpTransport {
+	return nvpTransport(func(values url.Values) string {
+		*calls++
+		from, _ := time.Parse(paypal.NVP_DATE_LAYOUT, values.Get("STARTDATE"))
+		to, _ := time.Parse(paypal.NVP_DATE_LAYOUT, values.Get("ENDDATE"))
+
+		answer := url.Values{"ACK": {"Success"}}
+		rows := 0
+		for i := count - 1; i >= 0; i-- {
+			at := start.Add(time.Duration(i) * time.Hour)
+			if at.Before(from) || at.After(to) {
+				continue
+			}
+			if rows == paypal.SEARCH_RESULT_LIMIT {
+				answer.Set("ACK", "SuccessWithWarning")
+				answer.Set("L_ERRORCODE0", paypal.ERROR_SEARCH_TRUNCATED)
+				answer.Set("L_SEVERITYCODE0", "Warning")
+				break
+			}
+			answer.Set(fmt.Sprintf("L_TRANSACTIONID%d", rows), fmt.Sprintf("TX%03d", i))
+			answer.Set(fmt.Sprintf("L_TIMESTAMP%d", rows), at.Format(paypal.NVP_DATE_LAYOUT))
+			answer.Set(fmt.Sprintf("L_AMT%d", rows), "1.00")
+			rows++
+		}
+		return answer.Encode()
+	})
+}
+
+func TestSearchAll(t *testing.T) {
+	start := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	calls := 0
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: hourlyTransactions(start, 250, &calls)})
+
+	results := client.SearchAll(paypal.PayPalTransactionSearch{StartDate: start, EndDate: start.Add(300 * time.Hour)})
+	seen := map[string]bool{}
+	for results.Next() {
+		seen[results.Result().TransactionId] = true
+	}
+	if err := results.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(seen) != 250 || !seen["TX000"] || !seen["TX249"] || results.Truncated() != 0 {
+		t.Errorf("Expected all 250 transactions, got %d after %d calls", len(seen), calls)
+	}
+}
+
+func TestSearchAllStopsOnError(t *testing.T) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		return "ACK=Failure&L_ERRORCODE0=10001"
+	})})
+
+	results := client.SearchAll(paypal.PayPalTransactionSearch{StartDate: time.Now().Add(-time.Hour)})
+	if results.Next() || results.Err() == nil {
+		t.Errorf("Expected the iteration to stop with the call's error")
+	}
+}
@@ -0,0 +1,194 @@
+package paypal
+
+import (
+	"strconv"
+	"time"
+)
+
+// STATUS values TransactionSearch accepts
+var searchStatuses = map[string]bool{
+	"Pending":    true,
+	"Processing": true,
+	"Success":    true,
+	"Denied":     true,
+	"Reversed":   true,
+}
+
+var transactionClasses = map[string]bool{
+	TRANSACTION_CLASS_ALL:                  true,
+	TRANSACTION_CLASS_SENT:                 true,
+	TRANSACTION_CLASS_RECEIVED:             true,
+	TRANSACTION_CLASS_MASS_PAY:             true,
+	TRANSACTION_CLASS_MONEY_REQUEST:        true,
+	TRANSACTION_CLASS_FUNDS_ADDED:          true,
+	TRANSACTION_CLASS_FUNDS_WITHDRAWN:      true,
+	TRANSACTION_CLASS_REFERRAL:             true,
+	TRANSACTION_CLASS_FEE:                  true,
+	TRANSACTION_CLASS_SUBSCRIPTION:         true,
+	TRANSACTION_CLASS_DIVIDEND:             true,
+	TRANSACTION_CLASS_BILLPAY:              true,
+	TRANSACTION_CLASS_REFUND:               true,
+	TRANSACTION_CLASS_CURRENCY_CONVERSIONS: true,
+	TRANSACTION_CLASS_BALANCE_TRANSFER:     true,
+	TRANSACTION_CLASS_REVERSAL:             true,
+	TRANSACTION_CLASS_SHIPPING:             true,
+	TRANSACTION_CLASS_BALANCE_AFFECTING:    true,
+	TRANSACTION_CLASS_ECHECK:               true,
+}
+
+// Returned by SearchQuery.Build for filters PayPal would reject or that
+// contradict each other
+type SearchQueryError struct {
+	Filter string
+	Detail string
+}
+
+func (e *SearchQueryError) Error() string {
+	return "PayPal search " + e.Filter + " " + e.Detail
+}
+
+// Builds a PayPalTransactionSearch one filter at a time and checks it before
+// anything is sent:
+//
+//	response, err := client.Search(paypal.NewSearchQuery().
+//		Between(monday, friday).
+//		ByEmail("buyer@example.com").
+//		ByStatus("Success"))
+type SearchQuery struct {
+	search PayPalTransactionSearch
+	// first mistake made while building, reported by Build
+	err error
+}
+
+func NewSearchQuery() *SearchQuery {
+	return &SearchQuery{}
+}
+
+// Searches transactions made in [start, end]. Both are sent in UTC, to the
+// second
+func (q *SearchQuery) Between(start, end time.Time) *SearchQuery {
+	q.setDates(start, end)
+	if q.err == nil && end.Before(start) {
+		q.err = &SearchQueryError{"ENDDATE", "is before STARTDATE"}
+	}
+	return q
+}
+
+// Searches transactions made since start, up to now
+func (q *SearchQuery) Since(start time.Time) *SearchQuery {
+	q.setDates(start, time.Time{})
+	return q
+}
+
+func (q *SearchQuery) setDates(start, end time.Time) {
+	if !q.search.StartDate.IsZero() {
+		q.fail("STARTDATE", "set twice")
+	}
+	q.search.StartDate, q.search.EndDate = start.Truncate(time.Second), end.Truncate(time.Second)
+}
+
+func (q *SearchQuery) ByTransactionId(transactionId string) *SearchQuery {
+	q.set("TRANSACTIONID", &q.search.TransactionId, transactionId)
+	return q
+}
+
+// Searches by the buyer's or receiver's email, whichever is not this account
+func (q *SearchQuery) ByEmail(email string) *SearchQuery {
+	q.set("EMAIL", &q.search.Email, email)
+	return q
+}
+
+func (q *SearchQuery) ByInvoice(invoiceId string) *SearchQuery {
+	q.set("INVNUM", &q.search.InvoiceId, invoiceId)
+	return q
+}
+
+// One of Pending, Processing, Success, Denied or Reversed
+func (q *SearchQuery) ByStatus(status string) *SearchQuery {
+	if !searchStatuses[status] {
+		q.fail("STATUS", "cannot be "+strconv.Quote(status))
+	}
+	q.set("STATUS", &q.search.Status, status)
+	return q
+}
+
+// One of the TRANSACTION_CLASS_ values
+func (q *SearchQuery) ByTransactionClass(class string) *SearchQuery {
+	if len(class) != 0 && !transactionClasses[class] {
+		q.fail("TRANSACTIONCLASS", "cannot be "+strconv.Quote(class))
+	}
+	q.set("TRANSACTIONCLASS", &q.search.TransactionClass, class)
+	return q
+}
+
+// Searches by the receipt number from the buyer's confirmation email
+func (q *SearchQuery) ByReceiptId(receiptId string) *SearchQuery {
+	q.set("RECEIPTID", &q.search.ReceiptId, receiptId)
+	return q
+}
+
+func (q *SearchQuery) ByAuctionItem(itemNumber string) *SearchQuery {
+	q.set("AUCTIONITEMNUMBER", &q.search.AuctionItemNumber, itemNumber)
+	return q
+}
+
+// Searches the payments of a recurring payments profile
+func (q *SearchQuery) ByProfileId(profileId string) *SearchQuery {
+	q.set("PROFILEID", &q.search.ProfileId, profileId)
+	return q
+}
+
+func (q *SearchQuery) set(filter string, field *string, value string) {
+	if len(value) == 0 {
+		q.fail(filter, "is empty")
+	} else if len(*field) != 0 && *field != value {
+		q.fail(filter, "set twice, to "+strconv.Quote(*field)+" and "+strconv.Quote(value))
+	}
+	*field = value
+}
+
+func (q *SearchQuery) fail(filter, detail string) {
+	if q.err == nil {
+		q.err = &SearchQueryError{filter, detail}
+	}
+}
+
+// Returns the search, or a *SearchQueryError when it is incomplete or its
+// filters conflict
+func (q *SearchQuery) Build() (PayPalTransactionSearch, error) {
+	if q.err != nil {
+		return PayPalTransactionSearch{}, q.err
+	}
+
+	search := q.search
+	if search.StartDate.IsZero() {
+		return PayPalTransactionSearch{}, &SearchQueryError{"STARTDATE", "is required, use Between or Since"}
+	}
+	// a transaction id matches a single transaction, so any other filter
+	// either repeats it or hides it
+	if len(search.TransactionId) != 0 {
+		for filter, value := range map[string]string{
+			"EMAIL":             search.Email,
+			"INVNUM":            search.InvoiceId,
+			"STATUS":            search.Status,
+			"TRANSACTIONCLASS":  search.TransactionClass,
+			"RECEIPTID":         search.ReceiptId,
+			"AUCTIONITEMNUMBER": search.AuctionItemNumber,
+			"PROFILEID":         search.ProfileId,
+		} {
+			if len(value) != 0 {
+				return PayPalTransactionSearch{}, &SearchQueryError{filter, "cannot be combined with TRANSACTIONID"}
+			}
+		}
+	}
+	return search, nil
+}
+
+// Checks query and calls TransactionSearch with it
+func (pClient *PayPalClient) Search(query *SearchQuery, opts ...CallOption) (*PayPalResponse, error) {
+	search, err := query.Build()
+	if err != nil {
+		return nil, err
+	}
+	return pClient.TransactionSearch(search, opts...)
+}
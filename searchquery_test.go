@@ -0,0 +1,86 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSearchQuery(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success"
+	})})
+
+	berlin := time.FixedZone("CEST", 2*60*60)
+	start := time.Date(2016, 3, 1, 10, 30, 15, 500000000, berlin)
+	query := paypal.NewSearchQuery().
+		Between(start, start.Add(48*time.Hour)).
+		ByEmail("buyer@example.com").
+		ByStatus("Success").
+		ByTransactionClass("Received")
+	if _, err := client.Search(query); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"STARTDATE":        "2016-03-01T08:30:15Z",
+		"ENDDATE":          "2016-03-03T08:30:15Z",
+		"EMAIL":            "buyer@example.com",
+		"STATUS":           "Success",
+		"TRANSACTIONCLASS": "Received",
+	}
+	for key, value := range expected {
+		if sent.Get(key) != value {
+			t.Errorf("Expected %s=%s, got %q", key, value, sent.Get(key))
+		}
+	}
+}
+
+func TestSearchQueryErrors(t *testing.T) {
+	start := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]*paypal.SearchQuery{
+		"STARTDATE":         paypal.NewSearchQuery().ByEmail("buyer@example.com"),
+		"ENDDATE":           paypal.NewSearchQuery().Between(start, start.Add(-time.Hour)),
+		"STATUS":            paypal.NewSearchQuery().Since(start).ByStatus("Completed"),
+		"INVNUM":            paypal.NewSearchQuery().Since(start).ByInvoice("INV-1").ByInvoice("INV-2"),
+		"EMAIL":             paypal.NewSearchQuery().Since(start).ByTransactionId("4HD5").ByEmail("buyer@example.com"),
+		"TRANSACTIONCLASS":  paypal.NewSearchQuery().Since(start).ByTransactionClass(""),
+		"AUCTIONITEMNUMBER": paypal.NewSearchQuery().Since(start).ByTransactionId("4HD5").ByAuctionItem("110171065741"),
+	}
+	for filter, query := range tests {
+		_, err := query.Build()
+		if queryError, ok := err.(*paypal.SearchQueryError); !ok || queryError.Filter != filter {
+			t.Errorf("Expected a %s error, got %v", filter, err)
+		}
+	}
+}
+
+func TestSearchQueryLookups(t *testing.T) {
+	var sent url.Values
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		sent = values
+		return "ACK=Success"
+	})})
+
+	start := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+	_, err := client.Search(paypal.NewSearchQuery().Since(start).
+		ByReceiptId("3325-1234-5678-9012").
+		ByAuctionItem("110012345678").
+		ByTransactionClass(paypal.TRANSACTION_CLASS_REFUND))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if sent.Get("RECEIPTID") != "3325-1234-5678-9012" || sent.Get("AUCTIONITEMNUMBER") != "110012345678" || sent.Get("TRANSACTIONCLASS") != "Refund" {
+		t.Errorf("Unexpected search %v", sent)
+	}
+
+	_, err = paypal.NewSearchQuery().Since(start).ByTransactionClass("Refunds").Build()
+	if queryError, ok := err.(*paypal.SearchQueryError); !ok || queryError.Filter != "TRANSACTIONCLASS" {
+		t.Errorf("Expected an unknown transaction class to be rejected, got %v", err)
+	}
+}
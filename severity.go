@@ -0,0 +1,51 @@
+package paypal
+
+import "strings"
+
+// How L_ERRORCODEn entries with L_SEVERITYCODEn=Warning are treated. By
+// default they fail the call like errors do.
+type WarningPolicy struct {
+	// Reports warnings in the response's Warnings instead of failing
+	NonFatal bool
+	// Per error code overrides of NonFatal, true for non-fatal
+	Codes map[string]bool
+}
+
+func (policy WarningPolicy) nonFatal(errorCode string) bool {
+	if nonFatal, ok := policy.Codes[errorCode]; ok {
+		return nonFatal
+	}
+	return policy.NonFatal
+}
+
+// Chooses, per error code, whether warnings PayPal returns with an
+// otherwise successful call fail it. Entries with SeverityCode=Error and
+// ACK=Failure responses always fail.
+func (pClient *PayPalClient) SetWarningPolicy(policy WarningPolicy) {
+	pClient.warningPolicy = policy
+}
+
+// Index of the L_ERRORCODEn entry the call fails with, adding the
+// non-fatal warnings to response.Warnings
+func (pClient *PayPalClient) failingEntry(response *PayPalResponse) (int, bool) {
+	values := response.Values
+	ack := strings.ToLower(response.Ack)
+	failedAck := ack == "failure" || ack == "failurewithwarning"
+
+	failing := -1
+	for i := 0; len(values.Get(listKey("L_ERRORCODE", i))) != 0; i++ {
+		errorCode := values.Get(listKey("L_ERRORCODE", i))
+		if strings.EqualFold(values.Get(listKey("L_SEVERITYCODE", i)), "Warning") && pClient.warningPolicy.nonFatal(errorCode) {
+			response.Warnings = append(response.Warnings, "PayPal warning "+errorCode+": "+values.Get(listKey("L_SHORTMESSAGE", i)))
+			continue
+		}
+		if failing < 0 {
+			failing = i
+		}
+	}
+
+	if failing >= 0 {
+		return failing, true
+	}
+	return 0, failedAck
+}
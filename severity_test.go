@@ -0,0 +1,41 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestWarningPolicy(t *testing.T) {
+	answer := "ACK=SuccessWithWarning&TOKEN=EC-1&L_ERRORCODE0=11812&L_SHORTMESSAGE0=Invalid+Data&L_SEVERITYCODE0=Warning"
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		return answer
+	})})
+
+	if _, err := client.GetExpressCheckoutDetails("EC-1"); err == nil {
+		t.Errorf("Expected warnings to fail the call by default")
+	}
+
+	client.SetWarningPolicy(paypal.WarningPolicy{Codes: map[string]bool{"11812": true}})
+	response, err := client.GetExpressCheckoutDetails("EC-1")
+	if err != nil {
+		t.Fatalf("Expected 11812 to be non-fatal, got %v", err)
+	}
+	if len(response.Warnings) != 1 || response.Warnings[0] != "PayPal warning 11812: Invalid Data" {
+		t.Errorf("Warning not reported: %#v", response.Warnings)
+	}
+
+	answer += "&L_ERRORCODE1=10004&L_SHORTMESSAGE1=Invalid+argument&L_SEVERITYCODE1=Warning"
+	_, err = client.GetExpressCheckoutDetails("EC-1")
+	if pError, ok := err.(*paypal.PayPalError); !ok || pError.ErrorCode != "10004" {
+		t.Errorf("Expected the call to fail with the fatal warning 10004, got %#v", err)
+	}
+
+	client.SetWarningPolicy(paypal.WarningPolicy{NonFatal: true})
+	answer = "ACK=Failure&L_ERRORCODE0=10486&L_SEVERITYCODE0=Warning"
+	if _, err = client.GetExpressCheckoutDetails("EC-1"); err == nil {
+		t.Errorf("An ACK=Failure response must fail even when its warnings are non-fatal")
+	}
+}
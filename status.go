@@ -0,0 +1,61 @@
+package paypal
+
+import "strings"
+
+// PAYERSTATUS or payer_status: whether the buyer's PayPal account is
+// verified
+type PayerStatus string
+
+const (
+	PAYER_STATUS_VERIFIED   PayerStatus = "verified"
+	PAYER_STATUS_UNVERIFIED PayerStatus = "unverified"
+)
+
+// ADDRESSSTATUS or address_status: whether PayPal has confirmed the
+// shipping address belongs to the buyer
+type AddressStatus string
+
+const (
+	ADDRESS_STATUS_NONE        AddressStatus = "None"
+	ADDRESS_STATUS_CONFIRMED   AddressStatus = "Confirmed"
+	ADDRESS_STATUS_UNCONFIRMED AddressStatus = "Unconfirmed"
+)
+
+// The NVP API and IPN spell the statuses with different cases
+func parsePayerStatus(value string) PayerStatus {
+	return PayerStatus(strings.ToLower(value))
+}
+
+func parseAddressStatus(value string) AddressStatus {
+	switch strings.ToLower(value) {
+	case "confirmed":
+		return ADDRESS_STATUS_CONFIRMED
+	case "unconfirmed":
+		return ADDRESS_STATUS_UNCONFIRMED
+	case "none":
+		return ADDRESS_STATUS_NONE
+	}
+	return AddressStatus(value)
+}
+
+// Which orders a merchant ships without looking at them first, e.g. only
+// to confirmed addresses to keep seller protection
+type ShippingPolicy struct {
+	RequireVerifiedPayer    bool
+	RequireConfirmedAddress bool
+}
+
+func (policy ShippingPolicy) holds(payer PayerStatus, address AddressStatus) bool {
+	if policy.RequireVerifiedPayer && payer != PAYER_STATUS_VERIFIED {
+		return true
+	}
+	return policy.RequireConfirmedAddress && address != ADDRESS_STATUS_CONFIRMED
+}
+
+func (details *PayPalCheckoutDetails) ShouldHoldForReview(policy ShippingPolicy) bool {
+	return policy.holds(details.PayerStatus, details.ShippingAddress.Status)
+}
+
+func (message *IPNMessage) ShouldHoldForReview(policy ShippingPolicy) bool {
+	return policy.holds(message.PayerStatus, message.Address.Status)
+}
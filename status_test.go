@@ -0,0 +1,33 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/url"
+	"testing"
+)
+
+func TestShouldHoldForReview(t *testing.T) {
+	values, _ := url.ParseQuery("PAYERSTATUS=verified&PAYMENTREQUEST_0_SHIPTOSTREET=1+Main+St&PAYMENTREQUEST_0_ADDRESSSTATUS=Unconfirmed")
+	details := new(paypal.PayPalCheckoutDetails)
+	details.Populate(values)
+
+	if details.PayerStatus != paypal.PAYER_STATUS_VERIFIED || details.ShippingAddress.Status != paypal.ADDRESS_STATUS_UNCONFIRMED {
+		t.Fatalf("Statuses not decoded: %#v", details)
+	}
+	if details.ShouldHoldForReview(paypal.ShippingPolicy{RequireVerifiedPayer: true}) {
+		t.Errorf("Verified payer held by a verified-payer policy")
+	}
+	if !details.ShouldHoldForReview(paypal.ShippingPolicy{RequireConfirmedAddress: true}) {
+		t.Errorf("Unconfirmed address not held by a confirmed-address policy")
+	}
+
+	ipn, _ := url.ParseQuery("payer_status=unverified&address_status=confirmed")
+	message := paypal.ParseIPNMessage(ipn)
+	if message.ShouldHoldForReview(paypal.ShippingPolicy{RequireConfirmedAddress: true}) {
+		t.Errorf("Confirmed IPN address held: %#v", message.Address)
+	}
+	if !message.ShouldHoldForReview(paypal.ShippingPolicy{RequireVerifiedPayer: true}) {
+		t.Errorf("Unverified IPN payer not held")
+	}
+}
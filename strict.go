@@ -0,0 +1,134 @@
+package paypal
+
+import (
+	"sort"
+	"strings"
+)
+
+// The keys a successful response to an API method must contain and the keys
+// it may contain, with every number replaced by n as in FieldLengthLimits.
+// A Known key ending in * matches every key starting with the rest.
+type ResponseSchema struct {
+	Required []string
+	Known    []string
+}
+
+// Schemas checked by strict response parsing, keyed by METHOD. Methods
+// missing from the map are not checked. Add or adjust entries as PayPal
+// documents new fields.
+var ResponseSchemas = map[string]ResponseSchema{
+	"SetExpressCheckout": {
+		Required: []string{"TOKEN"},
+		Known:    []string{"TOKEN"},
+	},
+	"GetExpressCheckoutDetails": {
+		Required: []string{"TOKEN", "CHECKOUTSTATUS"},
+		Known: []string{"TOKEN", "CHECKOUTSTATUS", "PAYERID", "PAYERSTATUS", "EMAIL", "FIRSTNAME", "MIDDLENAME", "LASTNAME", "SUFFIX",
+			"SALUTATION", "BUSINESS", "COUNTRYCODE", "PHONENUM", "NOTE", "CUSTOM", "INVNUM", "CURRENCYCODE", "AMT", "ITEMAMT",
+			"SHIPPINGAMT", "HANDLINGAMT", "TAXAMT", "INSURANCEAMT", "SHIPDISCAMT", "INSURANCEOPTIONOFFERED", "INSURANCEOPTIONSELECTED",
+			"BILLINGAGREEMENTACCEPTEDSTATUS", "REDIRECTREQUIRED", "ADDRESSSTATUS", "SHIPTO*", "DESC", "NOTETEXT", "TRANSACTIONID",
+			"PAYMENTREQUEST_n_*", "L_PAYMENTREQUEST_n_*", "PAYMENTREQUESTINFO_n_*", "L_*", "SHIPPINGCALCULATIONMODE",
+			"SHIPPINGOPTIONISDEFAULT", "SHIPPINGOPTIONNAME", "SHIPPINGOPTIONAMOUNT", "GIFT*", "BUYERMARKETINGEMAIL", "SURVEY*"},
+	},
+	"DoExpressCheckoutPayment": {
+		Required: []string{"PAYMENTINFO_n_TRANSACTIONID"},
+		Known: []string{"TOKEN", "PAYMENTINFO_n_*", "PAYMENTREQUEST_n_*", "SUCCESSPAGEREDIRECTREQUESTED", "INSURANCEOPTIONSELECTED",
+			"SHIPPINGOPTIONISDEFAULT", "REDIRECTREQUIRED", "NOTE", "BILLINGAGREEMENTID", "MSGSUBID"},
+	},
+	"DoAuthorization": {
+		Required: []string{"TRANSACTIONID"},
+		Known:    []string{"TRANSACTIONID", "AMT", "CURRENCYCODE", "PAYMENTSTATUS", "PENDINGREASON", "PROTECTIONELIGIBILITY*", "REASONCODE", "MSGSUBID"},
+	},
+	"DoCapture": {
+		Required: []string{"AUTHORIZATIONID", "TRANSACTIONID"},
+		Known: []string{"AUTHORIZATIONID", "TRANSACTIONID", "PARENTTRANSACTIONID", "RECEIPTID", "TRANSACTIONTYPE", "PAYMENTTYPE",
+			"EXPECTEDECHECKCLEARDATE", "ORDERTIME", "AMT", "FEEAMT", "SETTLEAMT", "TAXAMT", "EXCHANGERATE", "CURRENCYCODE",
+			"PAYMENTSTATUS", "PENDINGREASON", "REASONCODE", "PROTECTIONELIGIBILITY*", "MSGSUBID"},
+	},
+	"DoVoid": {
+		Required: []string{"AUTHORIZATIONID"},
+		Known:    []string{"AUTHORIZATIONID", "MSGSUBID"},
+	},
+	"DoReauthorization": {
+		Required: []string{"AUTHORIZATIONID"},
+		Known:    []string{"AUTHORIZATIONID", "PAYMENTSTATUS", "PENDINGREASON", "PROTECTIONELIGIBILITY*", "MSGSUBID"},
+	},
+	"RefundTransaction": {
+		Required: []string{"REFUNDTRANSACTIONID"},
+		Known: []string{"REFUNDTRANSACTIONID", "FEEREFUNDAMT", "GROSSREFUNDAMT", "NETREFUNDAMT", "TOTALREFUNDEDAMOUNT",
+			"CURRENCYCODE", "REFUNDINFO", "REFUNDSTATUS", "PENDINGREASON", "MSGSUBID"},
+	},
+	"GetBalance": {
+		Known: []string{"L_AMTn", "L_CURRENCYCODEn"},
+	},
+	"TransactionSearch": {
+		Known: []string{"L_*"},
+	},
+}
+
+var envelopeKeys = []string{"ACK", "CORRELATIONID", "TIMESTAMP", "VERSION", "BUILD", "L_ERRORCODEn", "L_SHORTMESSAGEn", "L_LONGMESSAGEn", "L_SEVERITYCODEn"}
+
+// Returned in strict mode when a successful response lacks keys its
+// ResponseSchema requires. The response is returned along with it.
+type ResponseSchemaError struct {
+	Method        string
+	Missing       []string
+	CorrelationId string
+}
+
+func (e *ResponseSchemaError) Error() string {
+	return "PayPal " + e.Method + " response is missing " + strings.Join(e.Missing, ", ") + " (correlation id " + e.CorrelationId + ")"
+}
+
+// In strict mode successful responses are checked against ResponseSchemas:
+// keys the schema doesn't know are added to the response's Warnings, and
+// missing required keys fail the call with a *ResponseSchemaError, so
+// changes on PayPal's side show up before they turn into zero values
+func (pClient *PayPalClient) SetStrictResponses(strict bool) {
+	pClient.strictResponses = strict
+}
+
+func matchesSchemaKey(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "*") {
+			if strings.HasPrefix(key, pattern[:len(pattern)-1]) {
+				return true
+			}
+		} else if pattern == key {
+			return true
+		}
+	}
+	return false
+}
+
+func checkResponseSchema(method string, response *PayPalResponse) error {
+	schema, ok := ResponseSchemas[method]
+	if !ok {
+		return nil
+	}
+
+	present := make(map[string]bool, len(response.Values))
+	var unknown []string
+	for key := range response.Values {
+		schemaKey := fieldLimitKey(key)
+		present[schemaKey] = true
+		if !matchesSchemaKey(envelopeKeys, schemaKey) && !matchesSchemaKey(schema.Known, schemaKey) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		response.Warnings = append(response.Warnings, "unknown "+method+" response key "+key)
+	}
+
+	var missing []string
+	for _, key := range schema.Required {
+		if !present[key] {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) != 0 {
+		return &ResponseSchemaError{method, missing, response.CorrelationId}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestStrictResponses(t *testing.T) {
+	body := "ACK=Success&TOKEN=EC-1"
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		return body
+	})})
+
+	body = "ACK=Success&TOKEN=EC-1&NEWFIELD=1"
+	response, err := client.SetExpressCheckout(paypal.PayPalOrder{CurrencyCode: "USD"}, nil)
+	if err != nil || len(response.Warnings) != 0 {
+		t.Errorf("Lenient client checked the response: %#v, %#v", err, response.Warnings)
+	}
+
+	client.SetStrictResponses(true)
+	response, err = client.SetExpressCheckout(paypal.PayPalOrder{CurrencyCode: "USD"}, nil)
+	if err != nil {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+	if len(response.Warnings) != 1 || response.Warnings[0] != "unknown SetExpressCheckout response key NEWFIELD" {
+		t.Errorf("Unknown key not reported: %#v", response.Warnings)
+	}
+
+	body = "ACK=Success&CORRELATIONID=abc&PAYMENTINFO_0_AMT=10.00"
+	response, err = client.DoExpressCheckoutSale("EC-1", "PAYER", "USD", 10)
+	schemaErr, ok := err.(*paypal.ResponseSchemaError)
+	if !ok || len(schemaErr.Missing) != 1 || schemaErr.Missing[0] != "PAYMENTINFO_n_TRANSACTIONID" {
+		t.Errorf("Expected a *ResponseSchemaError for the missing transaction id, got %#v", err)
+	}
+	if response == nil {
+		t.Errorf("Response not returned with the schema error")
+	}
+
+	body = "ACK=Success&L_TRANSACTIONID0=TXN&L_AMT0=1.00"
+	if response, err := client.TransactionSearch(paypal.PayPalTransactionSearch{}); err != nil || len(response.Warnings) != 0 {
+		t.Errorf("List keys not matched by the schema: %#v, %#v", err, response.Warnings)
+	}
+}
@@ -0,0 +1,142 @@
+package paypal
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Remembers how far a TransactionSync got. Implementations must be safe
+// for concurrent use when several syncs share them.
+type SyncCursorStore interface {
+	// Returns the zero time for syncs that never completed a run
+	Load(name string) (time.Time, error)
+	Save(name string, until time.Time) error
+}
+
+type MemorySyncCursorStore struct {
+	mu      sync.Mutex
+	cursors map[string]time.Time
+}
+
+// Only suited to tests and single runs; ingestion jobs need a store that
+// survives restarts
+func NewMemorySyncCursorStore() *MemorySyncCursorStore {
+	return &MemorySyncCursorStore{cursors: make(map[string]time.Time)}
+}
+
+func (s *MemorySyncCursorStore) Load(name string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cursors[name], nil
+}
+
+func (s *MemorySyncCursorStore) Save(name string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursors[name] = until
+	return nil
+}
+
+// Returned by TransactionSync.Run when a window of MIN_SEARCH_WINDOW held
+// more than SEARCH_RESULT_LIMIT rows, so some of them could not be read.
+// The cursor is left at the start of that window, never past it, and every
+// following run reads it again and fails the same way until the rows are
+// fetched another way, e.g. with GetTransactionDetails.
+type SyncTruncatedError struct {
+	Name string
+	// Start of the earliest truncated window
+	From time.Time
+	// Number of truncated windows
+	Windows int
+}
+
+func (e *SyncTruncatedError) Error() string {
+	return fmt.Sprintf("PayPal transaction sync %s stopped at %s: %d search windows had more than %d transactions", e.Name, e.From.Format(time.RFC3339), e.Windows, SEARCH_RESULT_LIMIT)
+}
+
+// What one TransactionSync run read
+type SyncRun struct {
+	From, Until time.Time
+	Rows        int
+	// See SearchIterator.Truncated
+	Truncated int
+}
+
+// Feeds the account's transactions to a handler incrementally, e.g. to load
+// them into a data warehouse: each run searches from where the last
+// successful one stopped, up to Lag ago.
+//
+// A run only moves the cursor once every row was handled, so a failing
+// handler or call makes the next run read the same window again. Together
+// with Overlap this means rows can be handled more than once; handlers
+// must be idempotent, typically keyed by TransactionId.
+type TransactionSync struct {
+	// Key of the cursor in the store
+	Name string
+	// Where the first run starts
+	Start time.Time
+	// Rows PayPal has not indexed yet are missed by a search, so every run
+	// also reads the last Overlap of the previous one again
+	Overlap time.Duration
+	// How far behind now runs stop
+	Lag time.Duration
+
+	client *PayPalClient
+	store  SyncCursorStore
+}
+
+func NewTransactionSync(client *PayPalClient, store SyncCursorStore, name string, start time.Time) *TransactionSync {
+	return &TransactionSync{
+		Name:    name,
+		Start:   start,
+		Overlap: 10 * time.Minute,
+		Lag:     time.Minute,
+		client:  client,
+		store:   store,
+	}
+}
+
+// Handles every transaction since the last run. Stops at the first error,
+// from PayPal, the handler or the store, without moving the cursor. A run
+// that could not read every row returns a *SyncTruncatedError, having moved
+// the cursor only up to the rows it missed.
+func (s *TransactionSync) Run(handle func(PayPalSearchResult) error, opts ...CallOption) (SyncRun, error) {
+	cursor, err := s.store.Load(s.Name)
+	if err != nil {
+		return SyncRun{}, err
+	}
+	from := s.Start
+	if !cursor.IsZero() && cursor.Add(-s.Overlap).After(s.Start) {
+		from = cursor.Add(-s.Overlap)
+	}
+	run := SyncRun{From: from, Until: time.Now().Add(-s.Lag).Truncate(time.Second)}
+	if !run.Until.After(from) {
+		return run, nil
+	}
+
+	results := s.client.SearchAll(PayPalTransactionSearch{StartDate: from, EndDate: run.Until}, opts...)
+	for results.Next() {
+		if err = handle(results.Result()); err != nil {
+			return run, err
+		}
+		run.Rows++
+	}
+	if err = results.Err(); err != nil {
+		return run, err
+	}
+	run.Truncated = results.Truncated()
+
+	if run.Truncated > 0 {
+		resume := results.TruncatedFrom()
+		// the window can lie in the overlap, behind the cursor; moving the
+		// cursor back would make every run start an Overlap earlier
+		if resume.After(cursor) {
+			if err = s.store.Save(s.Name, resume); err != nil {
+				return run, err
+			}
+		}
+		return run, &SyncTruncatedError{Name: s.Name, From: resume, Windows: run.Truncated}
+	}
+	return run, s.store.Save(s.Name, run.Until)
+}
@@ -0,0 +1,87 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTransactionSync(t *testing.T) {
+	// the last of the hourly transactions is half an hour old, inside the
+	// second run's overlap whatever the time of day
+	start := time.Now().Add(-99*time.Hour - 30*time.Minute).Truncate(time.Second)
+	calls := 0
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: hourlyTransactions(start, 100, &calls)})
+
+	store := paypal.NewMemorySyncCursorStore()
+	ingest := paypal.NewTransactionSync(client, store, "warehouse", start)
+	ingest.Overlap = 90 * time.Minute
+
+	failing := errors.New("warehouse down")
+	if _, err := ingest.Run(func(paypal.PayPalSearchResult) error { return failing }); err != failing {
+		t.Fatalf("Expected the handler's error, got %v", err)
+	}
+	if until, _ := store.Load("warehouse"); !until.IsZero() {
+		t.Errorf("Expected the cursor to stay put after a failed run, got %s", until)
+	}
+
+	seen := map[string]int{}
+	handle := func(row paypal.PayPalSearchResult) error {
+		seen[row.TransactionId]++
+		return nil
+	}
+	first, err := ingest.Run(handle)
+	if err != nil || first.Rows != 100 || len(seen) != 100 {
+		t.Fatalf("Expected all 100 transactions, got %+v, %v", first, err)
+	}
+	if until, _ := store.Load("warehouse"); !until.Equal(first.Until) {
+		t.Errorf("Expected the cursor at %s, got %s", first.Until, until)
+	}
+
+	second, err := ingest.Run(handle)
+	if err != nil || !second.From.Equal(first.Until.Add(-90*time.Minute)) {
+		t.Fatalf("Expected the second run to start an overlap before the first ended, got %+v, %v", second, err)
+	}
+	if second.Rows == 0 || second.Rows > 2 {
+		t.Errorf("Expected only the overlap to be read again, got %d rows", second.Rows)
+	}
+}
+
+func TestTransactionSyncTruncated(t *testing.T) {
+	start := time.Now().Add(-10 * time.Hour).Truncate(time.Hour)
+	burst := start.Add(5 * time.Hour)
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		from, _ := time.Parse(paypal.NVP_DATE_LAYOUT, values.Get("STARTDATE"))
+		to, _ := time.Parse(paypal.NVP_DATE_LAYOUT, values.Get("ENDDATE"))
+		if burst.Before(from) || burst.After(to) {
+			return "ACK=Success"
+		}
+		// more transactions in one second than a search returns
+		answer := url.Values{"ACK": {"SuccessWithWarning"}, "L_ERRORCODE0": {paypal.ERROR_SEARCH_TRUNCATED}, "L_SEVERITYCODE0": {"Warning"}}
+		for i := 0; i < paypal.SEARCH_RESULT_LIMIT; i++ {
+			answer.Set(fmt.Sprintf("L_TRANSACTIONID%d", i), fmt.Sprintf("TX%03d", i))
+			answer.Set(fmt.Sprintf("L_TIMESTAMP%d", i), burst.Format(paypal.NVP_DATE_LAYOUT))
+		}
+		return answer.Encode()
+	})})
+
+	store := paypal.NewMemorySyncCursorStore()
+	ingest := paypal.NewTransactionSync(client, store, "warehouse", start)
+	for i := 0; i < 2; i++ {
+		run, err := ingest.Run(func(paypal.PayPalSearchResult) error { return nil })
+		truncated, ok := err.(*paypal.SyncTruncatedError)
+		if !ok || run.Truncated == 0 {
+			t.Fatalf("Expected a *SyncTruncatedError, got %+v, %v", run, err)
+		}
+		// the truncated window is narrower than two MIN_SEARCH_WINDOW
+		cursor, _ := store.Load("warehouse")
+		if cursor.After(burst) || burst.Sub(cursor) >= 2*paypal.MIN_SEARCH_WINDOW || truncated.From.After(burst) {
+			t.Errorf("Expected the cursor at the truncated window, not past %s, got %s (%s)", burst, cursor, truncated.From)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package paypal
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errNotStructPointer = errors.New("paypal: DecodeTagged needs a pointer to a struct")
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Fills the struct v points to from the response's fields, following nvp
+// struct tags, according to the client's ParseMode:
+//
+//	type BalanceResult struct {
+//		Amounts    []float64 `nvp:"L_AMT{n}"`
+//		Currencies []string  `nvp:"L_CURRENCYCODE{n}"`
+//		Refunds    []Refund  `nvp:"*"` // Refund's tags use {n} in turn
+//		Timestamp  time.Time `nvp:"TIMESTAMP"`
+//	}
+//
+// Fields can be strings, numbers, bools, time.Time or types based on them.
+// {n} is replaced by 0, 1... for as long as the response has the field;
+// slices of structs tagged "*" are filled for as long as any of the
+// element's fields is present. Untagged struct fields are decoded into as
+// if they were part of v.
+func (r *PayPalResponse) DecodeTagged(v interface{}) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Ptr || target.Elem().Kind() != reflect.Struct {
+		return errNotStructPointer
+	}
+	return r.Decode(taggedView{target.Elem()})
+}
+
+type taggedView struct {
+	target reflect.Value
+}
+
+func (view taggedView) decode(p *fieldParser) {
+	decodeStruct(p, view.target, "")
+}
+
+// index replaces {n} in the tags of nested list elements
+func decodeStruct(p *fieldParser, target reflect.Value, index string) {
+	structType := target.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if len(field.PkgPath) != 0 {
+			continue
+		}
+		value := target.Field(i)
+		tag, tagged := field.Tag.Lookup("nvp")
+		if !tagged {
+			if field.Type.Kind() == reflect.Struct && field.Type != timeType {
+				decodeStruct(p, value, index)
+			}
+			continue
+		}
+		if tag == "-" {
+			continue
+		}
+		if len(index) != 0 {
+			tag = strings.Replace(tag, "{n}", index, -1)
+		}
+
+		switch {
+		case tag == "*" && field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+			decodeStructList(p, value)
+		case strings.Contains(tag, "{n}") && field.Type.Kind() == reflect.Slice:
+			decodeList(p, value, tag)
+		default:
+			decodeField(p, value, tag)
+		}
+	}
+}
+
+func decodeList(p *fieldParser, list reflect.Value, tag string) {
+	for n := 0; n <= len(p.values); n++ {
+		key := strings.Replace(tag, "{n}", strconv.Itoa(n), -1)
+		if _, ok := p.values[key]; !ok {
+			break
+		}
+		element := reflect.New(list.Type().Elem()).Elem()
+		decodeField(p, element, key)
+		list.Set(reflect.Append(list, element))
+	}
+}
+
+func decodeStructList(p *fieldParser, list reflect.Value) {
+	elementType := list.Type().Elem()
+	for n := 0; n <= len(p.values); n++ {
+		index := strconv.Itoa(n)
+		if !hasElementField(p, elementType, index) {
+			break
+		}
+		element := reflect.New(elementType).Elem()
+		decodeStruct(p, element, index)
+		list.Set(reflect.Append(list, element))
+	}
+}
+
+func hasElementField(p *fieldParser, elementType reflect.Type, index string) bool {
+	for i := 0; i < elementType.NumField(); i++ {
+		tag, tagged := elementType.Field(i).Tag.Lookup("nvp")
+		if !tagged || !strings.Contains(tag, "{n}") {
+			continue
+		}
+		if _, ok := p.values[strings.Replace(tag, "{n}", index, -1)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeField(p *fieldParser, value reflect.Value, key string) {
+	raw := p.values.Get(key)
+	if value.Type() == timeType {
+		value.Set(reflect.ValueOf(p.time(key)))
+		return
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		value.SetBool(parseNVPBool(raw))
+	case reflect.Float32, reflect.Float64:
+		value.SetFloat(p.float(key))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if len(raw) == 0 {
+			return
+		}
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			p.failures = append(p.failures, key+": invalid integer "+strconv.Quote(raw))
+			return
+		}
+		value.SetInt(parsed)
+	default:
+		p.failures = append(p.failures, key+": cannot decode into "+value.Type().String())
+	}
+}
@@ -0,0 +1,88 @@
+package paypal
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// PayPal's answer for calls made with a checkout token past its lifetime
+const ERROR_TOKEN_EXPIRED = "10411"
+
+// Returned instead of calling PayPal with a token this client created more
+// than CHECKOUT_TOKEN_LIFETIME ago; the buyer has to start checkout again
+var ErrExpiredToken = errors.New("paypal: checkout token has expired")
+
+// How long expired tokens are remembered, so calls still made with them
+// fail with ErrExpiredToken rather than reaching PayPal
+const tokenRetention = 2 * CHECKOUT_TOKEN_LIFETIME
+
+// When each SetExpressCheckout token was handed out. Copies made by With
+// share the same tracker.
+type tokenTracker struct {
+	mu      sync.Mutex
+	created map[string]time.Time
+	now     func() time.Time
+}
+
+func newTokenTracker() *tokenTracker {
+	return &tokenTracker{created: make(map[string]time.Time), now: time.Now}
+}
+
+// Replaces the clock checkout token ages are measured with, e.g. to test
+// how an integration handles expired tokens without waiting three hours.
+// Clients made from this one with With share the clock.
+func (pClient *PayPalClient) SetClock(now func() time.Time) {
+	pClient.tokens.now = now
+}
+
+func (t *tokenTracker) record(token string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	at := t.now()
+	for known, created := range t.created {
+		if at.Sub(created) > tokenRetention {
+			delete(t.created, known)
+		}
+	}
+	t.created[token] = at
+}
+
+// Zero for tokens this client didn't create, or has forgotten
+func (t *tokenTracker) createdAt(token string) time.Time {
+	if t == nil || len(token) == 0 {
+		return time.Time{}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.created[token]
+}
+
+func (t *tokenTracker) check(token string) error {
+	created := t.createdAt(token)
+	if !created.IsZero() && t.now().Sub(created) > CHECKOUT_TOKEN_LIFETIME {
+		return ErrExpiredToken
+	}
+	return nil
+}
+
+// When the response's checkout token stops working. Zero when the token
+// wasn't created by this client, e.g. after a restart, so its age is unknown.
+func (response *PayPalResponse) ExpiresAt() time.Time {
+	if response.tokenCreatedAt.IsZero() {
+		return time.Time{}
+	}
+	return response.tokenCreatedAt.Add(CHECKOUT_TOKEN_LIFETIME)
+}
+
+// False when the token's age is unknown
+func (response *PayPalResponse) IsExpired() bool {
+	expires := response.ExpiresAt()
+	return !expires.IsZero() && time.Now().After(expires)
+}
@@ -0,0 +1,76 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTokenExpiry(t *testing.T) {
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		if values.Get("METHOD") == "SetExpressCheckout" {
+			return "ACK=Success&TOKEN=EC-NEW"
+		}
+		return "ACK=Success&TOKEN=" + values.Get("TOKEN")
+	})})
+
+	before := time.Now()
+	response, err := client.SetExpressCheckout(paypal.PayPalOrder{CurrencyCode: "USD"}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if expires := response.ExpiresAt(); expires.Before(before.Add(paypal.CHECKOUT_TOKEN_LIFETIME)) || expires.After(time.Now().Add(paypal.CHECKOUT_TOKEN_LIFETIME)) {
+		t.Errorf("Expected the token to expire %s after it was created, got %s", paypal.CHECKOUT_TOKEN_LIFETIME, expires)
+	}
+	if response.IsExpired() {
+		t.Errorf("A new token should not be expired")
+	}
+
+	details, err := client.GetExpressCheckoutDetails("EC-NEW")
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if !details.ExpiresAt().Equal(response.ExpiresAt()) {
+		t.Errorf("Expected details for the token to carry its expiry, got %s", details.ExpiresAt())
+	}
+
+	unknown, err := client.GetExpressCheckoutDetails("EC-OTHER")
+	if err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if !unknown.ExpiresAt().IsZero() || unknown.IsExpired() {
+		t.Errorf("A token from another client should have no known expiry")
+	}
+}
+
+func TestExpiredTokenOutlivesNewCheckouts(t *testing.T) {
+	checkouts := 0
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		if values.Get("METHOD") == "SetExpressCheckout" {
+			checkouts++
+			return fmt.Sprintf("ACK=Success&TOKEN=EC-%d", checkouts)
+		}
+		return "ACK=Success&TOKEN=" + values.Get("TOKEN")
+	})})
+	now := time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC)
+	client.SetClock(func() time.Time { return now })
+
+	if _, err := client.SetExpressCheckout(paypal.PayPalOrder{CurrencyCode: "USD"}, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	now = now.Add(paypal.CHECKOUT_TOKEN_LIFETIME + time.Minute)
+	if _, err := client.SetExpressCheckout(paypal.PayPalOrder{CurrencyCode: "USD"}, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := client.GetExpressCheckoutDetails("EC-1"); err != paypal.ErrExpiredToken {
+		t.Errorf("Expected the first token to have expired, got %v", err)
+	}
+	if _, err := client.GetExpressCheckoutDetails("EC-2"); err != nil {
+		t.Errorf("Expected the second token to work, got %v", err)
+	}
+}
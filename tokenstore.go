@@ -0,0 +1,216 @@
+package paypal
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Keeps data by checkout token in storage shared between web frontends, so
+// the buyer can return from PayPal to any of them. Implementations must be
+// safe for concurrent use.
+type TokenStore interface {
+	// ttl is how long the data is needed; stores may keep it longer
+	Put(token string, data []byte, ttl time.Duration) error
+	// Returns nil, nil for unknown or expired tokens
+	Get(token string) ([]byte, error)
+	Delete(token string) error
+}
+
+type storedToken struct {
+	data    []byte
+	expires time.Time
+}
+
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]storedToken
+}
+
+// Only suited to a single process; use a RedisTokenStore or SQLTokenStore
+// behind a load balancer
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: make(map[string]storedToken)}
+}
+
+func (s *MemoryTokenStore) Put(token string, data []byte, ttl time.Duration) error {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for known, stored := range s.tokens {
+		if now.After(stored.expires) {
+			delete(s.tokens, known)
+		}
+	}
+	s.tokens[token] = storedToken{data, now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryTokenStore) Get(token string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.tokens[token]
+	if !ok || time.Now().After(stored.expires) {
+		return nil, nil
+	}
+	return stored.data, nil
+}
+
+func (s *MemoryTokenStore) Delete(token string) error {
+	s.mu.Lock()
+	delete(s.tokens, token)
+	s.mu.Unlock()
+	return nil
+}
+
+// The few Redis commands RedisTokenStore needs, so any Redis client library
+// can be plugged in with a small adapter
+type RedisClient interface {
+	// SET key value EX ttl
+	SetEX(key string, value []byte, ttl time.Duration) error
+	// found is false when the key does not exist
+	Get(key string) (value []byte, found bool, err error)
+	Del(key string) error
+}
+
+type RedisTokenStore struct {
+	Client RedisClient
+	// Prepended to tokens to build the Redis keys, e.g. "paypal:token:"
+	KeyPrefix string
+}
+
+func NewRedisTokenStore(client RedisClient, keyPrefix string) *RedisTokenStore {
+	return &RedisTokenStore{Client: client, KeyPrefix: keyPrefix}
+}
+
+func (s *RedisTokenStore) Put(token string, data []byte, ttl time.Duration) error {
+	return s.Client.SetEX(s.KeyPrefix+token, data, ttl)
+}
+
+func (s *RedisTokenStore) Get(token string) ([]byte, error) {
+	data, found, err := s.Client.Get(s.KeyPrefix + token)
+	if err != nil || !found {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *RedisTokenStore) Delete(token string) error {
+	return s.Client.Del(s.KeyPrefix + token)
+}
+
+// Keeps tokens in a table created with e.g.
+//
+//	CREATE TABLE paypal_tokens (
+//		token VARCHAR(64) PRIMARY KEY,
+//		data BLOB NOT NULL,
+//		expires_at TIMESTAMP NOT NULL
+//	)
+//
+// Expired rows are ignored but not removed; delete them periodically with
+// DeleteExpired.
+type SQLTokenStore struct {
+	DB    *sql.DB
+	Table string
+	// Use $1, $2... placeholders instead of ?, e.g. for PostgreSQL
+	NumberedPlaceholders bool
+}
+
+func NewSQLTokenStore(db *sql.DB, table string) *SQLTokenStore {
+	return &SQLTokenStore{DB: db, Table: table}
+}
+
+// Replaces the ? placeholders in query when NumberedPlaceholders is set
+func (s *SQLTokenStore) bind(query string) string {
+	if !s.NumberedPlaceholders {
+		return query
+	}
+	var bound strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			bound.WriteString("$" + strconv.Itoa(n))
+		} else {
+			bound.WriteRune(c)
+		}
+	}
+	return bound.String()
+}
+
+func (s *SQLTokenStore) Put(token string, data []byte, ttl time.Duration) error {
+	// delete and insert rather than an upsert, which every database spells
+	// differently
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err = tx.Exec(s.bind("DELETE FROM "+s.Table+" WHERE token = ?"), token); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err = tx.Exec(s.bind("INSERT INTO "+s.Table+" (token, data, expires_at) VALUES (?, ?, ?)"), token, data, time.Now().Add(ttl).UTC()); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLTokenStore) Get(token string) ([]byte, error) {
+	var data []byte
+	err := s.DB.QueryRow(s.bind("SELECT data FROM "+s.Table+" WHERE token = ? AND expires_at > ?"), token, time.Now().UTC()).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (s *SQLTokenStore) Delete(token string) error {
+	_, err := s.DB.Exec(s.bind("DELETE FROM "+s.Table+" WHERE token = ?"), token)
+	return err
+}
+
+func (s *SQLTokenStore) DeleteExpired() error {
+	_, err := s.DB.Exec(s.bind("DELETE FROM "+s.Table+" WHERE expires_at <= ?"), time.Now().UTC())
+	return err
+}
+
+// OrderStore keeping orders in a TokenStore, for SetOrderStore on clients
+// spread over several frontends. Orders are kept for the token's lifetime.
+type TokenOrderStore struct {
+	Store TokenStore
+}
+
+func NewTokenOrderStore(store TokenStore) *TokenOrderStore {
+	return &TokenOrderStore{Store: store}
+}
+
+func (s *TokenOrderStore) Save(token string, order *StoredOrder) error {
+	data, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+	return s.Store.Put(token, data, CHECKOUT_TOKEN_LIFETIME)
+}
+
+func (s *TokenOrderStore) Load(token string) (*StoredOrder, error) {
+	data, err := s.Store.Get(token)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	order := new(StoredOrder)
+	if err = json.Unmarshal(data, order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (s *TokenOrderStore) Delete(token string) error {
+	return s.Store.Delete(token)
+}
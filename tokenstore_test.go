@@ -0,0 +1,70 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type mapRedis struct {
+	values map[string][]byte
+	ttls   map[string]time.Duration
+}
+
+func (r *mapRedis) SetEX(key string, value []byte, ttl time.Duration) error {
+	r.values[key] = value
+	r.ttls[key] = ttl
+	return nil
+}
+
+func (r *mapRedis) Get(key string) ([]byte, bool, error) {
+	value, found := r.values[key]
+	return value, found, nil
+}
+
+func (r *mapRedis) Del(key string) error {
+	delete(r.values, key)
+	return nil
+}
+
+func TestMemoryTokenStoreExpiry(t *testing.T) {
+	store := paypal.NewMemoryTokenStore()
+
+	store.Put("EC-1", []byte("order"), time.Millisecond)
+	if data, _ := store.Get("EC-1"); string(data) != "order" {
+		t.Fatalf("Expected EC-1 to be stored, got %q", data)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if data, _ := store.Get("EC-1"); data != nil {
+		t.Errorf("EC-1 should have expired after its ttl, got %q", data)
+	}
+}
+
+func TestTokenOrderStoreOverRedis(t *testing.T) {
+	redis := &mapRedis{values: map[string][]byte{}, ttls: map[string]time.Duration{}}
+	client := paypal.NewClient("user", "pass", "sig", true, &http.Client{Transport: nvpTransport(func(values url.Values) string {
+		return "ACK=Success&TOKEN=EC-SHARED"
+	})})
+	client.SetOrderStore(paypal.NewTokenOrderStore(paypal.NewRedisTokenStore(redis, "paypal:")))
+
+	order := paypal.PayPalOrder{SubTotal: 19.98, Total: 19.98, CurrencyCode: "USD", Description: "Order #7"}
+	if _, err := client.SetExpressCheckout(order, benchmarkGoods(1)); err != nil {
+		t.Fatalf("Unexpected error: %#v", err)
+	}
+	if len(redis.values["paypal:EC-SHARED"]) == 0 || redis.ttls["paypal:EC-SHARED"] != paypal.CHECKOUT_TOKEN_LIFETIME {
+		t.Fatalf("Order not stored under the prefixed token for the token lifetime: %#v", redis)
+	}
+
+	// another frontend sharing the same Redis completes the checkout
+	other := paypal.NewTokenOrderStore(paypal.NewRedisTokenStore(redis, "paypal:"))
+	stored, err := other.Load("EC-SHARED")
+	if err != nil || stored == nil || stored.Order.Description != "Order #7" || len(stored.Goods) != 1 {
+		t.Fatalf("Order not loaded back: %#v, %v", stored, err)
+	}
+	if missing, err := other.Load("EC-UNKNOWN"); missing != nil || err != nil {
+		t.Errorf("Expected nil, nil for an unknown token, got %#v, %v", missing, err)
+	}
+}
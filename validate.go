@@ -0,0 +1,79 @@
+package paypal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Returned by PayPalOrder.Validate for a Discount below zero, which
+// SetExpressCheckout would leave out of the totals it sends
+var ErrNegativeDiscount = errors.New("paypal: order discount must not be negative")
+
+// Returned by PayPalOrder.Validate when the order's amounts don't add up
+type CartMathError struct {
+	// NVP field holding the wrong amount
+	Field    string
+	Expected float64
+	Actual   float64
+}
+
+func (e *CartMathError) Error() string {
+	return fmt.Sprintf("PayPal order %s is %.2f but its parts add up to %.2f (off by %.2f)", e.Field, e.Actual, e.Expected, e.Actual-e.Expected)
+}
+
+// Returned before calling PayPal when a payment is for more than the
+// MaxAmount the buyer agreed to at checkout
+type MaxAmountError struct {
+	Token     string
+	Amount    float64
+	MaxAmount float64
+}
+
+func (e *MaxAmountError) Error() string {
+	return fmt.Sprintf("PayPal checkout %s allows at most %.2f, %.2f requested", e.Token, e.MaxAmount, e.Amount)
+}
+
+func checkMaxAmount(token string, amount, maxAmount float64) error {
+	if maxAmount > 0 && toCents(amount) > toCents(maxAmount) {
+		return &MaxAmountError{token, amount, maxAmount}
+	}
+	return nil
+}
+
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// Checks the totals SetExpressCheckout will send agree with each other, the
+// most common reason PayPal rejects a checkout:
+//
+//	ITEMAMT = Σ(item amount × quantity) [− Discount as a line item]
+//	AMT     = ITEMAMT + TAXAMT + SHIPPINGAMT + HANDLINGAMT + INSURANCEAMT [− Discount as SHIPDISCAMT]
+func (order *PayPalOrder) Validate(goods []PayPalGood) error {
+	if order.Discount < 0 {
+		return ErrNegativeDiscount
+	}
+
+	var itemCents int64
+	for _, good := range goods {
+		itemCents += toCents(good.Amount) * int64(good.Quantity)
+	}
+	if order.DiscountStrategy == DISCOUNT_LINE_ITEM {
+		itemCents -= toCents(order.Discount)
+	}
+
+	if toCents(order.SubTotal) != itemCents {
+		return &CartMathError{"PAYMENTREQUEST_0_ITEMAMT", float64(itemCents) / 100, order.SubTotal}
+	}
+
+	totalCents := toCents(order.SubTotal) + toCents(order.Tax) + toCents(order.Shipping) + toCents(order.Handling) + toCents(order.Insurance)
+	if order.DiscountStrategy == DISCOUNT_SHIPPING_DISCOUNT {
+		totalCents -= toCents(order.Discount)
+	}
+	if toCents(order.Total) != totalCents {
+		return &CartMathError{"PAYMENTREQUEST_0_AMT", float64(totalCents) / 100, order.Total}
+	}
+
+	return nil
+}
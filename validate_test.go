@@ -0,0 +1,77 @@
+package paypal_test
+
+import (
+	"../go-paypal"
+
+	"testing"
+)
+
+func TestOrderValidate(t *testing.T) {
+	goods := []paypal.PayPalGood{
+		{Name: "Shirt", Amount: 19.99, Quantity: 3},
+		{Name: "Socks", Amount: 0.10, Quantity: 7},
+	}
+
+	order := paypal.PayPalOrder{SubTotal: 55.67, Discount: 5, Tax: 4.45, Shipping: 7.5, Total: 67.62}
+	if err := order.Validate(goods); err != nil {
+		t.Errorf("Expected a valid order, got %v", err)
+	}
+
+	order.SubTotal = 60.67
+	err, ok := order.Validate(goods).(*paypal.CartMathError)
+	if !ok || err.Field != "PAYMENTREQUEST_0_ITEMAMT" || err.Expected != 55.67 {
+		t.Errorf("Expected an ITEMAMT mismatch, got %#v", err)
+	}
+
+	order.SubTotal = 55.67
+	order.Total = 63.17
+	err, ok = order.Validate(goods).(*paypal.CartMathError)
+	if !ok || err.Field != "PAYMENTREQUEST_0_AMT" || err.Expected != 67.62 {
+		t.Errorf("Expected an AMT mismatch, got %#v", err)
+	}
+}
+
+func TestOrderValidateShippingDiscount(t *testing.T) {
+	goods := []paypal.PayPalGood{{Name: "Shirt", Amount: 20, Quantity: 2}}
+
+	order := paypal.PayPalOrder{SubTotal: 40, Discount: 5, DiscountStrategy: paypal.DISCOUNT_SHIPPING_DISCOUNT, Shipping: 7.5, Total: 42.5}
+	if err := order.Validate(goods); err != nil {
+		t.Errorf("Expected a valid order, got %v", err)
+	}
+
+	order.SubTotal = 35
+	if err := order.Validate(goods); err == nil {
+		t.Errorf("SubTotal must not include a SHIPDISCAMT discount")
+	}
+}
+
+func TestOrderValidateNegativeDiscount(t *testing.T) {
+	goods := []paypal.PayPalGood{{Name: "Shirt", Amount: 20, Quantity: 2}}
+
+	// adds up if the discount were applied, but SetExpressCheckout leaves
+	// it out, so PayPal would see ITEMAMT=45 against items of 40
+	order := paypal.PayPalOrder{SubTotal: 45, Discount: -5, Total: 45}
+	if err := order.Validate(goods); err != paypal.ErrNegativeDiscount {
+		t.Errorf("Expected ErrNegativeDiscount, got %v", err)
+	}
+
+	order.DiscountStrategy = paypal.DISCOUNT_SHIPPING_DISCOUNT
+	order.SubTotal, order.Total = 40, 45
+	if err := order.Validate(goods); err != paypal.ErrNegativeDiscount {
+		t.Errorf("Expected ErrNegativeDiscount for a shipping discount, got %v", err)
+	}
+}
+
+func TestOrderValidateHandling(t *testing.T) {
+	goods := []paypal.PayPalGood{{Name: "Vase", Amount: 30, Quantity: 1}}
+
+	order := paypal.PayPalOrder{SubTotal: 30, Shipping: 5, Handling: 2.5, Total: 37.5}
+	if err := order.Validate(goods); err != nil {
+		t.Errorf("Expected a valid order, got %v", err)
+	}
+
+	order.Total = 35
+	if err := order.Validate(goods); err == nil {
+		t.Errorf("Expected the handling amount to be part of the total")
+	}
+}